@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultActiveDays = 30       // Files read within this many days count as active
+	activeFolder      = "Active" // Files read recently
+	staleFolder       = "Stale"  // Files nobody has read in a while
+)
+
+// OrganizeByAccess separates files read within activeFor from those that
+// haven't been read since, using the access times recorded by the scan. Access
+// times are often not kept up to date, so a warning explains when they may be off.
+func (fo *FileOrganizer) OrganizeByAccess(activeFor time.Duration) error {
+	if activeFor <= 0 {
+		activeFor = defaultActiveDays * 24 * time.Hour
+	}
+
+	fmt.Println("👆 Starting access-based organization...")
+	fmt.Println()
+
+	if caveat := atimeCaveat(fo.BasePath); caveat != "" {
+		warningColor.Printf("⚠️  Results may be inaccurate: %s\n", caveat)
+	}
+
+	cutoff := time.Now().Add(-activeFor)
+	accessGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		if file.LastAccessed.IsZero() {
+			if fo.Scanner.Verbose {
+				fmt.Printf("   🔎 %s: no access time recorded, leaving it in place\n", file.Name)
+			}
+			continue
+		}
+
+		folder := staleFolder
+		if file.LastAccessed.After(cutoff) {
+			folder = activeFolder
+		}
+		accessGroups[folder] = append(accessGroups[folder], file)
+	}
+
+	return fo.moveGroups(accessGroups, "👆", "access-based")
+}
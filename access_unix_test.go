@@ -0,0 +1,52 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOrganizeByAccess(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	files := map[string]time.Time{
+		"reading.pdf": now.Add(-2 * time.Hour),
+		"recent.txt":  now.Add(-5 * 24 * time.Hour),
+		"old.pdf":     now.Add(-90 * 24 * time.Hour),
+		"ancient.zip": now.Add(-400 * 24 * time.Hour),
+	}
+	modified := now.Add(-500 * 24 * time.Hour)
+	for name, accessed := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, accessed, modified); err != nil {
+			t.Fatalf("Failed to set times on %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByAccess(30 * 24 * time.Hour); err != nil {
+		t.Fatalf("OrganizeByAccess() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"reading.pdf": activeFolder,
+		"recent.txt":  activeFolder,
+		"old.pdf":     staleFolder,
+		"ancient.zip": staleFolder,
+	}
+	for name, folder := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder, name)); err != nil {
+			t.Errorf("Expected %s in %s: %v", name, folder, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// accessTime returns when a file was last read, from its stat info
+func accessTime(info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec), true
+}
+
+// atimeCaveat explains why access times under path may be inaccurate, or
+// returns "" if the filesystem keeps them up to date
+func atimeCaveat(path string) string {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return "couldn't check how the filesystem tracks access times"
+	}
+	if stat.Flags&unix.MNT_NOATIME != 0 {
+		return "the filesystem is mounted with noatime, so access times are never updated"
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// accessTime returns when a file was last read, from its stat info
+func accessTime(info os.FileInfo) (time.Time, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), true
+}
+
+// atimeCaveat explains why access times under path may be inaccurate, or
+// returns "" if the filesystem keeps them up to date
+func atimeCaveat(path string) string {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return "couldn't check how the filesystem tracks access times"
+	}
+	switch {
+	case int64(stat.Flags)&unix.ST_NOATIME != 0:
+		return "the filesystem is mounted with noatime, so access times are never updated"
+	case int64(stat.Flags)&unix.ST_RELATIME != 0:
+		return "the filesystem is mounted with relatime, so access times are only updated about once a day"
+	}
+	return ""
+}
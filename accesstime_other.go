@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns when a file was last read, which isn't available on this platform
+func accessTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// atimeCaveat explains why access times under path may be inaccurate
+func atimeCaveat(path string) string {
+	return "access times aren't available on this platform"
+}
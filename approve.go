@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Approver asks before each move or deletion in --dry-run-interactive mode.
+// A nil Approver approves everything without asking.
+type Approver struct {
+	Approved int // Changes the user approved
+	Skipped  int // Changes the user skipped
+
+	reader     *bufio.Reader
+	out        io.Writer
+	approveAll bool
+}
+
+// NewApprover creates an Approver that reads answers from in and prompts on out
+func NewApprover(in io.Reader, out io.Writer) *Approver {
+	return &Approver{
+		reader: bufio.NewReader(in),
+		out:    out,
+	}
+}
+
+// isTerminal reports whether a file is an interactive terminal rather than a pipe or file
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Approve asks whether to go ahead with an action: y approves it, n skips it,
+// and a approves it and everything after it. Running out of input skips.
+func (a *Approver) Approve(action string) bool {
+	if a == nil {
+		return true
+	}
+	if a.approveAll {
+		a.Approved++
+		return true
+	}
+
+	for {
+		fmt.Fprintf(a.out, "   ❓ %s? [y]es / [n]o / [a]ll: ", action)
+		line, err := a.reader.ReadString('\n')
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			a.Approved++
+			return true
+		case "a", "all":
+			a.approveAll = true
+			a.Approved++
+			return true
+		case "n", "no":
+			a.Skipped++
+			return false
+		}
+
+		if err != nil {
+			fmt.Fprintln(a.out)
+			a.Skipped++
+			return false
+		}
+		fmt.Fprintln(a.out, "   Please answer y, n or a")
+	}
+}
+
+// PrintSummary prints how many changes were approved and skipped
+func (a *Approver) PrintSummary() {
+	if a == nil {
+		return
+	}
+	infoColor.Printf("\n🙋 Approved %d changes, skipped %d\n", a.Approved, a.Skipped)
+}
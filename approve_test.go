@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApproverOrganize(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Approve a, skip b, answer nonsense then approve everything from c on
+	var prompts bytes.Buffer
+	approver := NewApprover(strings.NewReader("y\nn\nmaybe\na\n"), &prompts)
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.Approver = approver
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "c.txt", "d.txt", "e.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Documents", name)); err != nil {
+			t.Errorf("Expected approved %s to be moved: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "b.txt")); err != nil {
+		t.Errorf("Expected skipped b.txt to stay put: %v", err)
+	}
+
+	if approver.Approved != 4 || approver.Skipped != 1 {
+		t.Errorf("Expected 4 approved and 1 skipped, got %d and %d", approver.Approved, approver.Skipped)
+	}
+	if organizer.TotalMoved != 4 {
+		t.Errorf("Expected 4 files moved, got %d", organizer.TotalMoved)
+	}
+	// Four prompts: a, b, c twice (after the invalid answer), and nothing once "all" is given
+	if count := strings.Count(prompts.String(), "❓"); count != 4 {
+		t.Errorf("Expected 4 prompts, got %d", count)
+	}
+}
+
+func TestApproverDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"report.pdf", "report (1).pdf", "report (2).pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Skip the first removal, then run out of input, which skips the rest
+	approver := NewApprover(strings.NewReader("n\n"), &bytes.Buffer{})
+	handler := NewDuplicateHandler(scanner, false)
+	handler.Approver = approver
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected all 3 files to be kept, got %d", len(entries))
+	}
+	if handler.TotalRemoved != 0 {
+		t.Errorf("Expected nothing removed, got %d", handler.TotalRemoved)
+	}
+	if approver.Approved != 0 || approver.Skipped != 2 {
+		t.Errorf("Expected 0 approved and 2 skipped, got %d and %d", approver.Approved, approver.Skipped)
+	}
+}
+
+func TestNilApproverApproves(t *testing.T) {
+	var approver *Approver
+	if !approver.Approve("Move a.txt -> Documents") {
+		t.Error("Expected a nil Approver to approve everything")
+	}
+}
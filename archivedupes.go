@@ -0,0 +1,67 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// archiveHashPrefix marks a hash taken from an archive's content rather than its bytes
+const archiveHashPrefix = "zip:"
+
+// archiveFingerprint hashes what a zip holds instead of the zip itself: the
+// sorted list of entry names, sizes and content hashes. Two archives of the
+// same files match even if they were zipped at different times or with
+// different compression. Archives too big to safely unpack are refused.
+func archiveFingerprint(path string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open zip file: %v", err)
+	}
+	defer r.Close()
+
+	if len(r.File) > defaultMaxZipEntries {
+		return "", fmt.Errorf("zip file has too many entries (%d)", len(r.File))
+	}
+	totalSize := uint64(0)
+	for _, f := range r.File {
+		totalSize += f.UncompressedSize64
+	}
+	if totalSize > defaultMaxZipSize*10 {
+		return "", fmt.Errorf("zip file would expand to too large size (%d bytes)", totalSize)
+	}
+
+	entries := make([]string, 0, len(r.File))
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %v", f.Name, err)
+		}
+		hash := md5.New()
+		size, err := io.Copy(hash, io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+		rc.Close()
+		if err != nil {
+			return "", fmt.Errorf("cannot read %s: %v", f.Name, err)
+		}
+		entries = append(entries, fmt.Sprintf("%s\x00%d\x00%s", f.Name, size, hex.EncodeToString(hash.Sum(nil))))
+	}
+	sort.Strings(entries)
+
+	fingerprint := md5.Sum([]byte(strings.Join(entries, "\n")))
+	return archiveHashPrefix + hex.EncodeToString(fingerprint[:]), nil
+}
+
+// contentHash recomputes a scanned file's hash the same way the scan did
+func (s *Scanner) contentHash(file FileInfo) (string, error) {
+	if strings.HasPrefix(file.Hash, archiveHashPrefix) {
+		return archiveFingerprint(file.Path)
+	}
+	return s.calculateFileHash(file.Path)
+}
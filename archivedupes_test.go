@@ -0,0 +1,91 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeZipWith zips the files in the given order, stamped with modified and
+// stored with the given compression method
+func writeZipWith(t *testing.T, path string, names []string, files map[string]string, modified time.Time, method uint16) {
+	t.Helper()
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, name := range names {
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := writer.Write([]byte(files[name])); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to finish zip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+}
+
+func TestCompareArchiveContents(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"photos/beach.jpg": "sand and sea",
+		"notes.txt":        "remember the sunscreen",
+	}
+	first := filepath.Join(tmpDir, "holiday.zip")
+	second := filepath.Join(tmpDir, "holiday-again.zip")
+	writeZipWith(t, first, []string{"notes.txt", "photos/beach.jpg"}, files, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), zip.Deflate)
+	writeZipWith(t, second, []string{"photos/beach.jpg", "notes.txt"}, files, time.Date(2024, 6, 1, 18, 30, 0, 0, time.UTC), zip.Store)
+
+	// A zip with the same names but different content must not match
+	other := map[string]string{"photos/beach.jpg": "snow and ski", "notes.txt": "remember the sunscreen"}
+	writeZipWith(t, filepath.Join(tmpDir, "winter.zip"), []string{"notes.txt", "photos/beach.jpg"}, other, time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), zip.Deflate)
+
+	firstData, _ := os.ReadFile(first)
+	secondData, _ := os.ReadFile(second)
+	if bytes.Equal(firstData, secondData) {
+		t.Fatal("Test archives should differ byte for byte")
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected no duplicates without CompareArchiveContents, got %d groups", len(scanner.Duplicates))
+	}
+
+	scanner = NewScanner()
+	scanner.CompareArchiveContents = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Duplicates) != 1 {
+		t.Fatalf("Expected 1 duplicate group, got %d", len(scanner.Duplicates))
+	}
+	for _, group := range scanner.Duplicates {
+		if len(group) != 2 || group[0].Path == group[1].Path {
+			t.Fatalf("Expected both holiday zips in the group, got %+v", group)
+		}
+		for _, file := range group {
+			if file.Path != first && file.Path != second {
+				t.Errorf("Unexpected file in the group: %s", file.Path)
+			}
+		}
+	}
+
+	// The kept copy is verified by its content fingerprint, so removal goes ahead
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicatesByPattern(); err != nil {
+		t.Fatalf("RemoveDuplicatesByPattern() error = %v", err)
+	}
+	if handler.TotalRemoved != 1 {
+		t.Errorf("Expected 1 zip removed, got %d", handler.TotalRemoved)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FolderWaste is how much space duplicates take up in one folder
+type FolderWaste struct {
+	Folder         string   `json:"folder"`
+	WastedBytes    int64    `json:"wasted_bytes"`
+	RedundantFiles []string `json:"redundant_files"`
+}
+
+// DuplicateAudit is a read-only breakdown of the space duplicates waste
+type DuplicateAudit struct {
+	TotalWastedBytes int64         `json:"total_wasted_bytes"`
+	RedundantFiles   int           `json:"redundant_files"`
+	Folders          []FolderWaste `json:"folders"`
+}
+
+// Audit works out which files are redundant and how much space they waste in
+// each folder, without touching anything. In each group the copy that
+// RemoveDuplicates would keep is not counted as waste.
+func (dh *DuplicateHandler) Audit() DuplicateAudit {
+	byFolder := make(map[string]*FolderWaste)
+	var audit DuplicateAudit
+
+	for _, files := range dh.duplicateGroups() {
+		if len(files) < 2 {
+			continue
+		}
+		keep := dh.keeper(files)
+		for _, file := range files {
+			if file.Path == keep.Path {
+				continue
+			}
+			folder := filepath.Dir(file.Path)
+			waste, ok := byFolder[folder]
+			if !ok {
+				waste = &FolderWaste{Folder: folder}
+				byFolder[folder] = waste
+			}
+			waste.WastedBytes += file.Size
+			waste.RedundantFiles = append(waste.RedundantFiles, file.Path)
+			audit.TotalWastedBytes += file.Size
+			audit.RedundantFiles++
+		}
+	}
+
+	audit.Folders = make([]FolderWaste, 0, len(byFolder))
+	for _, waste := range byFolder {
+		sort.Strings(waste.RedundantFiles)
+		audit.Folders = append(audit.Folders, *waste)
+	}
+
+	// Most wasteful folders first
+	sort.Slice(audit.Folders, func(i, j int) bool {
+		if audit.Folders[i].WastedBytes != audit.Folders[j].WastedBytes {
+			return audit.Folders[i].WastedBytes > audit.Folders[j].WastedBytes
+		}
+		return audit.Folders[i].Folder < audit.Folders[j].Folder
+	})
+	return audit
+}
+
+// RelativeTo returns a copy of the audit with its paths shown relative to base,
+// so a shared report doesn't give away where the files live. Paths outside
+// base are left absolute.
+func (audit DuplicateAudit) RelativeTo(base string) DuplicateAudit {
+	relative := audit
+	relative.Folders = make([]FolderWaste, len(audit.Folders))
+	for i, folder := range audit.Folders {
+		folder.Folder = relativePath(base, folder.Folder)
+		files := make([]string, len(folder.RedundantFiles))
+		for j, path := range folder.RedundantFiles {
+			files[j] = relativePath(base, path)
+		}
+		folder.RedundantFiles = files
+		relative.Folders[i] = folder
+	}
+	return relative
+}
+
+// relativePath returns path relative to base, or the absolute path if it isn't inside base
+func relativePath(base, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// Print prints the audit as a readable report
+func (audit DuplicateAudit) Print() {
+	fmt.Println("\n🔎 Duplicate audit (nothing was moved or deleted):")
+	if audit.RedundantFiles == 0 {
+		successColor.Printf("✅ No space wasted by duplicates!\n")
+		return
+	}
+
+	for _, folder := range audit.Folders {
+		infoColor.Printf("📂 %s: %.2f MB wasted by %d files\n", folder.Folder, float64(folder.WastedBytes)/1024/1024, len(folder.RedundantFiles))
+		for _, path := range folder.RedundantFiles {
+			fmt.Printf("   - %s\n", filepath.Base(path))
+		}
+	}
+
+	fmt.Println()
+	successColor.Printf("💾 %d redundant files waste %.2f MB in total\n", audit.RedundantFiles, float64(audit.TotalWastedBytes)/1024/1024)
+}
+
+// WriteJSON writes the audit as indented JSON
+func (audit DuplicateAudit) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(audit)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDuplicateAudit(t *testing.T) {
+	tmpDir := t.TempDir()
+	photos := filepath.Join(tmpDir, "photos")
+	backup := filepath.Join(tmpDir, "backup")
+
+	// The newest copy of each file is the one that would be kept
+	now := time.Now()
+	files := []struct {
+		path    string
+		content string
+		age     time.Duration
+	}{
+		{filepath.Join(photos, "beach.jpg"), "beach photo data", 0},
+		{filepath.Join(backup, "beach.jpg"), "beach photo data", time.Hour},
+		{filepath.Join(backup, "beach (1).jpg"), "beach photo data", 2 * time.Hour},
+		{filepath.Join(backup, "report.pdf"), "quarterly report", 0},
+		{filepath.Join(photos, "report.pdf"), "quarterly report", time.Hour},
+		{filepath.Join(photos, "unique.txt"), "only one of me", 0},
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", f.path, err)
+		}
+		modTime := now.Add(-f.age)
+		if err := os.Chtimes(f.path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	audit := NewDuplicateHandler(scanner, false).Audit()
+
+	beachSize := int64(len("beach photo data"))
+	reportSize := int64(len("quarterly report"))
+	if audit.TotalWastedBytes != 2*beachSize+reportSize {
+		t.Errorf("Expected %d bytes wasted in total, got %d", 2*beachSize+reportSize, audit.TotalWastedBytes)
+	}
+	if audit.RedundantFiles != 3 {
+		t.Errorf("Expected 3 redundant files, got %d", audit.RedundantFiles)
+	}
+
+	wasted := make(map[string]FolderWaste)
+	for _, folder := range audit.Folders {
+		wasted[folder.Folder] = folder
+	}
+	if len(wasted) != 2 {
+		t.Fatalf("Expected waste in 2 folders, got %d", len(wasted))
+	}
+	if got := wasted[backup]; got.WastedBytes != 2*beachSize || len(got.RedundantFiles) != 2 {
+		t.Errorf("backup: expected %d bytes in 2 files, got %d bytes in %d files", 2*beachSize, got.WastedBytes, len(got.RedundantFiles))
+	}
+	if got := wasted[photos]; got.WastedBytes != reportSize || len(got.RedundantFiles) != 1 {
+		t.Errorf("photos: expected %d bytes in 1 file, got %d bytes in %d files", reportSize, got.WastedBytes, len(got.RedundantFiles))
+	}
+
+	// Nothing may be touched
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err != nil {
+			t.Errorf("Expected %s to still exist: %v", f.path, err)
+		}
+	}
+
+	// The JSON report carries the same figures
+	var buf bytes.Buffer
+	if err := audit.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded DuplicateAudit
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON report: %v", err)
+	}
+	if decoded.TotalWastedBytes != audit.TotalWastedBytes || len(decoded.Folders) != 2 {
+		t.Errorf("JSON report doesn't match: %+v", decoded)
+	}
+	if decoded.Folders[0].Folder != backup {
+		t.Errorf("Expected the most wasteful folder first, got %s", decoded.Folders[0].Folder)
+	}
+}
+
+func TestDuplicateAuditRelativeTo(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	audit := DuplicateAudit{
+		TotalWastedBytes: 30,
+		RedundantFiles:   3,
+		Folders: []FolderWaste{
+			{Folder: filepath.Join(root, "backup"), WastedBytes: 20, RedundantFiles: []string{
+				filepath.Join(root, "backup", "a.jpg"),
+				filepath.Join(root, "backup", "b.jpg"),
+			}},
+			{Folder: outside, WastedBytes: 10, RedundantFiles: []string{filepath.Join(outside, "c.jpg")}},
+		},
+	}
+
+	relative := audit.RelativeTo(root)
+	if got := relative.Folders[0]; got.Folder != "backup" || got.RedundantFiles[0] != filepath.Join("backup", "a.jpg") || got.RedundantFiles[1] != filepath.Join("backup", "b.jpg") {
+		t.Errorf("Expected paths inside the base to be relative, got %+v", got)
+	}
+	if got := relative.Folders[1]; got.Folder != outside || got.RedundantFiles[0] != filepath.Join(outside, "c.jpg") {
+		t.Errorf("Expected paths outside the base to stay absolute, got %+v", got)
+	}
+
+	// The original report is left alone
+	if audit.Folders[0].RedundantFiles[0] != filepath.Join(root, "backup", "a.jpg") {
+		t.Errorf("RelativeTo() changed the original report: %+v", audit.Folders[0])
+	}
+}
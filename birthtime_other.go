@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "time"
+
+// birthTime returns when a file was created, which this platform doesn't record
+func birthTime(path string) (time.Time, bool) {
+	return time.Time{}, false
+}
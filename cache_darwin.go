@@ -0,0 +1,19 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseNoCache hints that a file will be read once sequentially,
+// so it shouldn't push other data out of the page cache
+func adviseNoCache(file *os.File) {
+	_, _ = unix.FcntlInt(file.Fd(), unix.F_NOCACHE, 1)
+}
+
+// dropFromCache asks the kernel to evict a file's pages after it has been read.
+// F_NOCACHE already keeps them out of the cache on macOS.
+func dropFromCache(file *os.File) {}
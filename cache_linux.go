@@ -0,0 +1,20 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// adviseNoCache hints that a file will be read once sequentially,
+// so it shouldn't push other data out of the page cache
+func adviseNoCache(file *os.File) {
+	_ = unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_SEQUENTIAL)
+}
+
+// dropFromCache asks the kernel to evict a file's pages after it has been read
+func dropFromCache(file *os.File) {
+	_ = unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os"
+
+// adviseNoCache hints that a file will be read once sequentially.
+// There is no portable way to do this on this platform.
+func adviseNoCache(file *os.File) {}
+
+// dropFromCache asks the kernel to evict a file's pages after it has been read.
+// There is no portable way to do this on this platform.
+func dropFromCache(file *os.File) {}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CategoryRule is the effective rule for one category: where its files go and
+// which extensions put them there
+type CategoryRule struct {
+	Category   string   `json:"category"`
+	Folder     string   `json:"folder"`
+	Extensions []string `json:"extensions"`
+}
+
+// CategoryRules is the full category map, sorted by category
+type CategoryRules []CategoryRule
+
+// ResolvedCategories returns the category map a scan and organize would use
+// with this config, built the same way so it can't drift from what runs
+func (c *Config) ResolvedCategories() CategoryRules {
+	scanner := NewScanner()
+	c.ApplyToScanner(scanner)
+	organizer := NewFileOrganizer(scanner, true, "")
+	c.ApplyToOrganizer(organizer)
+
+	byCategory := make(map[string]*CategoryRule)
+	rule := func(category string) *CategoryRule {
+		if byCategory[category] == nil {
+			byCategory[category] = &CategoryRule{Category: category, Folder: organizer.categoryFolder(category), Extensions: []string{}}
+		}
+		return byCategory[category]
+	}
+
+	for category := range organizer.CategoryMap {
+		rule(category)
+	}
+	for ext, category := range scanner.ExtensionCategories {
+		if _, ok := scanner.ExtensionOverrides[ext]; !ok {
+			rule(category).Extensions = append(rule(category).Extensions, ext)
+		}
+	}
+	for ext, folder := range scanner.ExtensionOverrides {
+		category, _ := scanner.folderCategory(folder)
+		rule(category).Extensions = append(rule(category).Extensions, ext)
+	}
+
+	rules := make(CategoryRules, 0, len(byCategory))
+	for _, r := range byCategory {
+		sort.Strings(r.Extensions)
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Category < rules[j].Category })
+	return rules
+}
+
+// Print lists each category with its folder and extensions
+func (rules CategoryRules) Print() {
+	infoColor.Printf("🗂️  Categories (%d):\n", len(rules))
+	for _, r := range rules {
+		fmt.Printf("  %s -> %s/\n", r.Category, r.Folder)
+		if len(r.Extensions) == 0 {
+			fmt.Println("     (no extensions: picked by file name, or for anything else)")
+			continue
+		}
+		fmt.Printf("     %s\n", strings.Join(r.Extensions, " "))
+	}
+}
+
+// WriteJSON writes the rules as indented JSON
+func (rules CategoryRules) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rules)
+}
@@ -0,0 +1,20 @@
+package main
+
+import "github.com/fatih/color"
+
+// Colors used for all output. Turning color off in configureColor covers every one of them.
+var (
+	successColor = color.New(color.FgGreen, color.Bold)
+	infoColor    = color.New(color.FgCyan)
+	warningColor = color.New(color.FgYellow)
+	errorColor   = color.New(color.FgRed, color.Bold)
+)
+
+// configureColor turns colored output off when asked to, or when the console
+// would print the escape codes instead of colors. Non-terminals and NO_COLOR
+// are already handled by the color library.
+func configureColor(disable bool) {
+	if disable || !consoleSupportsANSI() {
+		color.NoColor = true
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// consoleSupportsANSI reports whether the console understands ANSI color codes.
+// Terminals outside Windows all do.
+func consoleSupportsANSI() bool {
+	return true
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// consoleSupportsANSI reports whether the console understands ANSI color codes,
+// switching on virtual terminal processing where Windows 10 and later allow it
+func consoleSupportsANSI() bool {
+	handle := windows.Handle(os.Stdout.Fd())
+	return probeANSI(
+		func() (uint32, error) {
+			var mode uint32
+			err := windows.GetConsoleMode(handle, &mode)
+			return mode, err
+		},
+		func(mode uint32) error { return windows.SetConsoleMode(handle, mode) },
+	)
+}
+
+// probeANSI decides from the console mode whether ANSI codes will work. Output
+// that isn't a console (a pipe, or a terminal like mintty) is left alone, and
+// legacy consoles that refuse virtual terminal processing get no color.
+func probeANSI(getMode func() (uint32, error), setMode func(uint32) error) bool {
+	mode, err := getMode()
+	if err != nil {
+		return true
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	return setMode(mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestProbeANSI(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    uint32
+		getErr  error
+		setErr  error
+		want    bool
+		wantSet bool
+	}{
+		{name: "not a console", getErr: errors.New("invalid handle"), want: true},
+		{name: "already enabled", mode: windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING, want: true},
+		{name: "enabled by probe", mode: windows.ENABLE_PROCESSED_OUTPUT, want: true, wantSet: true},
+		{name: "legacy console", mode: windows.ENABLE_PROCESSED_OUTPUT, setErr: errors.New("invalid parameter"), want: false, wantSet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := false
+			got := probeANSI(
+				func() (uint32, error) { return tt.mode, tt.getErr },
+				func(mode uint32) error {
+					set = true
+					if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING == 0 {
+						t.Errorf("Expected virtual terminal processing to be requested, got mode %#x", mode)
+					}
+					return tt.setErr
+				},
+			)
+			if got != tt.want {
+				t.Errorf("probeANSI() = %v, want %v", got, tt.want)
+			}
+			if set != tt.wantSet {
+				t.Errorf("SetConsoleMode called = %v, want %v", set, tt.wantSet)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Config holds the user's category rules, read from config.json in the config directory
+type Config struct {
+	Folders            map[string]string   `json:"folders"`
+	Extensions         map[string][]string `json:"extensions"`
+	ExtensionOverrides map[string]string   `json:"extension_overrides"` // Extension to folder, winning over every category rule
+	SizeBuckets        []sizeBucket        `json:"size_buckets"`
+	IgnorePatterns     []string            `json:"ignore_patterns"`
+	Originality        *OriginalityWeights `json:"originality_weights"`
+}
+
+// defaultConfig returns the built-in rules as a Config
+func defaultConfig() *Config {
+	extensions := make(map[string][]string, len(defaultCategoryExtensions))
+	for category, exts := range defaultCategoryExtensions {
+		extensions[category] = append([]string(nil), exts...)
+	}
+	weights := defaultOriginalityWeights
+	return &Config{
+		Folders:            defaultCategoryFolders(),
+		Extensions:         extensions,
+		ExtensionOverrides: map[string]string{},
+		SizeBuckets:        append([]sizeBucket(nil), sizeCategories...),
+		IgnorePatterns:     []string{},
+		Originality:        &weights,
+	}
+}
+
+// getConfigPath returns the path of the user's config file
+func getConfigPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "config.json"), nil
+}
+
+// LoadConfig reads a config file, falling back to the built-in rules for any
+// section it leaves out. Lines starting with // are treated as comments.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	var stripped bytes.Buffer
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(lines.Text()), "//") {
+			continue
+		}
+		stripped.WriteString(lines.Text())
+		stripped.WriteByte('\n')
+	}
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	config := &Config{}
+	decoder := json.NewDecoder(&stripped)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("cannot parse config file %s: %v", path, err)
+	}
+
+	defaults := defaultConfig()
+	if config.Folders == nil {
+		config.Folders = defaults.Folders
+	}
+	if config.Extensions == nil {
+		config.Extensions = defaults.Extensions
+	}
+	if config.ExtensionOverrides == nil {
+		config.ExtensionOverrides = defaults.ExtensionOverrides
+	}
+	if config.SizeBuckets == nil {
+		config.SizeBuckets = defaults.SizeBuckets
+	}
+	if config.IgnorePatterns == nil {
+		config.IgnorePatterns = defaults.IgnorePatterns
+	}
+	if config.Originality == nil {
+		config.Originality = defaults.Originality
+	}
+
+	overrides := make(map[string]string, len(config.ExtensionOverrides))
+	for name, dest := range config.ExtensionOverrides {
+		ext := strings.ToLower(strings.TrimSpace(name))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		folder := filepath.Clean(filepath.FromSlash(strings.TrimSpace(dest)))
+		if ext == "" || ext == "." || folder == "." || !filepath.IsLocal(folder) {
+			return nil, fmt.Errorf("invalid config file %s: extension override %q -> %q needs an extension and a folder inside the one being organized", path, name, dest)
+		}
+		overrides[ext] = folder
+	}
+	config.ExtensionOverrides = overrides
+
+	for _, bucket := range config.SizeBuckets {
+		if bucket.Name == "" {
+			return nil, fmt.Errorf("invalid config file %s: every size bucket needs a name", path)
+		}
+	}
+	for _, pattern := range config.IgnorePatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: bad ignore pattern %q", path, pattern)
+		}
+	}
+	if w := config.Originality; w.NoCopyMarker < 0 || w.ShortName < 0 || w.OldModTime < 0 {
+		return nil, fmt.Errorf("invalid config file %s: originality weights can't be negative", path)
+	}
+	return config, nil
+}
+
+// loadUserConfig loads the user's config file, or the built-in rules if there isn't one
+func loadUserConfig() (*Config, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return defaultConfig(), nil
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return defaultConfig(), nil
+	}
+	return LoadConfig(configPath)
+}
+
+// ApplyToScanner makes the scanner use the config's extension rules, extension
+// overrides and ignore patterns
+func (c *Config) ApplyToScanner(s *Scanner) {
+	s.ExtensionCategories = extensionIndex(c.Extensions)
+	s.ExtensionOverrides = c.ExtensionOverrides
+	s.IgnorePatterns = append(s.IgnorePatterns, c.IgnorePatterns...)
+}
+
+// ApplyToOrganizer makes the organizer use the config's folders and size buckets
+func (c *Config) ApplyToOrganizer(fo *FileOrganizer) {
+	for category, folder := range c.Folders {
+		fo.CategoryMap[category] = folder
+	}
+	fo.SizeBuckets = c.SizeBuckets
+}
+
+// ApplyToDuplicateHandler makes pattern-based removal use the config's originality weights
+func (c *Config) ApplyToDuplicateHandler(dh *DuplicateHandler) {
+	dh.Weights = c.Originality
+}
+
+// WriteDefaultConfig writes a commented config file holding the built-in rules,
+// refusing to replace an existing one unless force is set
+func WriteDefaultConfig(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create config directory: %v", err)
+	}
+	if err := os.WriteFile(path, renderConfig(defaultConfig()), 0644); err != nil {
+		return fmt.Errorf("cannot write config file: %v", err)
+	}
+	return nil
+}
+
+// renderConfig formats a config as JSON with explanatory comments
+func renderConfig(config *Config) []byte {
+	var buf bytes.Buffer
+	quote := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	list := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	sortedKeys := func(m map[string]string) []string {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	buf.WriteString("// elf-cli configuration\n")
+	buf.WriteString("// Lines starting with // are comments. Remove a section to go back to the built-in rules for it.\n")
+	buf.WriteString("{\n")
+
+	buf.WriteString("  // Folder each category is moved into, relative to the organized folder\n")
+	buf.WriteString("  \"folders\": {\n")
+	keys := sortedKeys(config.Folders)
+	for i, category := range keys {
+		buf.WriteString(fmt.Sprintf("    %s: %s%s\n", quote(category), quote(config.Folders[category]), comma(i, len(keys))))
+	}
+	buf.WriteString("  },\n\n")
+
+	buf.WriteString("  // Extensions that belong to each category. Anything not listed goes to Other,\n")
+	buf.WriteString("  // unless its name mentions \"install\", \"setup\", \"manual\" or \"guide\"\n")
+	buf.WriteString("  \"extensions\": {\n")
+	keys = keys[:0]
+	for category := range config.Extensions {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	for i, category := range keys {
+		buf.WriteString(fmt.Sprintf("    %s: %s%s\n", quote(category), list(config.Extensions[category]), comma(i, len(keys))))
+	}
+	buf.WriteString("  },\n\n")
+
+	buf.WriteString("  // Folders for single extensions, like {\".torrent\": \"Torrents\"}. These win over the categories\n")
+	buf.WriteString("  // above and over plugins, so files with these extensions always go to that folder\n")
+	buf.WriteString("  \"extension_overrides\": {")
+	keys = sortedKeys(config.ExtensionOverrides)
+	for i, ext := range keys {
+		buf.WriteString(fmt.Sprintf("\n    %s: %s%s", quote(ext), quote(config.ExtensionOverrides[ext]), comma(i, len(keys))))
+	}
+	if len(keys) > 0 {
+		buf.WriteString("\n  ")
+	}
+	buf.WriteString("},\n\n")
+
+	buf.WriteString("  // Size ranges used by --organize-by-size, in bytes. A max_bytes of -1 means no upper limit\n")
+	buf.WriteString("  \"size_buckets\": [\n")
+	for i, bucket := range config.SizeBuckets {
+		buf.WriteString(fmt.Sprintf("    {\"name\": %s, \"min_bytes\": %d, \"max_bytes\": %d}%s\n", quote(bucket.Name), bucket.Min, bucket.Max, comma(i, len(config.SizeBuckets))))
+	}
+	buf.WriteString("  ],\n\n")
+
+	buf.WriteString("  // Filename patterns to leave alone, like \"*.part\" or \"keep-*\". Hidden files are always skipped\n")
+	buf.WriteString(fmt.Sprintf("  \"ignore_patterns\": %s,\n\n", list(config.IgnorePatterns)))
+
+	buf.WriteString("  // How --pattern-duplicates scores which copy is the original; the highest score is kept.\n")
+	buf.WriteString("  // The shortest name and the oldest copy get the full short_name and old_mod_time weights\n")
+	weights := config.Originality
+	buf.WriteString(fmt.Sprintf("  \"originality_weights\": {\"no_copy_marker\": %s, \"short_name\": %s, \"old_mod_time\": %s}\n",
+		quote(weights.NoCopyMarker), quote(weights.ShortName), quote(weights.OldModTime)))
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// comma returns the separator that follows item i of n in a JSON list
+func comma(i, n int) string {
+	if i < n-1 {
+		return ","
+	}
+	return ""
+}
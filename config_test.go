@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteDefaultConfigRoundTrip(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "elf-cli", "config.json")
+
+	if err := WriteDefaultConfig(configPath, false); err != nil {
+		t.Fatalf("WriteDefaultConfig() error = %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(config, defaultConfig()) {
+		t.Errorf("Generated config parsed to %+v, want %+v", config, defaultConfig())
+	}
+
+	// The scanner should behave exactly as it does without a config
+	scanner := NewScanner()
+	config.ApplyToScanner(scanner)
+	if !reflect.DeepEqual(scanner.ExtensionCategories, defaultExtensionCategories) {
+		t.Error("Expected the default config to produce the built-in extension rules")
+	}
+}
+
+func TestWriteDefaultConfigRefusesOverwrite(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	if err := WriteDefaultConfig(configPath, false); err == nil {
+		t.Error("Expected an error when the config already exists")
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != "{}" {
+		t.Errorf("Existing config was changed: %s", data)
+	}
+
+	if err := WriteDefaultConfig(configPath, true); err != nil {
+		t.Fatalf("WriteDefaultConfig() with force error = %v", err)
+	}
+	if _, err := LoadConfig(configPath); err != nil {
+		t.Errorf("LoadConfig() error after forced write = %v", err)
+	}
+}
+
+func TestLoadConfigOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	data := `// Only override a few things
+{
+  "folders": {"Documents": "Paperwork"},
+  "extensions": {"Documents": [".pdf", ".md"]},
+  "ignore_patterns": ["*.part"]
+}
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(config.SizeBuckets, sizeCategories) || *config.Originality != defaultOriginalityWeights {
+		t.Error("Expected missing sections to fall back to the built-in rules")
+	}
+
+	downloads := filepath.Join(tmpDir, "Downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, name := range []string{"notes.md", "movie.mp4.part", "photo.jpg"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	config.ApplyToScanner(scanner)
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 2 {
+		t.Errorf("Expected the ignored file to be left out of the scan, got %d files", len(scanner.Files))
+	}
+
+	organizer := NewFileOrganizer(scanner, false, downloads)
+	config.ApplyToOrganizer(organizer)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// photo.jpg has no extension rule any more, so it ends up in Other
+	for _, path := range []string{"Paperwork/notes.md", "movie.mp4.part", "Other/photo.jpg"} {
+		if _, err := os.Stat(filepath.Join(downloads, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+}
+
+func TestExtensionOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	data := `{
+  "folders": {"Documents": "Paperwork"},
+  "extension_overrides": {"torrent": "Torrents", ".PDF": "Receipts"}
+}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if want := map[string]string{".torrent": "Torrents", ".pdf": "Receipts"}; !reflect.DeepEqual(config.ExtensionOverrides, want) {
+		t.Errorf("ExtensionOverrides = %v, want %v", config.ExtensionOverrides, want)
+	}
+
+	downloads := filepath.Join(tmpDir, "Downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, name := range []string{"ubuntu.iso.torrent", "invoice.pdf", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	config.ApplyToScanner(scanner)
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, downloads)
+	config.ApplyToOrganizer(organizer)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// The overrides win over the Documents category, which notes.txt still follows
+	for _, path := range []string{"Torrents/ubuntu.iso.torrent", "Receipts/invoice.pdf", "Paperwork/notes.txt"} {
+		if _, err := os.Stat(filepath.Join(downloads, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+
+	rules := make(map[string]CategoryRule)
+	for _, rule := range config.ResolvedCategories() {
+		rules[rule.Category] = rule
+	}
+	if got := rules["Torrents"]; got.Folder != "Torrents" || !reflect.DeepEqual(got.Extensions, []string{".torrent"}) {
+		t.Errorf("Torrents rule = %+v, want .torrent going to Torrents", got)
+	}
+
+	// An override can't send files outside the organized folder
+	if err := os.WriteFile(configPath, []byte(`{"extension_overrides": {".torrent": "../Torrents"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected an error for an override outside the organized folder")
+	}
+}
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"folder": {"Images": "Pictures"}}`), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected an error for a misspelled section")
+	}
+}
+
+func TestResolvedCategories(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+  "folders": {"Images": "Pictures"},
+  "extensions": {"Images": [".jpg", ".png"], "Documents": [".pdf", ".webp"]}
+}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	rules := make(map[string]CategoryRule)
+	for _, rule := range config.ResolvedCategories() {
+		rules[rule.Category] = rule
+	}
+
+	if got := rules["Documents"].Extensions; !reflect.DeepEqual(got, []string{".pdf", ".webp"}) {
+		t.Errorf("Documents extensions = %v, want the config's .pdf and .webp", got)
+	}
+	if got := rules["Images"]; got.Folder != "Pictures" || !reflect.DeepEqual(got.Extensions, []string{".jpg", ".png"}) {
+		t.Errorf("Images rule = %+v, want .jpg and .png going to Pictures", got)
+	}
+	// Folders the config leaves out keep their built-in names
+	if got := rules["Videos"]; got.Folder != "Videos" || len(got.Extensions) != 0 {
+		t.Errorf("Videos rule = %+v, want the built-in folder and no extensions", got)
+	}
+}
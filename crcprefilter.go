@@ -0,0 +1,71 @@
+package main
+
+import "fmt"
+
+// hashCandidates fills in the hashes of files that could have a duplicate,
+// for a scan with CRCPrefilter set. Files are grouped by size, and each file
+// in a group of two or more gets a CRC-32C, which CPUs compute in hardware and
+// which is far cheaper than MD5. Files whose CRCs differ can't be the same, so
+// only files still sharing a size and CRC are fully hashed. Zips compared by
+// content can match zips of any size, so they are always hashed.
+func (s *Scanner) hashCandidates() {
+	var candidates []int
+	bySize := make(map[int64][]int)
+	for i, file := range s.Files {
+		if s.CompareArchiveContents && file.IsZip {
+			candidates = append(candidates, i)
+			continue
+		}
+		bySize[file.Size] = append(bySize[file.Size], i)
+	}
+
+	type sizeCRC struct {
+		size int64
+		crc  string
+	}
+	byCRC := make(map[sizeCRC][]int)
+	for size, indexes := range bySize {
+		if len(indexes) < 2 {
+			continue
+		}
+		for _, i := range indexes {
+			checksums, err := s.calculateFileHashes(s.Files[i].Path, []string{"crc32c"})
+			if err != nil {
+				if s.Deadline.Expired() {
+					s.DeadlineReached = true
+					continue
+				}
+				fmt.Printf("⚠️  Could not calculate hash for %s: %v\n", s.Files[i].Path, err)
+				continue
+			}
+			key := sizeCRC{size: size, crc: checksums["crc32c"]}
+			byCRC[key] = append(byCRC[key], i)
+		}
+	}
+	for _, indexes := range byCRC {
+		if len(indexes) > 1 {
+			candidates = append(candidates, indexes...)
+		}
+	}
+
+	hashed := 0
+	for _, i := range candidates {
+		file := &s.Files[i]
+		file.Hash, file.Hashes = s.hashFile(file.Path, file.Name, file.Extension)
+		// Hashing was cut short, so the remaining candidates go unhashed
+		if s.Deadline.Expired() {
+			file.Hash, file.Hashes = "", nil
+			s.DeadlineReached = true
+			break
+		}
+		for j := range s.Categories[file.Category] {
+			if categorized := &s.Categories[file.Category][j]; categorized.Path == file.Path {
+				categorized.Hash, categorized.Hashes = file.Hash, file.Hashes
+				break
+			}
+		}
+		hashed++
+	}
+
+	fmt.Printf("⚡ CRC32 pre-filter: fully hashed %d of %d files\n", hashed, len(s.Files))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCRCPrefilter(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"report.pdf":      "quarterly numbers",
+		"report (1).pdf":  "quarterly numbers",
+		"same-size.pdf":   "quarterly NUMBERS", // Same size as the reports, different CRC
+		"unique-size.pdf": "nothing else is this long",
+		"empty-a.txt":     "",
+		"empty-b.txt":     "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	duplicateNames := func(scanner *Scanner) []string {
+		var names []string
+		for _, group := range scanner.Duplicates {
+			for _, file := range group {
+				names = append(names, file.Name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	full := NewScanner()
+	if err := full.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	layered := NewScanner()
+	layered.CRCPrefilter = true
+	if err := layered.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// The pre-filter finds exactly the duplicates hashing everything does
+	expected := []string{"empty-a.txt", "empty-b.txt", "report (1).pdf", "report.pdf"}
+	if got := duplicateNames(full); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected duplicates %v from a full scan, got %v", expected, got)
+	}
+	if got := duplicateNames(layered); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected duplicates %v with the CRC pre-filter, got %v", expected, got)
+	}
+
+	// Only files sharing a size and CRC were fully hashed, with the usual hash
+	fullHashes := make(map[string]string)
+	for _, file := range full.Files {
+		fullHashes[file.Name] = file.Hash
+	}
+	for _, file := range layered.Files {
+		switch file.Name {
+		case "same-size.pdf", "unique-size.pdf":
+			if file.Hash != "" {
+				t.Errorf("Expected %s not to be hashed, got %s", file.Name, file.Hash)
+			}
+		default:
+			if file.Hash != fullHashes[file.Name] {
+				t.Errorf("Expected %s to have hash %s, got %q", file.Name, fullHashes[file.Name], file.Hash)
+			}
+		}
+	}
+
+	// The category lists see the same hashes
+	for _, file := range layered.Categories["Documents"] {
+		if file.Name == "report.pdf" && file.Hash != fullHashes["report.pdf"] {
+			t.Errorf("Expected report.pdf in Documents to have hash %s, got %q", fullHashes["report.pdf"], file.Hash)
+		}
+	}
+}
+
+func BenchmarkScanDirectoryCRCPrefilter(b *testing.B) {
+	tmpDir := b.TempDir()
+	// Files of the same size with different content, so every file needs a CRC
+	// but none turns out to need a full hash
+	for i := 0; i < 32; i++ {
+		data := make([]byte, 1024*1024)
+		data[0] = byte(i)
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%d.bin", i)), data, 0644); err != nil {
+			b.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	for _, prefilter := range []bool{false, true} {
+		b.Run(fmt.Sprintf("CRCPrefilter=%v", prefilter), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanner := NewScanner()
+				scanner.CRCPrefilter = prefilter
+				if err := scanner.ScanDirectory(tmpDir); err != nil {
+					b.Fatalf("ScanDirectory() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	exifReadLimit      = 256 * 1024 // EXIF data sits near the start of a file, so don't read further
+	exifDateTimeLayout = "2006:01:02 15:04:05"
+	exifIFDPointerTag  = 0x8769
+	dateTimeOrigTag    = 0x9003
+)
+
+// dateSources are the places OrganizeByDate can take a file's date from
+var dateSources = map[string]bool{
+	"exif":       true, // DateTimeOriginal from a photo's EXIF data
+	"birth":      true, // When the file was created, where the OS records it
+	"downloaded": true, // When the file was downloaded, as recorded by macOS
+	"mtime":      true, // When the file was last modified
+}
+
+// readBirthTime returns when a file was created; a variable so tests can stub it
+var readBirthTime = birthTime
+
+// readDownloadedTime returns when a file was downloaded; a variable so tests can stub it
+var readDownloadedTime = downloadedTime
+
+// parseDateSources validates an ordered list of date sources
+func parseDateSources(names []string) ([]string, error) {
+	var sources []string
+	for _, name := range names {
+		source := strings.ToLower(strings.TrimSpace(name))
+		if source == "" {
+			continue
+		}
+		if !dateSources[source] {
+			return nil, fmt.Errorf("unknown date source %q (use exif, birth, downloaded or mtime)", name)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// fileDate returns the date OrganizeByDate files a file under, trying each of
+// the organizer's date sources in order and falling back to the modification time
+func (fo *FileOrganizer) fileDate(file FileInfo) (time.Time, string) {
+	for _, source := range fo.DateSources {
+		switch source {
+		case "exif":
+			if date, ok := readExifDate(file.Path); ok {
+				return date, source
+			}
+		case "birth":
+			if date, ok := readBirthTime(file.Path); ok {
+				return date, source
+			}
+		case "downloaded":
+			if date, ok := readDownloadedTime(file.Path); ok {
+				return date, source
+			}
+		case "mtime":
+			return file.LastModified, source
+		}
+	}
+	return file.LastModified, "mtime"
+}
+
+// readExifDate returns the DateTimeOriginal recorded in a JPEG's EXIF data
+func readExifDate(path string) (time.Time, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, exifReadLimit))
+	if err != nil || len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return time.Time{}, false
+	}
+
+	// Walk the JPEG segments looking for the APP1 segment holding EXIF
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return time.Time{}, false
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return time.Time{}, false // Image data starts, or the segment is cut off
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseExifDate(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return time.Time{}, false
+}
+
+// parseExifDate finds DateTimeOriginal in a TIFF-structured EXIF block
+func parseExifDate(tiff []byte) (time.Time, bool) {
+	if len(tiff) < 8 {
+		return time.Time{}, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}, false
+	}
+
+	// findTag returns the value offset field of a tag in the IFD at offset
+	findTag := func(offset uint32, tag uint16) ([]byte, bool) {
+		if int(offset)+2 > len(tiff) {
+			return nil, false
+		}
+		count := int(order.Uint16(tiff[offset:]))
+		for i := 0; i < count; i++ {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(tiff) {
+				return nil, false
+			}
+			if order.Uint16(tiff[entry:]) == tag {
+				return tiff[entry : entry+12], true
+			}
+		}
+		return nil, false
+	}
+
+	entry, ok := findTag(order.Uint32(tiff[4:]), exifIFDPointerTag)
+	if !ok {
+		return time.Time{}, false
+	}
+	entry, ok = findTag(order.Uint32(entry[8:]), dateTimeOrigTag)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	// The date is a 20 byte ASCII string, too long to fit in the entry itself
+	count, offset := order.Uint32(entry[4:]), order.Uint32(entry[8:])
+	if count < 19 || int(offset)+19 > len(tiff) {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(exifDateTimeLayout, string(tiff[offset:offset+19]), time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
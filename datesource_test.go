@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestJPEGWithExif writes a minimal JPEG whose EXIF data holds a DateTimeOriginal
+func writeTestJPEGWithExif(t *testing.T, path, dateTaken string) {
+	t.Helper()
+	var tiff bytes.Buffer
+	le := binary.LittleEndian
+	tiff.WriteString("II")
+	binary.Write(&tiff, le, uint16(42))
+	binary.Write(&tiff, le, uint32(8)) // IFD0 offset
+
+	// IFD0 with a single pointer to the Exif IFD
+	binary.Write(&tiff, le, uint16(1))
+	binary.Write(&tiff, le, []uint16{exifIFDPointerTag, 4})
+	binary.Write(&tiff, le, []uint32{1, 26})
+	binary.Write(&tiff, le, uint32(0))
+
+	// Exif IFD with DateTimeOriginal, stored after it
+	binary.Write(&tiff, le, uint16(1))
+	binary.Write(&tiff, le, []uint16{dateTimeOrigTag, 2})
+	binary.Write(&tiff, le, []uint32{20, 44})
+	binary.Write(&tiff, le, uint32(0))
+	tiff.WriteString(dateTaken + "\x00")
+
+	var jpeg bytes.Buffer
+	jpeg.Write([]byte{0xFF, 0xD8, 0xFF, 0xE1})
+	binary.Write(&jpeg, binary.BigEndian, uint16(2+6+tiff.Len()))
+	jpeg.WriteString("Exif\x00\x00")
+	jpeg.Write(tiff.Bytes())
+	jpeg.Write([]byte{0xFF, 0xDA, 0x00, 0x02, 0xFF, 0xD9})
+
+	if err := os.WriteFile(path, jpeg.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to create test JPEG: %v", err)
+	}
+}
+
+func TestReadExifDate(t *testing.T) {
+	tmpDir := t.TempDir()
+	photo := filepath.Join(tmpDir, "photo.jpg")
+	writeTestJPEGWithExif(t, photo, "2019:07:04 10:30:00")
+
+	date, ok := readExifDate(photo)
+	if !ok {
+		t.Fatal("Expected an EXIF date")
+	}
+	if want := time.Date(2019, 7, 4, 10, 30, 0, 0, time.Local); !date.Equal(want) {
+		t.Errorf("readExifDate() = %v, want %v", date, want)
+	}
+
+	plain := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(plain, []byte("no exif here"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, ok := readExifDate(plain); ok {
+		t.Error("Expected no EXIF date for a text file")
+	}
+}
+
+func TestDateSourceFallback(t *testing.T) {
+	birth := time.Date(2021, 3, 15, 9, 0, 0, 0, time.Local)
+	hasBirthTime := map[string]bool{}
+	original := readBirthTime
+	readBirthTime = func(path string) (time.Time, bool) {
+		return birth, hasBirthTime[filepath.Base(path)]
+	}
+	defer func() { readBirthTime = original }()
+
+	tmpDir := t.TempDir()
+	mtime := time.Date(2023, 11, 20, 12, 0, 0, 0, time.Local)
+	writeTestJPEGWithExif(t, filepath.Join(tmpDir, "photo.jpg"), "2019:07:04 10:30:00")
+	for _, name := range []string{"scan.jpg", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"photo.jpg", "scan.jpg", "notes.txt"} {
+		if err := os.Chtimes(filepath.Join(tmpDir, name), mtime, mtime); err != nil {
+			t.Fatalf("Failed to set times on %s: %v", name, err)
+		}
+	}
+	// photo.jpg has EXIF and a birth time, scan.jpg only a birth time, notes.txt neither
+	hasBirthTime["photo.jpg"] = true
+	hasBirthTime["scan.jpg"] = true
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	sources, err := parseDateSources([]string{"exif", "birth", "mtime"})
+	if err != nil {
+		t.Fatalf("parseDateSources() error = %v", err)
+	}
+	organizer.DateSources = sources
+
+	expected := map[string]string{"photo.jpg": "exif", "scan.jpg": "birth", "notes.txt": "mtime"}
+	for _, file := range scanner.Files {
+		if _, source := organizer.fileDate(file); source != expected[file.Name] {
+			t.Errorf("%s dated from %s, want %s", file.Name, source, expected[file.Name])
+		}
+	}
+
+	if err := organizer.OrganizeByDate(); err != nil {
+		t.Fatalf("OrganizeByDate() error = %v", err)
+	}
+	for _, path := range []string{"2019-07/photo.jpg", "2021-03/scan.jpg", "2023-11/notes.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+
+	// Birth time first skips EXIF entirely
+	organizer.DateSources = []string{"birth", "exif"}
+	file := FileInfo{Path: filepath.Join(tmpDir, "2019-07", "photo.jpg"), Name: "photo.jpg", LastModified: mtime}
+	if date, source := organizer.fileDate(file); source != "birth" || !date.Equal(birth) {
+		t.Errorf("fileDate() = %v from %s, want %v from birth", date, source, birth)
+	}
+
+	if _, err := parseDateSources([]string{"exif", "ctime"}); err == nil {
+		t.Error("Expected an error for an unknown date source")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Deadline is the time budget for a run with --max-runtime. Once it is used
+// up the scan stops and no new move, rename or removal is started, while one
+// already under way finishes, so nothing is left half done. A nil Deadline
+// never runs out.
+type Deadline struct {
+	Budget    time.Duration // How long the run may take
+	Remaining []string      // Files left alone because the budget ran out
+
+	ctx   context.Context
+	noted map[string]bool
+}
+
+// NewDeadline starts a budget of the given length. Call the returned function
+// once the run is over to release its timer.
+func NewDeadline(budget time.Duration) (*Deadline, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	return &Deadline{Budget: budget, ctx: ctx, noted: make(map[string]bool)}, cancel
+}
+
+// Allow reports whether an operation on path may start. Once the budget is
+// used up it notes the path as left for the next run and returns false.
+func (d *Deadline) Allow(path string) bool {
+	if !d.Expired() {
+		return true
+	}
+	if len(d.Remaining) == 0 {
+		warningColor.Printf("⏰ Reached --max-runtime of %s, not starting anything new\n", d.Budget)
+	}
+	if !d.noted[path] {
+		d.noted[path] = true
+		d.Remaining = append(d.Remaining, path)
+	}
+	return false
+}
+
+// Expired reports whether the budget is used up
+func (d *Deadline) Expired() bool {
+	return d != nil && d.ctx.Err() != nil
+}
+
+// Reader wraps r so reading fails once the budget is used up, so hashing a
+// huge file stops there instead of running on long past it
+func (d *Deadline) Reader(r io.Reader) io.Reader {
+	if d == nil {
+		return r
+	}
+	return &deadlineReader{ctx: d.ctx, r: r}
+}
+
+// deadlineReader is a reader that fails with its context's error once the context is done
+type deadlineReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (dr *deadlineReader) Read(p []byte) (int, error) {
+	if err := dr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return dr.r.Read(p)
+}
+
+// Report lists the files left for the next run because the budget ran out
+func (d *Deadline) Report() {
+	if d == nil || len(d.Remaining) == 0 {
+		return
+	}
+	warningColor.Printf("⏸️  Stopped after --max-runtime of %s, leaving %d files for the next run:\n", d.Budget, len(d.Remaining))
+	for _, path := range d.Remaining {
+		fmt.Printf("   ⏭️  %s\n", path)
+	}
+}
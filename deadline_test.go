@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrganizeFilesMaxRuntime(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"a.pdf", "b.pdf", "c.pdf", "d.pdf", "e.pdf", "f.pdf"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Each move takes longer than the whole budget, so only the first starts
+	deadline, cancel := NewDeadline(20 * time.Millisecond)
+	defer cancel()
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.Deadline = deadline
+	organizer.moveFile = func(src, dst string) error {
+		time.Sleep(50 * time.Millisecond)
+		return os.Rename(src, dst)
+	}
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// The move under way when the budget ran out still finished
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected 1 file moved, got %d", organizer.TotalMoved)
+	}
+	if len(deadline.Remaining) != len(names)-1 {
+		t.Fatalf("Expected %d files reported as remaining, got %d", len(names)-1, len(deadline.Remaining))
+	}
+	for _, path := range deadline.Remaining {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Remaining file %s is no longer in place: %v", path, err)
+		}
+	}
+	moved, err := os.ReadDir(filepath.Join(tmpDir, "Documents"))
+	if err != nil {
+		t.Fatalf("Failed to read Documents: %v", err)
+	}
+	if len(moved) != 1 {
+		t.Errorf("Expected 1 file in Documents, got %d", len(moved))
+	}
+}
+
+func TestDeadlineNil(t *testing.T) {
+	var deadline *Deadline
+	if !deadline.Allow("anything") {
+		t.Error("A nil Deadline should never run out")
+	}
+	deadline.Report()
+}
+
+func TestScanDirectoryMaxRuntime(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	// A budget used up before the scan starts leaves the whole folder unseen
+	deadline, cancel := NewDeadline(time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	scanner := NewScanner()
+	scanner.Deadline = deadline
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if !scanner.DeadlineReached || len(scanner.Files) != 0 {
+		t.Errorf("Expected the scan to stop with no files, got %d files (reached: %v)", len(scanner.Files), scanner.DeadlineReached)
+	}
+}
+
+func TestDeadlineReader(t *testing.T) {
+	deadline, cancel := NewDeadline(time.Hour)
+	reader := deadline.Reader(strings.NewReader("some content to hash"))
+	buf := make([]byte, 4)
+	if _, err := reader.Read(buf); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// Hashing a huge file stops as soon as the budget is gone
+	cancel()
+	if _, err := reader.Read(buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected reading to stop once the deadline is over, got %v", err)
+	}
+}
+
+func TestFlattenAndFolderRemovalMaxRuntime(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"Documents/report.pdf": "quarterly numbers",
+		"A/photo.jpg":          "photo",
+		"B/photo.jpg":          "photo",
+	})
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// A budget used up before anything starts
+	deadline, cancel := NewDeadline(time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+
+	flattener := NewFileOrganizer(NewScanner(), false, tmpDir)
+	flattener.Deadline = deadline
+	if err := flattener.Flatten(false); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "report.pdf")); err != nil {
+		t.Errorf("Expected report.pdf to be left for the next run: %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	handler.Deadline = deadline
+	handler.input = bufio.NewReader(strings.NewReader("y\n"))
+	if err := handler.RemoveDuplicateDirectories(scanner.FindDuplicateDirectories(tmpDir)); err != nil {
+		t.Fatalf("RemoveDuplicateDirectories() error = %v", err)
+	}
+	for _, dir := range []string{"A", "B"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, dir)); err != nil {
+			t.Errorf("Expected %s to be left for the next run: %v", dir, err)
+		}
+	}
+
+	if len(deadline.Remaining) != 2 {
+		t.Errorf("Expected report.pdf and one folder reported as remaining, got %v", deadline.Remaining)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "fmt"
+
+// FlushDeletions carries out the removals held back by DeferDeletions. Files
+// that changed since the scan or can't be removed are left in place and taken
+// back out of the totals and the deletion report.
+func (dh *DuplicateHandler) FlushDeletions() {
+	pending := dh.deferred
+	dh.deferred = nil
+	if len(pending) == 0 {
+		return
+	}
+
+	fmt.Printf("🗑️  Carrying out %d deferred deletions...\n", len(pending))
+	var failed []FileInfo
+	for _, file := range pending {
+		if changedSinceScan(file) {
+			failed = append(failed, file)
+			continue
+		}
+		err := dh.removeNow(file)
+		dh.Plan.Record("remove", file.Path, "", err)
+		if err != nil {
+			warningColor.Printf("   ⚠️  Failed to remove %s: %v\n", file.Name, err)
+			failed = append(failed, file)
+		}
+	}
+	dh.unrecord(failed)
+
+	successColor.Printf("✅ Carried out %d deferred deletions\n", len(pending)-len(failed))
+}
+
+// DiscardDeletions drops the removals held back by DeferDeletions, leaving
+// the files in place, and returns how many there were
+func (dh *DuplicateHandler) DiscardDeletions() int {
+	pending := dh.deferred
+	dh.deferred = nil
+	dh.unrecord(pending)
+	return len(pending)
+}
+
+// unrecord takes files that were counted as removed but are still in place
+// back out of the totals and the deletion report
+func (dh *DuplicateHandler) unrecord(files []FileInfo) {
+	if len(files) == 0 {
+		return
+	}
+
+	kept := make(map[string]bool, len(files))
+	for _, file := range files {
+		kept[file.Path] = true
+		dh.TotalRemoved--
+		dh.TotalSpaceSaved -= reclaimedSize(file)
+	}
+
+	removed := dh.Removed[:0]
+	for _, record := range dh.Removed {
+		if !kept[record.OriginalPath] {
+			removed = append(removed, record)
+		}
+	}
+	dh.Removed = removed
+}
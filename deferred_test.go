@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDeferredDeletionsSkippedWhenMoveFails(t *testing.T) {
+	for _, moveFails := range []bool{true, false} {
+		tmpDir := t.TempDir()
+		older := time.Now().Add(-time.Hour)
+		for name, content := range map[string]string{
+			"report.pdf":     "report content",
+			"report (1).pdf": "report content",
+			"photo.jpg":      "photo content",
+		} {
+			path := filepath.Join(tmpDir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create test file %s: %v", name, err)
+			}
+			if name == "report (1).pdf" {
+				os.Chtimes(path, older, older)
+			}
+		}
+		duplicate := filepath.Join(tmpDir, "report (1).pdf")
+
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(tmpDir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+		handler := NewDuplicateHandler(scanner, false)
+		handler.DeferDeletions = true
+		if err := handler.RemoveDuplicates(); err != nil {
+			t.Fatalf("RemoveDuplicates() error = %v", err)
+		}
+		if _, err := os.Stat(duplicate); err != nil {
+			t.Fatalf("Expected the duplicate to wait for the moves, got %v", err)
+		}
+
+		organizer := NewFileOrganizer(scanner, false, tmpDir)
+		if moveFails {
+			organizer.moveFile = func(src, dst string) error {
+				return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.ENOSPC}
+			}
+		}
+		err := organizer.OrganizeFiles()
+		if moveFails {
+			if err == nil {
+				t.Fatal("Expected OrganizeFiles() to fail on a fatal move error")
+			}
+			if n := handler.DiscardDeletions(); n != 1 {
+				t.Errorf("Expected 1 deletion to be discarded, got %d", n)
+			}
+			if _, err := os.Stat(duplicate); err != nil {
+				t.Errorf("Expected no deletions after a failed move, got %v", err)
+			}
+			if handler.TotalRemoved != 0 || len(handler.Removed) != 0 {
+				t.Errorf("Expected discarded deletions not to be counted, got %d removed and %v", handler.TotalRemoved, handler.Removed)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("OrganizeFiles() error = %v", err)
+		}
+		handler.FlushDeletions()
+		if _, err := os.Stat(duplicate); !os.IsNotExist(err) {
+			t.Errorf("Expected the duplicate to be removed once every move succeeded, got %v", err)
+		}
+		if handler.TotalRemoved != 1 || len(handler.Removed) != 1 {
+			t.Errorf("Expected 1 removal to be counted, got %d removed and %v", handler.TotalRemoved, handler.Removed)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DeletionRecord remembers a duplicate that was removed, where it was and
+// which copy was kept in its place, so it can be found or downloaded again
+type DeletionRecord struct {
+	OriginalPath string    `json:"original_path"`
+	Size         int64     `json:"size"`
+	Hash         string    `json:"hash"`
+	Survivor     string    `json:"survivor"`
+	RemovedAt    time.Time `json:"removed_at"`
+}
+
+// DeletionReport lists the duplicates one run removed, or would remove in a dry run
+type DeletionReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Removed []DeletionRecord `json:"removed"`
+}
+
+// DeletionReport returns what RemoveDuplicates has removed so far
+func (dh *DuplicateHandler) DeletionReport() DeletionReport {
+	removed := dh.Removed
+	if removed == nil {
+		removed = []DeletionRecord{}
+	}
+	return DeletionReport{DryRun: dh.DryRun, Removed: removed}
+}
+
+// Print prints the report as a readable list
+func (report DeletionReport) Print() {
+	if len(report.Removed) == 0 {
+		return
+	}
+
+	if report.DryRun {
+		fmt.Println("\n🧾 Duplicates that would be removed, and the copies that would stay:")
+	} else {
+		fmt.Println("\n🧾 Removed duplicates, and the copies kept in their place:")
+	}
+	for _, record := range report.Removed {
+		fmt.Printf("  - %s (%.2f MB, hash %s)\n", record.OriginalPath, float64(record.Size)/1024/1024, record.Hash)
+		fmt.Printf("    kept: %s\n", record.Survivor)
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (report DeletionReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveDuplicatesRecordsDeletions(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldDir := filepath.Join(tmpDir, "old")
+	os.MkdirAll(oldDir, 0755)
+
+	original := filepath.Join(oldDir, "setup.exe")
+	survivor := filepath.Join(tmpDir, "setup.exe")
+	for _, path := range []string{original, survivor} {
+		if err := os.WriteFile(path, []byte("installer bytes"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	older := time.Now().Add(-time.Hour)
+	os.Chtimes(original, older, older)
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+
+	report := handler.DeletionReport()
+	if len(report.Removed) != 1 {
+		t.Fatalf("Expected 1 deletion record, got %v", report.Removed)
+	}
+	record := report.Removed[0]
+	if record.OriginalPath != original {
+		t.Errorf("OriginalPath = %s, want %s", record.OriginalPath, original)
+	}
+	if record.Survivor != survivor {
+		t.Errorf("Survivor = %s, want %s", record.Survivor, survivor)
+	}
+	if record.Size != int64(len("installer bytes")) || record.Hash == "" {
+		t.Errorf("Expected the size and hash to be recorded, got %+v", record)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded DeletionReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Report is not valid JSON: %v", err)
+	}
+	if len(decoded.Removed) != 1 || decoded.Removed[0].OriginalPath != original || decoded.Removed[0].Survivor != survivor {
+		t.Errorf("JSON report = %s, want the original and survivor paths", buf.String())
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DuplicateDirectory is a set of folders holding exactly the same files
+type DuplicateDirectory struct {
+	Paths []string // Folders with identical content, shortest path first
+	Files int      // Number of files in each folder, including subfolders
+	Size  int64    // Size of each folder's content
+}
+
+// dirSummary accumulates what is known about a folder's content
+type dirSummary struct {
+	hashes   []string // Hashes of the files and subfolders directly inside
+	files    int
+	size     int64
+	unhashed bool // Holds a file that couldn't be hashed, so its content is unknown
+}
+
+// FindDuplicateDirectories finds folders below root whose content matches.
+// A folder's hash is built from the sorted hashes of its files and subfolders,
+// so names don't matter, only content. Matching subfolders of folders that are
+// already reported aren't reported again.
+func (s *Scanner) FindDuplicateDirectories(root string) []DuplicateDirectory {
+	root = filepath.Clean(root)
+	summaries := make(map[string]*dirSummary)
+	summary := func(dir string) *dirSummary {
+		if summaries[dir] == nil {
+			summaries[dir] = &dirSummary{}
+		}
+		return summaries[dir]
+	}
+
+	for _, file := range s.Files {
+		dir := filepath.Dir(file.Path)
+		// A folder with a file of unknown content can't be shown to match
+		// another, or removing it could delete that file
+		if file.Hash == "" {
+			for d := dir; ; d = filepath.Dir(d) {
+				summary(d).unhashed = true
+				if d == root || d == filepath.Dir(d) {
+					break
+				}
+			}
+			continue
+		}
+		summary(dir).hashes = append(summary(dir).hashes, file.Hash)
+		// Count the file in every folder up to the root
+		for d := dir; ; d = filepath.Dir(d) {
+			summary(d).files++
+			summary(d).size += file.Size
+			if d == root || d == filepath.Dir(d) {
+				break
+			}
+		}
+	}
+
+	// Hash the deepest folders first so subfolder hashes are ready for their parents
+	dirs := make([]string, 0, len(summaries))
+	for dir := range summaries {
+		if dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		di, dj := strings.Count(dirs[i], string(filepath.Separator)), strings.Count(dirs[j], string(filepath.Separator))
+		if di != dj {
+			return di > dj
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	dirHashes := make(map[string]string)
+	byHash := make(map[string][]string)
+	for _, dir := range dirs {
+		if summaries[dir].unhashed {
+			continue
+		}
+		hashes := append([]string(nil), summaries[dir].hashes...)
+		sort.Strings(hashes)
+		hash := md5.Sum([]byte(strings.Join(hashes, "\n")))
+		dirHashes[dir] = "dir:" + hex.EncodeToString(hash[:])
+		byHash[dirHashes[dir]] = append(byHash[dirHashes[dir]], dir)
+
+		if parent := filepath.Dir(dir); parent != root {
+			summary(parent).hashes = append(summary(parent).hashes, dirHashes[dir])
+		}
+	}
+
+	var groups []DuplicateDirectory
+	for _, paths := range byHash {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			if len(paths[i]) != len(paths[j]) {
+				return len(paths[i]) < len(paths[j])
+			}
+			return paths[i] < paths[j]
+		})
+		groups = append(groups, DuplicateDirectory{Paths: paths, Files: summaries[paths[0]].files, Size: summaries[paths[0]].size})
+	}
+
+	// Report the outermost matches only: if both parents match, so do their children
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.Count(groups[i].Paths[0], string(filepath.Separator)) < strings.Count(groups[j].Paths[0], string(filepath.Separator))
+	})
+	reported := make(map[string]bool)
+	var result []DuplicateDirectory
+	for _, group := range groups {
+		covered := true
+		for _, path := range group.Paths {
+			if !reported[filepath.Dir(path)] {
+				covered = false
+			}
+		}
+		for _, path := range group.Paths {
+			reported[path] = true
+		}
+		if !covered {
+			result = append(result, group)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Paths[0] < result[j].Paths[0]
+	})
+	return result
+}
+
+// PrintDuplicateDirectories lists folders with identical content
+func PrintDuplicateDirectories(groups []DuplicateDirectory) {
+	if len(groups) == 0 {
+		fmt.Println("✅ No duplicate folders found")
+		return
+	}
+
+	infoColor.Printf("\n📁 Duplicate folders (%d groups):\n", len(groups))
+	for _, group := range groups {
+		fmt.Printf("  %d files, %.2f MB each:\n", group.Files, float64(group.Size)/1024/1024)
+		for _, path := range group.Paths {
+			fmt.Printf("    - %s\n", path)
+		}
+	}
+}
+
+// RemoveDuplicateDirectories removes every folder in each group except the
+// first, after asking for confirmation. Folders holding anything the scan
+// didn't see, like hidden files, are left alone.
+func (dh *DuplicateHandler) RemoveDuplicateDirectories(groups []DuplicateDirectory) error {
+	scanned := make(map[string]bool, len(dh.Scanner.Files))
+	for _, file := range dh.Scanner.Files {
+		scanned[file.Path] = true
+	}
+
+	removed := 0
+	for _, group := range groups {
+		keep := group.Paths[0]
+		for _, dir := range group.Paths[1:] {
+			if unseen := unscannedEntry(dir, scanned); unseen != "" {
+				warningColor.Printf("⚠️  Keeping %s: it contains %s, which wasn't scanned\n", dir, unseen)
+				continue
+			}
+
+			if dh.DryRun {
+				fmt.Printf("   🗑️  Would remove folder: %s (same as %s)\n", dir, keep)
+				dh.Plan.Add("remove", dir, "")
+				removed++
+				continue
+			}
+
+			if !dh.Deadline.Allow(dir) {
+				continue
+			}
+			ok, err := dh.confirmFolderRemoval(dir, keep)
+			if !ok {
+				fmt.Println("   ⏭️  Kept")
+				if err != nil {
+					return nil // Out of input
+				}
+				continue
+			}
+
+			fmt.Printf("   🗑️  Removing folder: %s\n", dir)
+			err = dh.removeDirNow(dir, group.Size)
+			dh.Plan.Record("remove", dir, "", err)
+			if err != nil {
+				warningColor.Printf("⚠️  Failed to remove %s: %v\n", dir, err)
+				continue
+			}
+			removed++
+			dh.TotalRemoved += group.Files
+			dh.TotalSpaceSaved += group.Size
+			dh.Scanner.forgetFilesUnder(dir)
+		}
+	}
+
+	if dh.DryRun {
+		successColor.Printf("✅ Would remove %d duplicate folders\n", removed)
+	} else {
+		successColor.Printf("✅ Removed %d duplicate folders\n", removed)
+		successColor.Printf("💾 Space saved: %.2f MB\n", float64(dh.TotalSpaceSaved)/1024/1024)
+	}
+	return nil
+}
+
+// confirmFolderRemoval asks before a duplicate folder is removed. With an
+// Approver the question goes through it, so the answer counts along with
+// every other change; otherwise the folder is asked about on its own.
+func (dh *DuplicateHandler) confirmFolderRemoval(dir, keep string) (bool, error) {
+	if dh.Approver != nil {
+		return dh.Approver.Approve(fmt.Sprintf("Remove folder %s (same as %s)", dir, keep)), nil
+	}
+	fmt.Printf("Remove folder %s (same as %s)? [y/N]: ", dir, keep)
+	answer, err := dh.readAnswer()
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes"), err
+}
+
+// removeDirNow removes a duplicate folder the way removeNow removes a file:
+// into the quarantine when there is one, otherwise shredding each file first
+// when shredding is on
+func (dh *DuplicateHandler) removeDirNow(dir string, size int64) error {
+	if dh.Quarantine != nil {
+		return dh.Quarantine.Add(FileInfo{Path: dir, Name: filepath.Base(dir), Size: size})
+	}
+	if dh.ShredPasses > 0 {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			return shredFile(path, dh.ShredPasses)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// forgetFilesUnder drops the files inside dir from the scan results, after the folder is removed
+func (s *Scanner) forgetFilesUnder(dir string) {
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	keep := func(files []FileInfo) []FileInfo {
+		kept := files[:0]
+		for _, file := range files {
+			if !strings.HasPrefix(file.Path, prefix) {
+				kept = append(kept, file)
+			}
+		}
+		return kept
+	}
+
+	s.Files = keep(s.Files)
+	for category, files := range s.Categories {
+		if s.Categories[category] = keep(files); len(s.Categories[category]) == 0 {
+			delete(s.Categories, category)
+		}
+	}
+	for hash, files := range s.Duplicates {
+		if s.Duplicates[hash] = keep(files); len(s.Duplicates[hash]) >= 2 {
+			continue
+		}
+		// A lone survivor isn't a duplicate any more
+		for _, file := range s.Duplicates[hash] {
+			for i := range s.Files {
+				if s.Files[i].Path == file.Path {
+					s.Files[i].IsDuplicate = false
+				}
+			}
+		}
+		delete(s.Duplicates, hash)
+	}
+}
+
+// unscannedEntry returns the first file inside dir that isn't in scanned, or "" if there is none
+func unscannedEntry(dir string, scanned map[string]bool) string {
+	var found string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			found = path
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !scanned[path] {
+			found = path
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found
+}
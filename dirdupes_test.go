@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files under root from a map of slash-separated paths to contents
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(root, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create folder for %s: %v", path, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", path, err)
+		}
+	}
+}
+
+func TestFindDuplicateDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"Project/readme.txt":          "readme",
+		"Project/src/main.go":         "package main",
+		"Project copy/readme.txt":     "readme",
+		"Project copy/src/renamed.go": "package main",
+		"Other/readme.txt":            "readme",
+		"Other/src/main.go":           "package other",
+		"loose.txt":                   "loose",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	groups := scanner.FindDuplicateDirectories(tmpDir)
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group of duplicate folders, got %d: %+v", len(groups), groups)
+	}
+
+	// Only the outermost folders are reported, not their matching src folders
+	group := groups[0]
+	expected := []string{filepath.Join(tmpDir, "Project"), filepath.Join(tmpDir, "Project copy")}
+	if len(group.Paths) != 2 || group.Paths[0] != expected[0] || group.Paths[1] != expected[1] {
+		t.Errorf("Got folders %v, want %v", group.Paths, expected)
+	}
+	if group.Files != 2 {
+		t.Errorf("Expected 2 files per folder, got %d", group.Files)
+	}
+}
+
+func TestFindDuplicateDirectoriesUnhashed(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"A/x.txt": "same",
+		"A/y.txt": "only in A",
+		"B/x.txt": "same",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	// As if hashing y.txt had failed partway through the scan
+	for i := range scanner.Files {
+		if scanner.Files[i].Name == "y.txt" {
+			scanner.Files[i].Hash = ""
+		}
+	}
+
+	if groups := scanner.FindDuplicateDirectories(tmpDir); len(groups) != 0 {
+		t.Errorf("Expected a folder with an unhashed file to match nothing, got %+v", groups)
+	}
+}
+
+func TestRemoveDuplicateDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"A/photo.jpg": "photo",
+		"A/notes.txt": "notes",
+		"B/photo.jpg": "photo",
+		"B/notes.txt": "notes",
+		"C/photo.jpg": "photo",
+		"C/notes.txt": "notes",
+		"C/.hidden":   "not scanned",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	groups := scanner.FindDuplicateDirectories(tmpDir)
+
+	// Nothing happens without confirmation
+	handler := NewDuplicateHandler(scanner, false)
+	handler.input = bufio.NewReader(strings.NewReader("n\n"))
+	if err := handler.RemoveDuplicateDirectories(groups); err != nil {
+		t.Fatalf("RemoveDuplicateDirectories() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "B")); err != nil {
+		t.Errorf("Expected B to be kept without confirmation: %v", err)
+	}
+
+	handler = NewDuplicateHandler(scanner, false)
+	handler.input = bufio.NewReader(strings.NewReader("y\ny\n"))
+	if err := handler.RemoveDuplicateDirectories(groups); err != nil {
+		t.Fatalf("RemoveDuplicateDirectories() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "A")); err != nil {
+		t.Errorf("Expected A to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "B")); !os.IsNotExist(err) {
+		t.Error("Expected B to be removed")
+	}
+	// C has a hidden file the scan didn't see, so it stays
+	if _, err := os.Stat(filepath.Join(tmpDir, "C", ".hidden")); err != nil {
+		t.Errorf("Expected C to be kept: %v", err)
+	}
+	if handler.TotalRemoved != 2 {
+		t.Errorf("Expected 2 files removed, got %d", handler.TotalRemoved)
+	}
+	for _, file := range scanner.Files {
+		if strings.HasPrefix(file.Path, filepath.Join(tmpDir, "B")) {
+			t.Errorf("Expected %s to be dropped from the scan results", file.Path)
+		}
+	}
+}
+
+func TestRemoveDuplicateDirectoriesQuarantine(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"A/photo.jpg": "photo",
+		"B/photo.jpg": "photo",
+		"C/photo.jpg": "photo",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	groups := scanner.FindDuplicateDirectories(tmpDir)
+
+	quarantine, err := OpenQuarantine(tmpDir)
+	if err != nil {
+		t.Fatalf("OpenQuarantine() error = %v", err)
+	}
+
+	// The approver is asked instead of the folder prompt: yes to B, no to C
+	handler := NewDuplicateHandler(scanner, false)
+	handler.Quarantine = quarantine
+	handler.Approver = NewApprover(strings.NewReader("y\nn\n"), io.Discard)
+	if err := handler.RemoveDuplicateDirectories(groups); err != nil {
+		t.Fatalf("RemoveDuplicateDirectories() error = %v", err)
+	}
+
+	if handler.Approver.Approved != 1 || handler.Approver.Skipped != 1 {
+		t.Errorf("Expected 1 approved and 1 skipped, got %d and %d", handler.Approver.Approved, handler.Approver.Skipped)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "C", "photo.jpg")); err != nil {
+		t.Errorf("Expected C to be kept: %v", err)
+	}
+	if len(quarantine.Entries) != 1 || quarantine.Entries[0].OriginalPath != filepath.Join(tmpDir, "B") {
+		t.Fatalf("Expected B to be quarantined, got %+v", quarantine.Entries)
+	}
+	if data, err := os.ReadFile(filepath.Join(quarantine.Entries[0].QuarantinedPath, "photo.jpg")); err != nil || string(data) != "photo" {
+		t.Errorf("Expected B's files to be kept in the quarantine, got %q (%v)", data, err)
+	}
+}
+
+func TestRemoveDuplicateDirectoriesDryRunPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"A/photo.jpg": "photo",
+		"B/photo.jpg": "photo",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// --assert-clean relies on the plan to notice the folder would go
+	handler := NewDuplicateHandler(scanner, true)
+	handler.Plan = &Plan{}
+	if err := handler.RemoveDuplicateDirectories(scanner.FindDuplicateDirectories(tmpDir)); err != nil {
+		t.Fatalf("RemoveDuplicateDirectories() error = %v", err)
+	}
+	if err := handler.Plan.AssertEmpty(io.Discard); err == nil {
+		t.Error("Expected the folder removal to be in the plan")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "B")); err != nil {
+		t.Errorf("Expected a dry run to keep B: %v", err)
+	}
+}
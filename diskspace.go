@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// pendingMove describes a file that is about to be moved
+type pendingMove struct {
+	Src  string
+	Dst  string
+	Size int64
+}
+
+// spaceChecker makes sure cross-device moves won't run out of space halfway.
+// Moves within a filesystem are renames and need no extra space, but moves
+// across filesystems fall back to copying.
+type spaceChecker struct {
+	DeviceID  func(path string) (string, error)
+	FreeSpace func(path string) (uint64, error)
+}
+
+// newSpaceChecker returns a spaceChecker that asks the operating system
+func newSpaceChecker() spaceChecker {
+	return spaceChecker{
+		DeviceID:  deviceID,
+		FreeSpace: freeDiskSpace,
+	}
+}
+
+// Check sums the bytes to be copied onto each destination filesystem and
+// returns an error if any of them doesn't have enough free space
+func (sc spaceChecker) Check(moves []pendingMove) error {
+	needed := make(map[string]uint64)    // Bytes to copy per destination device
+	checkPath := make(map[string]string) // A path on each destination device
+
+	for _, move := range moves {
+		srcDevice, err := sc.DeviceID(move.Src)
+		if err != nil {
+			continue
+		}
+		dstDir := existingAncestor(filepath.Dir(move.Dst))
+		dstDevice, err := sc.DeviceID(dstDir)
+		if err != nil || srcDevice == dstDevice {
+			continue
+		}
+		needed[dstDevice] += uint64(move.Size)
+		checkPath[dstDevice] = dstDir
+	}
+
+	for device, bytes := range needed {
+		free, err := sc.FreeSpace(checkPath[device])
+		if err != nil {
+			// Don't block moves just because the platform can't tell us
+			continue
+		}
+		if bytes > free {
+			return fmt.Errorf("not enough free space on the drive holding %s: need %.2f MB, only %.2f MB free",
+				checkPath[device], float64(bytes)/1024/1024, float64(free)/1024/1024)
+		}
+	}
+	return nil
+}
+
+// existingAncestor returns the closest existing folder to path, since destination folders may not be created yet
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
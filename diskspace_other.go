@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package main
+
+import "fmt"
+
+// freeDiskSpace returns the number of bytes available to the current user on the filesystem holding path
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, fmt.Errorf("checking free disk space is not supported on this platform")
+}
+
+// deviceID identifies the filesystem holding an existing path
+func deviceID(path string) (string, error) {
+	return "", fmt.Errorf("checking devices is not supported on this platform")
+}
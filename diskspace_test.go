@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSpaceChecker treats everything under destRoot as a separate drive with the given free space
+func fakeSpaceChecker(destRoot string, free uint64) spaceChecker {
+	return spaceChecker{
+		DeviceID: func(path string) (string, error) {
+			if strings.HasPrefix(path, destRoot) {
+				return "dest", nil
+			}
+			return "src", nil
+		},
+		FreeSpace: func(path string) (uint64, error) {
+			return free, nil
+		},
+	}
+}
+
+func TestSpaceCheckerCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	destRoot := filepath.Join(tmpDir, "external")
+	if err := os.MkdirAll(destRoot, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+
+	moves := []pendingMove{
+		{Src: filepath.Join(tmpDir, "a.bin"), Dst: filepath.Join(destRoot, "a.bin"), Size: 600},
+		{Src: filepath.Join(tmpDir, "b.bin"), Dst: filepath.Join(destRoot, "sub", "b.bin"), Size: 600},
+		// Same-device moves are renames and need no space
+		{Src: filepath.Join(tmpDir, "c.bin"), Dst: filepath.Join(tmpDir, "Other", "c.bin"), Size: 5000},
+	}
+
+	if err := fakeSpaceChecker(destRoot, 1000).Check(moves); err == nil {
+		t.Error("Expected moves to be refused when the destination is short on space")
+	}
+	if err := fakeSpaceChecker(destRoot, 1200).Check(moves); err != nil {
+		t.Errorf("Expected moves to be allowed with enough space: %v", err)
+	}
+}
+
+func TestMoveDuplicatesRefusedWhenSpaceShort(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "external", "duplicates")
+
+	files := []string{"file1.txt", "file2.txt", "file3.txt"}
+	for _, filename := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, filename), []byte("duplicate content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", filename, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	handler.spaceChecker = fakeSpaceChecker(filepath.Join(tmpDir, "external"), 10)
+	if err := handler.MoveDuplicatesToFolder(destDir); err == nil {
+		t.Error("Expected MoveDuplicatesToFolder() to refuse when space is short")
+	}
+
+	// Nothing should have been moved
+	for _, filename := range files {
+		if _, err := os.Stat(filepath.Join(tmpDir, filename)); err != nil {
+			t.Errorf("File %s was moved despite the space check: %v", filename, err)
+		}
+	}
+}
+
+func TestExistingAncestor(t *testing.T) {
+	tmpDir := t.TempDir()
+	if result := existingAncestor(filepath.Join(tmpDir, "a", "b", "c")); result != tmpDir {
+		t.Errorf("existingAncestor() = %s, want %s", result, tmpDir)
+	}
+}
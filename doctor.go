@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// CheckStatus is the outcome of a single doctor check
+type CheckStatus int
+
+const (
+	CheckPass CheckStatus = iota
+	CheckWarn
+	CheckFail
+)
+
+// lowDiskSpace is the free space below which doctor warns regardless of pending moves
+const lowDiskSpace = 100 * 1024 * 1024 // 100MB
+
+// CheckResult holds the outcome of a single doctor check
+type CheckResult struct {
+	Name    string
+	Status  CheckStatus
+	Message string
+}
+
+// RunDoctor runs non-destructive checks against a folder and reports any problems
+func RunDoctor(path string) []CheckResult {
+	var results []CheckResult
+
+	// Is the path somewhere we're allowed to work?
+	if err := validatePath(path); err != nil {
+		results = append(results, CheckResult{"Path is allowed", CheckFail, err.Error()})
+	} else {
+		results = append(results, CheckResult{"Path is allowed", CheckPass, "within your user or temp directory"})
+	}
+
+	// Does it exist, and is it a folder?
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		results = append(results, CheckResult{"Path exists", CheckFail, fmt.Sprintf("%s does not exist", path)})
+		return results
+	}
+	if err != nil {
+		results = append(results, CheckResult{"Path exists", CheckFail, err.Error()})
+		return results
+	}
+	if !info.IsDir() {
+		results = append(results, CheckResult{"Path exists", CheckFail, fmt.Sprintf("%s is not a folder", path)})
+		return results
+	}
+	results = append(results, CheckResult{"Path exists", CheckPass, path})
+
+	// Can we create files in it?
+	results = append(results, checkWritable(path))
+
+	// Is there room for the files that would be moved?
+	results = append(results, checkFreeSpace(path))
+
+	return results
+}
+
+// checkWritable checks that files can be created in a folder
+func checkWritable(path string) CheckResult {
+	testFile, err := os.CreateTemp(path, ".elf-doctor-*")
+	if err != nil {
+		return CheckResult{"Path is writable", CheckFail, fmt.Sprintf("cannot create files: %v", err)}
+	}
+	testFile.Close()
+	os.Remove(testFile.Name())
+	return CheckResult{"Path is writable", CheckPass, "files can be created and removed"}
+}
+
+// checkFreeSpace checks that there is enough free space to copy the loose files
+// in a folder, which is what a move falls back to across devices
+func checkFreeSpace(path string) CheckResult {
+	free, err := freeDiskSpace(path)
+	if err != nil {
+		return CheckResult{"Free disk space", CheckWarn, fmt.Sprintf("could not check: %v", err)}
+	}
+
+	pending := int64(0)
+	entries, err := os.ReadDir(path)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				pending += info.Size()
+			}
+		}
+	}
+
+	freeMB := float64(free) / 1024 / 1024
+	pendingMB := float64(pending) / 1024 / 1024
+	if uint64(pending) > free {
+		return CheckResult{"Free disk space", CheckWarn,
+			fmt.Sprintf("%.2f MB free but %.2f MB of loose files; moves to another drive may fail", freeMB, pendingMB)}
+	}
+	if free < lowDiskSpace {
+		return CheckResult{"Free disk space", CheckWarn, fmt.Sprintf("only %.2f MB free", freeMB)}
+	}
+	return CheckResult{"Free disk space", CheckPass, fmt.Sprintf("%.2f MB free, %.2f MB of loose files", freeMB, pendingMB)}
+}
+
+// printCheckResults prints doctor results and returns an error if any check failed
+func printCheckResults(results []CheckResult) error {
+	failed := 0
+	for _, result := range results {
+		switch result.Status {
+		case CheckPass:
+			successColor.Printf("✅ %s: ", result.Name)
+		case CheckWarn:
+			warningColor.Printf("⚠️  %s: ", result.Name)
+		case CheckFail:
+			errorColor.Printf("❌ %s: ", result.Name)
+			failed++
+		}
+		fmt.Println(result.Message)
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	successColor.Printf("✨ Everything looks good!\n")
+	return nil
+}
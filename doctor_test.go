@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDoctor(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	results := RunDoctor(tmpDir)
+	for _, result := range results {
+		if result.Status == CheckFail {
+			t.Errorf("Check %s failed on a healthy folder: %s", result.Name, result.Message)
+		}
+	}
+	if _, ok := findCheck(results, "Free disk space"); !ok {
+		t.Error("Expected free disk space to be checked")
+	}
+}
+
+func TestRunDoctorNonexistentPath(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	results := RunDoctor(missing)
+	result, ok := findCheck(results, "Path exists")
+	if !ok || result.Status != CheckFail {
+		t.Errorf("Expected 'Path exists' to fail for %s, got %+v", missing, result)
+	}
+
+	// Checks that need the folder shouldn't run
+	if _, ok := findCheck(results, "Path is writable"); ok {
+		t.Error("Writable check ran for a nonexistent path")
+	}
+	if err := printCheckResults(results); err == nil {
+		t.Error("Expected printCheckResults() to report failure")
+	}
+}
+
+func TestRunDoctorNonWritable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions aren't enforced for root")
+	}
+
+	tmpDir := t.TempDir()
+	readOnly := filepath.Join(tmpDir, "readonly")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	defer os.Chmod(readOnly, 0755)
+
+	results := RunDoctor(readOnly)
+	result, ok := findCheck(results, "Path is writable")
+	if !ok || result.Status != CheckFail {
+		t.Errorf("Expected 'Path is writable' to fail for a read-only folder, got %+v", result)
+	}
+}
+
+func TestRunDoctorDisallowedPath(t *testing.T) {
+	results := RunDoctor("/etc")
+	result, ok := findCheck(results, "Path is allowed")
+	if !ok || result.Status != CheckFail {
+		t.Errorf("Expected 'Path is allowed' to fail for /etc, got %+v", result)
+	}
+}
+
+// findCheck returns the result of the named check, if it ran
+func findCheck(results []CheckResult, name string) (CheckResult, bool) {
+	for _, result := range results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return CheckResult{}, false
+}
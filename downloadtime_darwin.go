@@ -0,0 +1,18 @@
+//go:build darwin
+
+package main
+
+import "time"
+
+// downloadedTime returns when macOS recorded a file as being downloaded
+func downloadedTime(path string) (time.Time, bool) {
+	data, err := readXattr(path, downloadedAttr)
+	if err != nil || data == nil {
+		return time.Time{}, false
+	}
+	dates, err := parseBinaryPlistDates(data)
+	if err != nil || len(dates) == 0 {
+		return time.Time{}, false
+	}
+	return dates[0], true
+}
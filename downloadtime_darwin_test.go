@@ -0,0 +1,55 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestOrganizeByDownloadedDate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	downloaded := filepath.Join(tmpDir, "statement.pdf")
+	local := filepath.Join(tmpDir, "notes.txt")
+	for _, path := range []string{downloaded, local} {
+		if err := os.WriteFile(path, []byte("content of "+filepath.Base(path)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Downloaded in February 2020, but modified much later
+	downloadDate := time.Date(2020, 2, 14, 12, 0, 0, 0, time.Local)
+	if err := unix.Setxattr(downloaded, downloadedAttr, encodeTestBinaryPlistDate(downloadDate), 0); err != nil {
+		t.Skipf("Cannot set extended attributes here: %v", err)
+	}
+	mtime := time.Date(2023, 11, 20, 12, 0, 0, 0, time.Local)
+	for _, path := range []string{downloaded, local} {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set file times: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.DateSources = []string{"downloaded"}
+	if err := organizer.OrganizeByDate(); err != nil {
+		t.Fatalf("OrganizeByDate() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "2020-02", "statement.pdf")); err != nil {
+		t.Errorf("Expected the downloaded file in its download month: %v", err)
+	}
+	// Without a download date the modification time is used
+	if _, err := os.Stat(filepath.Join(tmpDir, "2023-11", "notes.txt")); err != nil {
+		t.Errorf("Expected the local file in its modification month: %v", err)
+	}
+}
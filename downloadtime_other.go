@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package main
+
+import "time"
+
+// downloadedTime returns when a file was downloaded, which only macOS records
+func downloadedTime(path string) (time.Time, bool) {
+	return time.Time{}, false
+}
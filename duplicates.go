@@ -1,26 +1,146 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
-
-	"github.com/fatih/color"
+	"time"
 )
 
 // DuplicateHandler handles the removal of duplicate files
 type DuplicateHandler struct {
 	Scanner *Scanner
 	DryRun  bool
+
+	PerDirectory bool                // Keep one copy of each duplicate in every directory that has it
+	ShredPasses  int                 // Overwrite removed duplicates this many times before deleting them (0 deletes normally)
+	Approver     *Approver           // Asks before each removal or move, nil to go ahead without asking
+	Deadline     *Deadline           // Stops starting new removals or moves once --max-runtime is up, nil for no limit
+	MinGroupSize int                 // Only act on groups with at least this many copies (2 or less acts on all)
+	Quarantine   *Quarantine         // Moves removed duplicates here instead of deleting them, nil to delete
+	Plan         *Plan               // Records what a dry run would do, nil to not record
+	Resolver     DuplicateResolver   // Picks the copy to keep in each group, nil keeps the newest
+	Throttle     *RateLimiter        // Limits how fast duplicates are copied between drives, nil for no limit
+	PreserveXattrs bool              // Copy extended attributes and ACLs along with duplicates moved between drives
+	Weights      *OriginalityWeights // How pattern removal scores which copy is the original, nil for the defaults
+
+	ForceDeleteReadOnly bool // Clear the read-only bit on duplicates instead of skipping them
+	RestoreNames        bool // Strip copy markers like "(1)" from duplicates moved to a folder, when the plain name is free there
+	GroupOutput         bool // Move each group of duplicates into its own numbered subfolder, with a KEPT.txt naming the copy kept
+	DeferDeletions      bool // Hold removals back until FlushDeletions, so nothing is deleted before every move has succeeded
+	SkipSymlinks        bool // Leave duplicates that are symbolic links in place
+
+	TotalRemoved    int   // Duplicates removed or moved so far
+	TotalSpaceSaved int64 // Bytes reclaimed so far
+	ReadOnlySkipped int   // Read-only duplicates left in place
+
+	Removed []DeletionRecord // Duplicates RemoveDuplicates removed, with the copy kept in their place
+
+	spaceChecker spaceChecker  // Checks free space before cross-device moves
+	input        *bufio.Reader // Answers for interactive mode, read from stdin if nil
+	deferred     []FileInfo    // Removals held back by DeferDeletions
 }
 
 // NewDuplicateHandler creates a new DuplicateHandler instance
 func NewDuplicateHandler(scanner *Scanner, dryRun bool) *DuplicateHandler {
 	return &DuplicateHandler{
-		Scanner: scanner,
-		DryRun:  dryRun,
+		Scanner:      scanner,
+		DryRun:       dryRun,
+		spaceChecker: newSpaceChecker(),
+	}
+}
+
+// findNewest returns the most recently modified file in a group. Files
+// modified at the same moment, as after a bulk copy, are told apart by the
+// shortest path and then the alphabetically first, so the same copy is picked
+// whatever order the group is in.
+func findNewest(files []FileInfo) FileInfo {
+	newestFile := files[0]
+	for _, file := range files[1:] {
+		if file.LastModified.After(newestFile.LastModified) ||
+			(file.LastModified.Equal(newestFile.LastModified) && pathBefore(file.Path, newestFile.Path)) {
+			newestFile = file
+		}
+	}
+	return newestFile
+}
+
+// reclaimedSize is the space removing a duplicate frees. A symbolic link
+// frees next to nothing, however large the file it points to.
+func reclaimedSize(file FileInfo) int64 {
+	if file.IsSymlink {
+		return 0
+	}
+	return file.Size
+}
+
+// pathBefore orders paths shortest first, then alphabetically
+func pathBefore(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
 	}
+	return a < b
+}
+
+// errReadOnly is returned when a read-only duplicate is left in place
+var errReadOnly = errors.New("file is read-only (use --force-delete-readonly to remove it anyway)")
+
+// removeFile deletes a duplicate, or with DeferDeletions notes it for FlushDeletions
+func (dh *DuplicateHandler) removeFile(file FileInfo) error {
+	if dh.DeferDeletions {
+		// Organizing mustn't move it out from under the deletion
+		dh.deferred = append(dh.deferred, file)
+		dh.Scanner.forgetPath(file.Path)
+		return nil
+	}
+	err := dh.removeNow(file)
+	dh.Plan.Record("remove", file.Path, "", err)
+	return err
+}
+
+// removeNow deletes a duplicate, shredding it first or quarantining it instead if requested
+func (dh *DuplicateHandler) removeNow(file FileInfo) error {
+	if dh.Quarantine != nil {
+		return dh.Quarantine.Add(file)
+	}
+
+	// Read-only files can't be deleted on some platforms, and may be read-only on purpose
+	if info, err := os.Lstat(file.Path); err == nil && info.Mode().Perm()&0200 == 0 {
+		if !dh.ForceDeleteReadOnly {
+			dh.ReadOnlySkipped++
+			return errReadOnly
+		}
+		if err := os.Chmod(file.Path, info.Mode().Perm()|0200); err != nil {
+			return fmt.Errorf("cannot clear read-only bit: %v", err)
+		}
+	}
+	// Shredding a symlink would overwrite the copy being kept
+	if dh.ShredPasses > 0 && !file.IsSymlink {
+		return shredFile(file.Path, dh.ShredPasses)
+	}
+	return os.Remove(file.Path)
+}
+
+// survivorIntact re-hashes the copy that is about to be kept and reports
+// whether it still matches the group. If it changed or vanished since the
+// scan, the other copies may be the only good ones left, so none of them
+// should be touched.
+func (dh *DuplicateHandler) survivorIntact(keep FileInfo) bool {
+	hash, err := dh.Scanner.contentHash(keep)
+	if err != nil {
+		warningColor.Printf("   ⚠️  Cannot verify %s (%v), leaving this group alone\n", keep.Name, err)
+		return false
+	}
+	if hash != keep.Hash {
+		warningColor.Printf("   ⚠️  %s changed since scan, leaving this group alone\n", keep.Name)
+		return false
+	}
+	return true
 }
 
 // atomicMove performs an atomic file move operation
@@ -35,6 +155,60 @@ func (dh *DuplicateHandler) atomicMove(src, dst string) error {
 	return dh.copyAndDelete(src, dst)
 }
 
+// duplicateGroups returns the groups of duplicates to process, keyed by hash.
+// In per-directory mode each hash group is split by directory, so that only
+// redundant copies within the same directory are considered duplicates.
+// Groups with fewer copies than MinGroupSize are reported and left alone.
+func (dh *DuplicateHandler) duplicateGroups() map[string][]FileInfo {
+	groups := dh.Scanner.Duplicates
+	if dh.SkipSymlinks {
+		groups = make(map[string][]FileInfo)
+		for hash, files := range dh.Scanner.Duplicates {
+			for _, file := range files {
+				if file.IsSymlink {
+					infoColor.Printf("ℹ️  Leaving alone %s, a symlink\n", file.Path)
+					continue
+				}
+				groups[hash] = append(groups[hash], file)
+			}
+			if len(groups[hash]) < 2 {
+				delete(groups, hash)
+			}
+		}
+	}
+	if dh.PerDirectory {
+		byHash := groups
+		groups = make(map[string][]FileInfo)
+		for hash, files := range byHash {
+			for _, file := range files {
+				key := hash + " " + filepath.Dir(file.Path)
+				groups[key] = append(groups[key], file)
+			}
+		}
+
+		// Directories with a single copy have nothing to remove
+		for key, files := range groups {
+			if len(files) < 2 {
+				delete(groups, key)
+			}
+		}
+	}
+
+	if dh.MinGroupSize <= 2 {
+		return groups
+	}
+
+	kept := make(map[string][]FileInfo)
+	for key, files := range groups {
+		if len(files) >= dh.MinGroupSize {
+			kept[key] = files
+			continue
+		}
+		infoColor.Printf("ℹ️  Leaving alone %d copies of %s (fewer than %d)\n", len(files), files[0].Name, dh.MinGroupSize)
+	}
+	return kept
+}
+
 // RemoveDuplicates removes duplicate files, keeping the newest version of each
 func (dh *DuplicateHandler) RemoveDuplicates() error {
 	if len(dh.Scanner.Duplicates) == 0 {
@@ -42,33 +216,28 @@ func (dh *DuplicateHandler) RemoveDuplicates() error {
 		return nil
 	}
 
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("🔄 Processing duplicate files...")
 	
 	totalRemoved := 0
 	totalSpaceSaved := int64(0)
 
-	for hash, files := range dh.Scanner.Duplicates {
+	for hash, files := range dh.duplicateGroups() {
 		if len(files) < 2 {
 			continue
 		}
 
-		// Find the newest file to keep
-		newestFile := files[0]
-		for _, file := range files {
-			if file.LastModified.After(newestFile.LastModified) {
-				newestFile = file
-			}
-		}
+		// Find the file to keep
+		newestFile := dh.keeper(files)
 
 		infoColor.Printf("📋 Processing duplicates for hash: %s...\n", hash[:8]+"...")
 		infoColor.Printf("   Keeping: %s (%.2f MB, modified: %s)\n", 
 			newestFile.Name, 
 			float64(newestFile.Size)/1024/1024, 
 			newestFile.LastModified.Format("2006-01-02 15:04:05"))
+		if !dh.DryRun && !dh.survivorIntact(newestFile) {
+			fmt.Println()
+			continue
+		}
 
 		// Remove all other duplicates
 		for _, file := range files {
@@ -78,9 +247,13 @@ func (dh *DuplicateHandler) RemoveDuplicates() error {
 
 			if dh.DryRun {
 				warningColor.Printf("   🗑️  Would remove: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
+				dh.Plan.Add("remove", file.Path, "")
 			} else {
+				if !dh.Deadline.Allow(file.Path) || !dh.Approver.Approve(fmt.Sprintf("Remove %s", file.Path)) || changedSinceScan(file) {
+					continue
+				}
 				fmt.Printf("   🗑️  Removing: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
-				err := os.Remove(file.Path)
+				err := dh.removeFile(file)
 				if err != nil {
 					warningColor.Printf("   ⚠️  Failed to remove %s: %v\n", file.Name, err)
 					continue
@@ -88,11 +261,21 @@ func (dh *DuplicateHandler) RemoveDuplicates() error {
 			}
 			
 			totalRemoved++
-			totalSpaceSaved += file.Size
+			totalSpaceSaved += reclaimedSize(file)
+			dh.Removed = append(dh.Removed, DeletionRecord{
+				OriginalPath: file.Path,
+				Size:         file.Size,
+				Hash:         file.Hash,
+				Survivor:     newestFile.Path,
+				RemovedAt:    time.Now(),
+			})
 		}
 		fmt.Println()
 	}
 
+	dh.TotalRemoved += totalRemoved
+	dh.TotalSpaceSaved += totalSpaceSaved
+
 	if totalRemoved > 0 {
 		successColor.Printf("✅ Removed %d duplicate files!\n", totalRemoved)
 		successColor.Printf("💾 Space saved: %.2f MB\n", float64(totalSpaceSaved)/1024/1024)
@@ -110,11 +293,6 @@ func (dh *DuplicateHandler) RemoveDuplicatesInteractive() error {
 		return nil
 	}
 
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-	errorColor := color.New(color.FgRed, color.Bold)
-
 	fmt.Println("🔄 Interactive duplicate removal...")
 	fmt.Println("For each set of duplicates, you'll be asked which file to keep.")
 	fmt.Println()
@@ -122,76 +300,68 @@ func (dh *DuplicateHandler) RemoveDuplicatesInteractive() error {
 	totalRemoved := 0
 	totalSpaceSaved := int64(0)
 
-	for hash, files := range dh.Scanner.Duplicates {
+	for hash, files := range dh.duplicateGroups() {
 		if len(files) < 2 {
 			continue
 		}
 
-		infoColor.Printf("📋 Found %d duplicates with hash: %s\n", len(files), hash[:8]+"...")
-		
-		// Display files with numbers
-		for i, file := range files {
-			fmt.Printf("   %d. %s (%.2f MB, modified: %s)\n", 
-				i+1, 
-				file.Name, 
-				float64(file.Size)/1024/1024, 
-				file.LastModified.Format("2006-01-02 15:04:05"))
-		}
-
-		// Ask user which file to keep
-		var choice int
-		for {
-			fmt.Printf("\n🤔 Which file would you like to keep? (1-%d, or 0 to skip): ", len(files))
-			_, err := fmt.Scanln(&choice)
-			if err != nil {
-				fmt.Println("   Please enter a valid number.")
-				// Clear the input buffer to prevent infinite loop
-				var discard string
-				fmt.Scanln(&discard)
-				continue
-			}
-			
-			if choice == 0 {
-				fmt.Println("   Skipping this set of duplicates.")
-				break
+		// Don't ask about a group once nothing more may be removed
+		if !dh.DryRun && dh.Deadline.Expired() {
+			for _, file := range files {
+				dh.Deadline.Allow(file.Path)
 			}
-			
-			if choice < 1 || choice > len(files) {
-				fmt.Printf("   Please enter a number between 1 and %d.\n", len(files))
+			continue
+		}
+
+		infoColor.Printf("📋 Found %d duplicates with hash: %s\n", len(files), hash[:8]+"...")
+
+		// Ask user which file to keep, never offering a symlink over its target
+		choices := keepable(files)
+		choice := dh.chooseKeeper(choices)
+		if choice < 0 {
+			fmt.Println("   Skipping this set of duplicates.")
+			fmt.Println()
+			continue
+		}
+
+		keepFile := choices[choice]
+		infoColor.Printf("   Keeping: %s\n", keepFile.Name)
+		if !dh.DryRun && !dh.survivorIntact(keepFile) {
+			fmt.Println()
+			continue
+		}
+
+		// Remove other files
+		for _, file := range files {
+			if file.Path == keepFile.Path {
 				continue
 			}
-			
-			// Valid choice
-			keepFile := files[choice-1]
-			infoColor.Printf("   Keeping: %s\n", keepFile.Name)
-			
-			// Remove other files
-			for i, file := range files {
-				if i == choice-1 {
+
+			if dh.DryRun {
+				warningColor.Printf("   🗑️  Would remove: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
+				dh.Plan.Add("remove", file.Path, "")
+			} else {
+				if !dh.Deadline.Allow(file.Path) || changedSinceScan(file) {
 					continue
 				}
-
-				if dh.DryRun {
-					warningColor.Printf("   🗑️  Would remove: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
-				} else {
-					fmt.Printf("   🗑️  Removing: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
-					err := os.Remove(file.Path)
-					if err != nil {
-						errorColor.Printf("   ❌ Failed to remove %s: %v\n", file.Name, err)
-						continue
-					}
+				fmt.Printf("   🗑️  Removing: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
+				err := dh.removeFile(file)
+				if err != nil {
+					errorColor.Printf("   ❌ Failed to remove %s: %v\n", file.Name, err)
+					continue
 				}
-				
-				totalRemoved++
-				totalSpaceSaved += file.Size
 			}
-			
-			break
+
+			totalRemoved++
+			totalSpaceSaved += reclaimedSize(file)
 		}
-		
+
 		fmt.Println()
 	}
 
+	dh.TotalRemoved += totalRemoved
+	dh.TotalSpaceSaved += totalSpaceSaved
+
 	if totalRemoved > 0 {
 		successColor.Printf("✅ Removed %d duplicate files!\n", totalRemoved)
 		successColor.Printf("💾 Space saved: %.2f MB\n", float64(totalSpaceSaved)/1024/1024)
@@ -202,6 +372,115 @@ func (dh *DuplicateHandler) RemoveDuplicatesInteractive() error {
 	return nil
 }
 
+// interactivePageSize is how many files of a duplicate group are listed at a time
+const interactivePageSize = 10
+
+// readAnswer reads a line of input for interactive mode
+func (dh *DuplicateHandler) readAnswer() (string, error) {
+	if dh.input == nil {
+		dh.input = bufio.NewReader(os.Stdin)
+	}
+	line, err := dh.input.ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+// chooseKeeper asks which file of a duplicate group to keep and returns its
+// index, or -1 to skip the group. Long groups are listed a page at a time,
+// and the keeper can also be picked by searching for part of its path.
+func (dh *DuplicateHandler) chooseKeeper(files []FileInfo) int {
+	shown := make([]int, len(files)) // Indexes of the files being listed
+	for i := range files {
+		shown[i] = i
+	}
+	paged := len(files) > interactivePageSize
+	page := 0
+	redraw := true
+
+	for {
+		pages := (len(shown) + interactivePageSize - 1) / interactivePageSize
+		if redraw {
+			start := page * interactivePageSize
+			end := start + interactivePageSize
+			if end > len(shown) {
+				end = len(shown)
+			}
+			for _, i := range shown[start:end] {
+				name := files[i].Name
+				if paged {
+					name = files[i].Path // Long groups are usually spread over folders
+				}
+				fmt.Printf("   %d. %s (%.2f MB, modified: %s)\n",
+					i+1,
+					name,
+					float64(files[i].Size)/1024/1024,
+					files[i].LastModified.Format("2006-01-02 15:04:05"))
+			}
+			if pages > 1 {
+				fmt.Printf("   Page %d of %d (n: next page, p: previous page)\n", page+1, pages)
+			}
+			redraw = false
+		}
+
+		if paged {
+			fmt.Printf("\n🤔 Which file would you like to keep? (1-%d, /text to search by path, or 0 to skip): ", len(files))
+		} else {
+			fmt.Printf("\n🤔 Which file would you like to keep? (1-%d, or 0 to skip): ", len(files))
+		}
+		answer, err := dh.readAnswer()
+
+		switch {
+		case answer == "n" && pages > 1:
+			if page < pages-1 {
+				page++
+			}
+			redraw = true
+			continue
+		case answer == "p" && pages > 1:
+			if page > 0 {
+				page--
+			}
+			redraw = true
+			continue
+		case strings.HasPrefix(answer, "/"):
+			term := strings.ToLower(strings.TrimSpace(answer[1:]))
+			var matches []int
+			for i, file := range files {
+				if term != "" && strings.Contains(strings.ToLower(file.Path), term) {
+					matches = append(matches, i)
+				}
+			}
+			if len(matches) == 1 {
+				return matches[0]
+			}
+			if len(matches) == 0 {
+				fmt.Printf("   No files match %q.\n", term)
+				continue
+			}
+			fmt.Printf("   %d files match %q:\n", len(matches), term)
+			shown, page, redraw = matches, 0, true
+			continue
+		}
+
+		choice, convErr := strconv.Atoi(answer)
+		if convErr != nil {
+			if err != nil {
+				fmt.Println()
+				return -1 // Out of input
+			}
+			fmt.Println("   Please enter a valid number.")
+			continue
+		}
+		if choice == 0 {
+			return -1
+		}
+		if choice < 1 || choice > len(files) {
+			fmt.Printf("   Please enter a number between 1 and %d.\n", len(files))
+			continue
+		}
+		return choice - 1
+	}
+}
+
 // RemoveDuplicatesByPattern removes duplicates based on naming patterns
 func (dh *DuplicateHandler) RemoveDuplicatesByPattern() error {
 	if len(dh.Scanner.Duplicates) == 0 {
@@ -209,60 +488,50 @@ func (dh *DuplicateHandler) RemoveDuplicatesByPattern() error {
 		return nil
 	}
 
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("🔄 Removing duplicates by pattern...")
-	fmt.Println("Keeping files without copy indicators like '(1)', 'copy', etc.")
+	fmt.Println("Keeping the copy that looks most like the original: no '(1)' or 'copy', a shorter name, an older date")
 	fmt.Println()
 
 	totalRemoved := 0
 	totalSpaceSaved := int64(0)
 
-	for hash, files := range dh.Scanner.Duplicates {
+	var resolver DuplicateResolver = PatternResolver{Weights: dh.Weights}
+	if dh.Resolver != nil {
+		resolver = dh.Resolver
+	}
+
+	for hash, files := range dh.duplicateGroups() {
 		if len(files) < 2 {
 			continue
 		}
 
-		// Find the file that looks like the original (no copy indicators)
-		var originalFile *FileInfo
+		// Find the file that looks most like the original
+		originalFile := resolver.Keep(keepable(files))
 		var copyFiles []FileInfo
-
-		for i := range files {
-			if dh.isOriginalFile(files[i].Name) {
-				originalFile = &files[i]
-			} else {
-				copyFiles = append(copyFiles, files[i])
-			}
-		}
-
-		// If we couldn't determine an original, keep the newest
-		if originalFile == nil {
-			originalFile = &files[0]
-			for _, file := range files {
-				if file.LastModified.After(originalFile.LastModified) {
-					originalFile = &file
-				}
-			}
-			// Add all other files to copies
-			for _, file := range files {
-				if file.Path != originalFile.Path {
-					copyFiles = append(copyFiles, file)
-				}
+		for _, file := range files {
+			if file.Path != originalFile.Path {
+				copyFiles = append(copyFiles, file)
 			}
 		}
 
 		infoColor.Printf("📋 Processing duplicates for hash: %s...\n", hash[:8]+"...")
 		infoColor.Printf("   Keeping: %s (%.2f MB)\n", originalFile.Name, float64(originalFile.Size)/1024/1024)
+		if !dh.DryRun && !dh.survivorIntact(originalFile) {
+			fmt.Println()
+			continue
+		}
 
 		// Remove copy files
 		for _, file := range copyFiles {
 			if dh.DryRun {
 				warningColor.Printf("   🗑️  Would remove: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
+				dh.Plan.Add("remove", file.Path, "")
 			} else {
+				if !dh.Deadline.Allow(file.Path) || !dh.Approver.Approve(fmt.Sprintf("Remove %s", file.Path)) || changedSinceScan(file) {
+					continue
+				}
 				fmt.Printf("   🗑️  Removing: %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
-				err := os.Remove(file.Path)
+				err := dh.removeFile(file)
 				if err != nil {
 					warningColor.Printf("   ⚠️  Failed to remove %s: %v\n", file.Name, err)
 					continue
@@ -270,11 +539,14 @@ func (dh *DuplicateHandler) RemoveDuplicatesByPattern() error {
 			}
 			
 			totalRemoved++
-			totalSpaceSaved += file.Size
+			totalSpaceSaved += reclaimedSize(file)
 		}
 		fmt.Println()
 	}
 
+	dh.TotalRemoved += totalRemoved
+	dh.TotalSpaceSaved += totalSpaceSaved
+
 	if totalRemoved > 0 {
 		successColor.Printf("✅ Removed %d duplicate files!\n", totalRemoved)
 		successColor.Printf("💾 Space saved: %.2f MB\n", float64(totalSpaceSaved)/1024/1024)
@@ -287,34 +559,152 @@ func (dh *DuplicateHandler) RemoveDuplicatesByPattern() error {
 
 // isOriginalFile determines if a filename looks like an original (not a copy)
 func (dh *DuplicateHandler) isOriginalFile(filename string) bool {
+	return isOriginalName(filename)
+}
+
+// copyPatterns are the markers that show a file name belongs to a copy
+var copyPatterns = []string{
+	" (1)", " (2)", " (3)", " (4)", " (5)", " (6)", " (7)", " (8)", " (9)", " (10)",
+	" copy", " copy (1)", " copy (2)", " copy (3)", " copy (4)", " copy (5)",
+	" - copy", " - copy (1)", " - copy (2)", " - copy (3)", " - copy (4)", " - copy (5)",
+	"_copy", "_copy(1)", "_copy(2)", "_copy(3)", "_copy(4)", "_copy(5)",
+	"-copy", "-copy(1)", "-copy(2)", "-copy(3)", "-copy(4)", "-copy(5)",
+	".copy", ".copy(1)", ".copy(2)", ".copy(3)", ".copy(4)", ".copy(5)",
+	" (copy)", " (copy 1)", " (copy 2)", " (copy 3)", " (copy 4)", " (copy 5)",
+	"- (copy)", "- (copy 1)", "- (copy 2)", "- (copy 3)", "- (copy 4)", "- (copy 5)",
+	"_ (copy)", "_ (copy 1)", "_ (copy 2)", "_ (copy 3)", "_ (copy 4)", "_ (copy 5)",
+	" duplicate", " duplicate (1)", " duplicate (2)", " duplicate (3)", " duplicate (4)", " duplicate (5)",
+	" - duplicate", " - duplicate (1)", " - duplicate (2)", " - duplicate (3)", " - duplicate (4)", " - duplicate (5)",
+	"_duplicate", "_duplicate(1)", "_duplicate(2)", "_duplicate(3)", "_duplicate(4)", "_duplicate(5)",
+	"-duplicate", "-duplicate(1)", "-duplicate(2)", "-duplicate(3)", "-duplicate(4)", "-duplicate(5)",
+}
+
+// isOriginalName determines if a filename looks like an original (not a copy)
+func isOriginalName(filename string) bool {
 	lowerName := strings.ToLower(filename)
-	
-	// Patterns that indicate a file is a copy
-	copyPatterns := []string{
-		" (1)", " (2)", " (3)", " (4)", " (5)", " (6)", " (7)", " (8)", " (9)", " (10)",
-		" copy", " copy (1)", " copy (2)", " copy (3)", " copy (4)", " copy (5)",
-		" - copy", " - copy (1)", " - copy (2)", " - copy (3)", " - copy (4)", " - copy (5)",
-		"_copy", "_copy(1)", "_copy(2)", "_copy(3)", "_copy(4)", "_copy(5)",
-		"-copy", "-copy(1)", "-copy(2)", "-copy(3)", "-copy(4)", "-copy(5)",
-		".copy", ".copy(1)", ".copy(2)", ".copy(3)", ".copy(4)", ".copy(5)",
-		" (copy)", " (copy 1)", " (copy 2)", " (copy 3)", " (copy 4)", " (copy 5)",
-		"- (copy)", "- (copy 1)", "- (copy 2)", "- (copy 3)", "- (copy 4)", "- (copy 5)",
-		"_ (copy)", "_ (copy 1)", "_ (copy 2)", "_ (copy 3)", "_ (copy 4)", "_ (copy 5)",
-		" duplicate", " duplicate (1)", " duplicate (2)", " duplicate (3)", " duplicate (4)", " duplicate (5)",
-		" - duplicate", " - duplicate (1)", " - duplicate (2)", " - duplicate (3)", " - duplicate (4)", " - duplicate (5)",
-		"_duplicate", "_duplicate(1)", "_duplicate(2)", "_duplicate(3)", "_duplicate(4)", "_duplicate(5)",
-		"-duplicate", "-duplicate(1)", "-duplicate(2)", "-duplicate(3)", "-duplicate(4)", "-duplicate(5)",
-	}
-	
+
 	for _, pattern := range copyPatterns {
 		if strings.Contains(lowerName, pattern) {
 			return false
 		}
 	}
-	
+
 	return true
 }
 
+// canonicalName strips a copy marker from the end of a file name, before its
+// extension, so "report (1).pdf" becomes "report.pdf". The longest matching
+// marker is removed, and names without one are returned unchanged.
+func canonicalName(filename string) string {
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext)
+
+	marker := ""
+	for _, pattern := range copyPatterns {
+		if len(pattern) > len(marker) && len(pattern) < len(stem) && strings.EqualFold(stem[len(stem)-len(pattern):], pattern) {
+			marker = pattern
+		}
+	}
+	return stem[:len(stem)-len(marker)] + ext
+}
+
+// holdingName picks the name a duplicate gets in the folder it is moved to.
+// With RestoreNames it gets its canonical name, unless that is already taken
+// in the folder or by another duplicate moved there in this run.
+func (dh *DuplicateHandler) holdingName(file FileInfo, destFolder string, taken map[string]bool) string {
+	if !dh.RestoreNames {
+		return file.Name
+	}
+	name := canonicalName(file.Name)
+	if name == file.Name || taken[name] {
+		return file.Name
+	}
+	if _, err := os.Lstat(filepath.Join(destFolder, name)); !os.IsNotExist(err) {
+		return file.Name
+	}
+	taken[name] = true
+	return name
+}
+
+// checkMoveDestination resolves the folder duplicates will be moved into and
+// reports whether it is inside the scanned folder, in which case it has to be
+// left out of the scan. The scanned folder itself is refused outright.
+func checkMoveDestination(root, dest string) (string, bool, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid path: %v", err)
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid move folder path: %v", err)
+	}
+
+	rel, err := filepath.Rel(absRoot, absDest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return absDest, false, nil
+	}
+	if rel == "." {
+		return "", false, fmt.Errorf("duplicates can't be moved into the folder being cleaned: %s", absDest)
+	}
+	return absDest, true, nil
+}
+
+// groupFolders returns the folder each group of duplicates is moved into:
+// destFolder itself, or with GroupOutput a group-NNN subfolder of it per group.
+// Groups are numbered in order of the path of the copy kept, after any group
+// folders left by earlier runs.
+func (dh *DuplicateHandler) groupFolders(groups map[string][]FileInfo, destFolder string) map[string]string {
+	folders := make(map[string]string, len(groups))
+	if !dh.GroupOutput {
+		for hash := range groups {
+			folders[hash] = destFolder
+		}
+		return folders
+	}
+
+	keepers := make(map[string]string, len(groups))
+	var hashes []string
+	for hash, files := range groups {
+		if len(files) < 2 {
+			continue
+		}
+		keepers[hash] = dh.keeper(files).Path
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return keepers[hashes[i]] < keepers[hashes[j]] })
+
+	n := 1
+	for _, hash := range hashes {
+		for {
+			folder := filepath.Join(destFolder, fmt.Sprintf("group-%03d", n))
+			n++
+			if _, err := os.Lstat(folder); os.IsNotExist(err) {
+				folders[hash] = folder
+				break
+			}
+		}
+	}
+	return folders
+}
+
+// keptFileName names the file in each group folder that records the copy kept
+const keptFileName = "KEPT.txt"
+
+// writeKeptFile records in a group folder which copy of its duplicates was
+// kept. A duplicate that was itself named KEPT.txt is left alone, and the
+// record gets a "(n)" suffix instead.
+func writeKeptFile(folder string, kept FileInfo) error {
+	content := fmt.Sprintf("Kept: %s\n", kept.Path)
+	if kept.Hash != "" {
+		content += fmt.Sprintf("Hash: %s\n", kept.Hash)
+	}
+	path := filepath.Join(folder, keptFileName)
+	if _, err := os.Lstat(path); err == nil {
+		path = nextFreeName(folder, keptFileName)
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
 // MoveDuplicatesToFolder moves duplicate files to a specified folder instead of deleting them
 func (dh *DuplicateHandler) MoveDuplicatesToFolder(destFolder string) error {
 	if len(dh.Scanner.Duplicates) == 0 {
@@ -322,10 +712,6 @@ func (dh *DuplicateHandler) MoveDuplicatesToFolder(destFolder string) error {
 		return nil
 	}
 
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	// Create destination folder if it doesn't exist
 	if !dh.DryRun {
 		err := os.MkdirAll(destFolder, 0755)
@@ -334,53 +720,118 @@ func (dh *DuplicateHandler) MoveDuplicatesToFolder(destFolder string) error {
 		}
 	}
 
+	groups := dh.duplicateGroups()
+	folders := dh.groupFolders(groups, destFolder)
+
+	// Make sure a move to another drive won't run out of space halfway
+	var moves []pendingMove
+	for hash, files := range groups {
+		if len(files) < 2 {
+			continue
+		}
+		newestFile := dh.keeper(files)
+		for _, file := range files {
+			if file.Path != newestFile.Path {
+				moves = append(moves, pendingMove{Src: file.Path, Dst: filepath.Join(folders[hash], file.Name), Size: file.Size})
+			}
+		}
+	}
+	if err := dh.spaceChecker.Check(moves); err != nil {
+		if !dh.DryRun {
+			return err
+		}
+		warningColor.Printf("⚠️  %v\n", err)
+	}
+
 	fmt.Printf("🔄 Moving duplicates to: %s\n", destFolder)
 	fmt.Println()
 
 	totalMoved := 0
 	totalSpaceSaved := int64(0)
 
-	for hash, files := range dh.Scanner.Duplicates {
+	// Names duplicates keep or are given in each destination folder; a
+	// restored name mustn't clash with another duplicate moved there under
+	// its own name
+	taken := make(map[string]map[string]bool)
+	for _, move := range moves {
+		folder := filepath.Dir(move.Dst)
+		if taken[folder] == nil {
+			taken[folder] = make(map[string]bool)
+		}
+		taken[folder][filepath.Base(move.Src)] = true
+	}
+
+	for hash, files := range groups {
 		if len(files) < 2 {
 			continue
 		}
 
-		// Find the newest file to keep
-		newestFile := files[0]
-		for _, file := range files {
-			if file.LastModified.After(newestFile.LastModified) {
-				newestFile = file
-			}
-		}
+		// Find the file to keep
+		newestFile := dh.keeper(files)
 
 		infoColor.Printf("📋 Processing duplicates for hash: %s...\n", hash[:8]+"...")
 		infoColor.Printf("   Keeping: %s (%.2f MB)\n", newestFile.Name, float64(newestFile.Size)/1024/1024)
+		if !dh.DryRun && !dh.survivorIntact(newestFile) {
+			fmt.Println()
+			continue
+		}
+		folder := folders[hash]
+		if dh.GroupOutput && !dh.DryRun {
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				warningColor.Printf("   ⚠️  Failed to create folder %s: %v\n", folder, err)
+				fmt.Println()
+				continue
+			}
+		}
 
 		// Move all other duplicates
+		groupMoved := 0
 		for _, file := range files {
 			if file.Path == newestFile.Path {
 				continue
 			}
 
-			destPath := filepath.Join(destFolder, file.Name)
+			destName := dh.holdingName(file, folder, taken[folder])
+			destPath := filepath.Join(folder, destName)
 			
 			if dh.DryRun {
-				warningColor.Printf("   📁 Would move: %s -> %s\n", file.Name, destFolder)
+				if destName != file.Name {
+					warningColor.Printf("   📁 Would move: %s -> %s as %s\n", file.Name, folder, destName)
+				} else {
+					warningColor.Printf("   📁 Would move: %s -> %s\n", file.Name, folder)
+				}
+				dh.Plan.Add("move", file.Path, destPath)
 			} else {
+				if !dh.Deadline.Allow(file.Path) || !dh.Approver.Approve(fmt.Sprintf("Move %s -> %s", file.Path, folder)) || changedSinceScan(file) {
+					continue
+				}
 				fmt.Printf("   📁 Moving: %s\n", file.Name)
 				err := dh.atomicMove(file.Path, destPath)
+				dh.Plan.Record("move", file.Path, destPath, err)
 				if err != nil {
 					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", file.Name, err)
 					continue
 				}
 			}
 			
-			totalMoved++
-			totalSpaceSaved += file.Size
+			groupMoved++
+			totalSpaceSaved += reclaimedSize(file)
+		}
+		totalMoved += groupMoved
+
+		if dh.GroupOutput && !dh.DryRun {
+			if groupMoved == 0 {
+				os.Remove(folder) // Nothing was moved, so don't leave an empty group behind
+			} else if err := writeKeptFile(folder, newestFile); err != nil {
+				warningColor.Printf("   ⚠️  Failed to record the kept copy: %v\n", err)
+			}
 		}
 		fmt.Println()
 	}
 
+	dh.TotalRemoved += totalMoved
+	dh.TotalSpaceSaved += totalSpaceSaved
+
 	if totalMoved > 0 {
 		successColor.Printf("✅ Moved %d duplicate files!\n", totalMoved)
 		successColor.Printf("💾 Space saved in original folder: %.2f MB\n", float64(totalSpaceSaved)/1024/1024)
@@ -405,17 +856,26 @@ func (dh *DuplicateHandler) copyAndDelete(src, dst string) error {
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	// Copy file content
-	_, err = dstFile.ReadFrom(srcFile)
+	// Copy file content and sync to ensure data is written, removing a
+	// partial copy so it isn't left in the duplicates folder
+	_, err = dstFile.ReadFrom(dh.Throttle.Reader(srcFile))
+	if err == nil {
+		err = dstFile.Sync()
+	}
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		os.Remove(dst)
 		return err
 	}
 
-	// Sync to ensure data is written
-	if err := dstFile.Sync(); err != nil {
-		return err
+	// A rename keeps extended attributes, but a copy has to carry them over
+	if dh.PreserveXattrs {
+		if err := copyXattrs(src, dst); err != nil {
+			warningColor.Printf("   ⚠️  %s: %v\n", filepath.Base(dst), err)
+		}
 	}
 
 	// Delete source file
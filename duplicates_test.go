@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDuplicateHandler(t *testing.T) {
@@ -160,6 +166,405 @@ func TestMoveDuplicatesToFolder(t *testing.T) {
 	}
 }
 
+func TestMoveDuplicatesRestoreNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "downloads")
+	destDir := filepath.Join(tmpDir, "holding")
+	os.MkdirAll(srcDir, 0755)
+	os.MkdirAll(destDir, 0755)
+
+	// The plain-named file is newest, so it is kept and the marked copy moved
+	older := time.Now().Add(-time.Hour)
+	files := map[string]string{
+		"report.pdf":     "report content",
+		"report (1).pdf": "report content",
+		"notes.txt":      "notes content",
+		"notes copy.txt": "notes content",
+	}
+	for name, content := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if !isOriginalName(name) {
+			os.Chtimes(path, older, older)
+		}
+	}
+	// notes.txt is already taken in the holding folder
+	os.WriteFile(filepath.Join(destDir, "notes.txt"), []byte("older notes"), 0644)
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(srcDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	handler.RestoreNames = true
+	if err := handler.MoveDuplicatesToFolder(destDir); err != nil {
+		t.Fatalf("MoveDuplicatesToFolder() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "report.pdf")); err != nil {
+		t.Errorf("report (1).pdf was not restored to report.pdf: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "notes copy.txt")); err != nil {
+		t.Errorf("notes copy.txt should keep its marker when notes.txt is taken: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "notes.txt")); string(data) != "older notes" {
+		t.Errorf("Existing notes.txt in the holding folder was overwritten")
+	}
+}
+
+func TestMoveDuplicatesGroupOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	srcDir := filepath.Join(tmpDir, "downloads")
+	destDir := filepath.Join(tmpDir, "dupes")
+	os.MkdirAll(srcDir, 0755)
+
+	// The plain-named files are newest, so they are the copies kept
+	older := time.Now().Add(-time.Hour)
+	files := map[string]string{
+		"a.pdf":      "a content",
+		"a (1).pdf":  "a content",
+		"b.txt":      "b content",
+		"b copy.txt": "b content",
+		"b (2).txt":  "b content",
+	}
+	for name, content := range files {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if !isOriginalName(name) {
+			os.Chtimes(path, older, older)
+		}
+	}
+	// An earlier run already used group-001
+	os.MkdirAll(filepath.Join(destDir, "group-001"), 0755)
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(srcDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	handler.GroupOutput = true
+	if err := handler.MoveDuplicatesToFolder(destDir); err != nil {
+		t.Fatalf("MoveDuplicatesToFolder() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"group-002": {"KEPT.txt", "a (1).pdf"},
+		"group-003": {"KEPT.txt", "b (2).txt", "b copy.txt"},
+	}
+	for group, members := range want {
+		entries, err := os.ReadDir(filepath.Join(destDir, group))
+		if err != nil {
+			t.Fatalf("Expected %s to be created: %v", group, err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if !reflect.DeepEqual(names, members) {
+			t.Errorf("Expected %s to hold %v, got %v", group, members, names)
+		}
+	}
+
+	kept, err := os.ReadFile(filepath.Join(destDir, "group-003", "KEPT.txt"))
+	if err != nil || !strings.Contains(string(kept), filepath.Join(srcDir, "b.txt")) {
+		t.Errorf("Expected KEPT.txt to name the kept b.txt, got %q (err %v)", kept, err)
+	}
+	if _, err := os.Stat(filepath.Join(srcDir, "b.txt")); err != nil {
+		t.Errorf("The kept copy should stay in place: %v", err)
+	}
+}
+
+func TestRemoveDuplicatesEqualModTimes(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755)
+
+	// Copied in bulk, so all four were modified at the same moment
+	copied := time.Now().Add(-time.Hour).Truncate(time.Second)
+	paths := []string{"zz.pdf", "aa.pdf", filepath.Join("sub", "a.pdf"), "ab.pdf"}
+	for _, path := range paths {
+		full := filepath.Join(tmpDir, path)
+		if err := os.WriteFile(full, []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", path, err)
+		}
+		os.Chtimes(full, copied, copied)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// The shortest path wins, and of those the alphabetically first
+	want := filepath.Join(tmpDir, "aa.pdf")
+	for _, files := range scanner.Duplicates {
+		reversed := make([]FileInfo, len(files))
+		for i, file := range files {
+			reversed[len(files)-1-i] = file
+		}
+		for _, group := range [][]FileInfo{files, reversed} {
+			if got := findNewest(group).Path; got != want {
+				t.Errorf("Expected %s to be kept, got %s", want, got)
+			}
+		}
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+	for _, path := range paths {
+		_, err := os.Stat(filepath.Join(tmpDir, path))
+		if kept := filepath.Join(tmpDir, path) == want; kept != (err == nil) {
+			t.Errorf("%s: expected kept=%v, stat error %v", path, kept, err)
+		}
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	tests := map[string]string{
+		"report (1).pdf":         "report.pdf",
+		"report - Copy (2).docx": "report.docx",
+		"photo_copy.jpg":         "photo.jpg",
+		"archive.tar copy.gz":    "archive.tar.gz",
+		"report.pdf":             "report.pdf",
+		" (1).txt":               " (1).txt",
+	}
+	for name, want := range tests {
+		if got := canonicalName(name); got != want {
+			t.Errorf("canonicalName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMoveDuplicatesNestedDestination(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "duplicates")
+
+	// A previous run already left a copy in the destination
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, path := range []string{"file1.txt", "file2.txt", filepath.Join("duplicates", "file0.txt")} {
+		if err := os.WriteFile(filepath.Join(tmpDir, path), []byte("duplicate content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", path, err)
+		}
+	}
+
+	absDest, nested, err := checkMoveDestination(tmpDir, destDir)
+	if err != nil {
+		t.Fatalf("checkMoveDestination() error = %v", err)
+	}
+	if !nested {
+		t.Fatal("Expected a destination under the scan root to be reported as nested")
+	}
+
+	scanner := NewScanner()
+	scanner.ExcludeDirs = []string{absDest}
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	for _, file := range scanner.Files {
+		if filepath.Dir(file.Path) == destDir {
+			t.Errorf("Expected %s in the destination to be left out of the scan", file.Path)
+		}
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.MoveDuplicatesToFolder(destDir); err != nil {
+		t.Fatalf("MoveDuplicatesToFolder() error = %v", err)
+	}
+	if handler.TotalRemoved != 1 {
+		t.Errorf("Expected 1 duplicate moved, got %d", handler.TotalRemoved)
+	}
+
+	// The scan root itself is refused, and folders elsewhere aren't nested
+	if _, _, err := checkMoveDestination(tmpDir, tmpDir+string(filepath.Separator)); err == nil {
+		t.Error("Expected an error when moving duplicates into the scan root")
+	}
+	if _, nested, err := checkMoveDestination(destDir, filepath.Join(tmpDir, "duplicates-old")); err != nil || nested {
+		t.Errorf("Expected a sibling folder not to be nested, got nested=%v err=%v", nested, err)
+	}
+}
+
+func TestRemoveDuplicatesReadOnly(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		keep := filepath.Join(tmpDir, "report.pdf")
+		readOnly := filepath.Join(tmpDir, "report (1).pdf")
+		if err := os.WriteFile(keep, []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := os.WriteFile(readOnly, []byte("same content"), 0444); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(tmpDir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+
+		handler := NewDuplicateHandler(scanner, false)
+		handler.ForceDeleteReadOnly = force
+		if err := handler.RemoveDuplicatesByPattern(); err != nil {
+			t.Fatalf("RemoveDuplicatesByPattern() error = %v", err)
+		}
+
+		_, err := os.Stat(readOnly)
+		if force {
+			if !os.IsNotExist(err) {
+				t.Error("Expected the read-only duplicate to be removed with ForceDeleteReadOnly")
+			}
+			if handler.ReadOnlySkipped != 0 || handler.TotalRemoved != 1 {
+				t.Errorf("Expected 1 removed and none skipped, got %d and %d", handler.TotalRemoved, handler.ReadOnlySkipped)
+			}
+		} else {
+			if err != nil {
+				t.Errorf("Expected the read-only duplicate to be kept by default: %v", err)
+			}
+			if handler.ReadOnlySkipped != 1 || handler.TotalRemoved != 0 {
+				t.Errorf("Expected 1 skipped and none removed, got %d and %d", handler.ReadOnlySkipped, handler.TotalRemoved)
+			}
+		}
+		if _, err := os.Stat(keep); err != nil {
+			t.Errorf("Expected the original to be kept: %v", err)
+		}
+	}
+}
+
+func TestRemoveDuplicatesSymlinks(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		data := []byte(strings.Repeat("x", 1000))
+		original := filepath.Join(tmpDir, "data.bin")
+		copyPath := filepath.Join(tmpDir, "data copy.bin")
+		link := filepath.Join(tmpDir, "link.bin")
+		for i, path := range []string{copyPath, original} {
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			modTime := time.Now().Add(time.Duration(i-2) * time.Hour)
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatalf("Failed to set modification time: %v", err)
+			}
+		}
+		// The link is the newest copy, so it would be kept if it were a file
+		if err := os.Symlink(original, link); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(tmpDir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+		handler := NewDuplicateHandler(scanner, false)
+		handler.SkipSymlinks = skip
+		if err := handler.RemoveDuplicates(); err != nil {
+			t.Fatalf("RemoveDuplicates() error = %v", err)
+		}
+
+		// The file the link points to is kept either way
+		if _, err := os.Stat(original); err != nil {
+			t.Errorf("Expected the linked file to be kept: %v", err)
+		}
+		if _, err := os.Stat(copyPath); !os.IsNotExist(err) {
+			t.Error("Expected the real copy to be removed")
+		}
+
+		// Removing the link reclaims nothing
+		if handler.TotalSpaceSaved != int64(len(data)) {
+			t.Errorf("Expected %d bytes reclaimed, got %d", len(data), handler.TotalSpaceSaved)
+		}
+		_, err := os.Lstat(link)
+		if skip {
+			if err != nil || handler.TotalRemoved != 1 {
+				t.Errorf("Expected the link to be left in place and 1 removed, got %d removed (%v)", handler.TotalRemoved, err)
+			}
+		} else if !os.IsNotExist(err) || handler.TotalRemoved != 2 {
+			t.Errorf("Expected the link to be removed along with the copy, got %d removed", handler.TotalRemoved)
+		}
+	}
+}
+
+func TestSymlinkNeverKeptOverTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	original := filepath.Join(tmpDir, "data.bin")
+	if err := os.WriteFile(original, []byte("the only real copy"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	older := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(original, older, older); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+	link := filepath.Join(tmpDir, "link.bin")
+	if err := os.Symlink(original, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// A resolver that would pick the newer link deletes the link in the script
+	var script bytes.Buffer
+	handler := NewDuplicateHandler(scanner, false)
+	if _, err := handler.WriteDeletionScript(&script, NewestResolver{}, false); err != nil {
+		t.Fatalf("WriteDeletionScript() error = %v", err)
+	}
+	if !strings.Contains(script.String(), "# Keep: "+original+"\n") {
+		t.Errorf("Expected the script to keep %s, got:\n%s", original, script.String())
+	}
+
+	// Only the real file is offered as the one to keep, so asking for the link fails
+	handler.input = bufio.NewReader(strings.NewReader("2\n1\n"))
+	if err := handler.RemoveDuplicatesInteractive(); err != nil {
+		t.Fatalf("RemoveDuplicatesInteractive() error = %v", err)
+	}
+	if _, err := os.Stat(original); err != nil {
+		t.Errorf("Expected the linked file to be kept: %v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("Expected the link to be removed")
+	}
+}
+
+func TestRemoveDuplicatesChangedSurvivor(t *testing.T) {
+	tmpDir := t.TempDir()
+	keep := filepath.Join(tmpDir, "report.pdf")
+	copyPath := filepath.Join(tmpDir, "report (1).pdf")
+	for _, path := range []string{keep, copyPath} {
+		if err := os.WriteFile(path, []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// The copy that would be kept gets overwritten after the scan
+	if err := os.WriteFile(keep, []byte("new content!"), 0644); err != nil {
+		t.Fatalf("Failed to modify test file: %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicatesByPattern(); err != nil {
+		t.Fatalf("RemoveDuplicatesByPattern() error = %v", err)
+	}
+
+	if _, err := os.Stat(copyPath); err != nil {
+		t.Errorf("Expected the only remaining good copy to be kept: %v", err)
+	}
+	if handler.TotalRemoved != 0 {
+		t.Errorf("Expected nothing removed, got %d", handler.TotalRemoved)
+	}
+}
+
 func TestAtomicMove(t *testing.T) {
 	handler := NewDuplicateHandler(nil, true)
 
@@ -229,4 +634,212 @@ func TestCopyAndDelete(t *testing.T) {
 	if string(content) != testContent {
 		t.Errorf("Content mismatch: expected '%s', got '%s'", testContent, string(content))
 	}
-} 
\ No newline at end of file
+}
+
+func TestRemoveDuplicatesPerDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	dirA := filepath.Join(tmpDir, "a")
+	dirB := filepath.Join(tmpDir, "b")
+
+	// Two copies in each directory
+	testContent := "duplicate content"
+	paths := []string{
+		filepath.Join(dirA, "file1.txt"),
+		filepath.Join(dirA, "file2.txt"),
+		filepath.Join(dirB, "file1.txt"),
+		filepath.Join(dirB, "file2.txt"),
+	}
+	for _, path := range paths {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(testContent), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", path, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	handler.PerDirectory = true
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Errorf("RemoveDuplicates() error = %v", err)
+	}
+
+	// Exactly one copy should survive in each directory
+	for _, dir := range []string{dirA, dirB} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to read directory %s: %v", dir, err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("Expected 1 file in %s, got %d", dir, len(entries))
+		}
+	}
+
+	if handler.TotalRemoved != 2 {
+		t.Errorf("Expected 2 files removed, got %d", handler.TotalRemoved)
+	}
+}
+
+func TestMinGroupSize(t *testing.T) {
+	modes := []struct {
+		name string
+		run  func(dh *DuplicateHandler, tmpDir string) error
+	}{
+		{"remove", func(dh *DuplicateHandler, tmpDir string) error { return dh.RemoveDuplicates() }},
+		{"pattern", func(dh *DuplicateHandler, tmpDir string) error { return dh.RemoveDuplicatesByPattern() }},
+		{"move", func(dh *DuplicateHandler, tmpDir string) error {
+			return dh.MoveDuplicatesToFolder(filepath.Join(tmpDir, "dupes"))
+		}},
+	}
+
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			files := map[string]string{
+				"pair.txt":     "two copies",
+				"pair (1).txt": "two copies",
+				"trio.txt":     "three copies",
+				"trio (1).txt": "three copies",
+				"trio (2).txt": "three copies",
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create test file %s: %v", name, err)
+				}
+			}
+
+			scanner := NewScanner()
+			if err := scanner.ScanDirectory(tmpDir); err != nil {
+				t.Fatalf("ScanDirectory() error = %v", err)
+			}
+
+			handler := NewDuplicateHandler(scanner, false)
+			handler.MinGroupSize = 3
+			if err := mode.run(handler, tmpDir); err != nil {
+				t.Fatalf("%s error = %v", mode.name, err)
+			}
+
+			// The pair is below the threshold and must be untouched
+			for _, name := range []string{"pair.txt", "pair (1).txt"} {
+				if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+					t.Errorf("Expected %s to be left alone: %v", name, err)
+				}
+			}
+
+			// Only one of the trio should be left
+			remaining := 0
+			for _, name := range []string{"trio.txt", "trio (1).txt", "trio (2).txt"} {
+				if _, err := os.Stat(filepath.Join(tmpDir, name)); err == nil {
+					remaining++
+				}
+			}
+			if remaining != 1 {
+				t.Errorf("Expected 1 copy of trio.txt left, got %d", remaining)
+			}
+			if handler.TotalRemoved != 2 {
+				t.Errorf("Expected 2 duplicates handled, got %d", handler.TotalRemoved)
+			}
+		})
+	}
+}
+
+func TestRemoveDuplicatesInteractiveSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A long group of identical files spread over many folders
+	for i := 1; i <= 25; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("folder-%02d", i), "wallpaper.jpg")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("same wallpaper"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Page forward, try a search with no hits, then one that narrows to folder-17
+	handler := NewDuplicateHandler(scanner, false)
+	handler.input = bufio.NewReader(strings.NewReader("n\n/nowhere\n/FOLDER-17\n"))
+	if err := handler.RemoveDuplicatesInteractive(); err != nil {
+		t.Fatalf("RemoveDuplicatesInteractive() error = %v", err)
+	}
+
+	kept := filepath.Join(tmpDir, "folder-17", "wallpaper.jpg")
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("Expected the searched-for file to be kept: %v", err)
+	}
+	if handler.TotalRemoved != 24 {
+		t.Errorf("Expected 24 duplicates removed, got %d", handler.TotalRemoved)
+	}
+}
+
+func TestChooseKeeper(t *testing.T) {
+	var files []FileInfo
+	for i := 1; i <= 15; i++ {
+		files = append(files, FileInfo{Path: fmt.Sprintf("/downloads/folder-%d/file.txt", i), Name: "file.txt"})
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{"number", "3\n", 2},
+		{"out of range then number", "99\nabc\n15\n", 14},
+		{"search with several matches then number", "/folder-1\n12\n", 11},
+		{"unique search", "/folder-7/\n", 6},
+		{"skip", "0\n", -1},
+		{"out of input", "", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewDuplicateHandler(NewScanner(), true)
+			handler.input = bufio.NewReader(strings.NewReader(tt.input))
+			if result := handler.chooseKeeper(files); result != tt.expected {
+				t.Errorf("chooseKeeper() with input %q = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMoveDuplicatesGroupOutputKeepsDuplicateNamedKept(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "dupes")
+	writeTree(t, tmpDir, map[string]string{
+		"downloads/a/KEPT.txt": "notes",
+		"downloads/b/KEPT.txt": "notes",
+	})
+	older := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(tmpDir, "downloads", "b", "KEPT.txt"), older, older)
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(filepath.Join(tmpDir, "downloads")); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	handler.GroupOutput = true
+	if err := handler.MoveDuplicatesToFolder(destDir); err != nil {
+		t.Fatalf("MoveDuplicatesToFolder() error = %v", err)
+	}
+
+	// The moved duplicate keeps its content; the record goes next to it
+	moved, err := os.ReadFile(filepath.Join(destDir, "group-001", "KEPT.txt"))
+	if err != nil || string(moved) != "notes" {
+		t.Errorf("Expected the moved KEPT.txt to keep its content, got %q (err %v)", moved, err)
+	}
+	record, err := os.ReadFile(filepath.Join(destDir, "group-001", "KEPT (1).txt"))
+	if err != nil || !strings.Contains(string(record), filepath.Join(tmpDir, "downloads", "a", "KEPT.txt")) {
+		t.Errorf("Expected KEPT (1).txt to name the kept copy, got %q (err %v)", record, err)
+	}
+}
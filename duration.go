@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultDurationTiers is where Short media files end and Long ones begin
+const defaultDurationTiers = "4m,20m"
+
+// unknownDurationFolder holds media files whose duration can't be read
+const unknownDurationFolder = "Unknown"
+
+// errNoDuration means a file's format isn't recognized or doesn't record a duration
+var errNoDuration = errors.New("no duration found")
+
+// readMediaDuration reads how long an audio or video file plays, overridden in tests
+var readMediaDuration = mediaDuration
+
+// Matroska and WebM element IDs, with their length marker bits kept
+const (
+	ebmlHeader        = 0x1A45DFA3
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlCluster       = 0x1F43B675
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+)
+
+// MP3 bit rates in kbit/s by index, for MPEG-1 and for MPEG-2 and 2.5 Layer III
+var mp3Bitrates = [2][15]uint64{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+}
+
+// MPEG-1 sample rates by index; MPEG-2 halves them and MPEG-2.5 quarters them
+var mp3SampleRates = [3]uint64{44100, 48000, 32000}
+
+// parseDurationTiers parses --duration-tiers, the longest Short and the
+// longest Medium media file, such as "4m,20m"
+func parseDurationTiers(value string) ([2]time.Duration, error) {
+	var tiers [2]time.Duration
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return tiers, fmt.Errorf("invalid duration tiers %q: use two lengths like 4m,20m", value)
+	}
+	for i, part := range parts {
+		tier, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || tier <= 0 {
+			return tiers, fmt.Errorf("invalid duration tiers %q: use two lengths like 4m,20m", value)
+		}
+		tiers[i] = tier
+	}
+	if tiers[0] >= tiers[1] {
+		return tiers, fmt.Errorf("invalid duration tiers %q: the Short limit must be below the Medium one", value)
+	}
+	return tiers, nil
+}
+
+// durationFolder returns the subfolder for a media file, by how long it plays
+func durationFolder(path string, tiers [2]time.Duration) string {
+	duration, err := readMediaDuration(path)
+	switch {
+	case err != nil:
+		return unknownDurationFolder
+	case duration < tiers[0]:
+		return "Short"
+	case duration < tiers[1]:
+		return "Medium"
+	default:
+		return "Long"
+	}
+}
+
+// OrganizeByDuration sorts the Music and Videos categories into Short, Medium
+// and Long subfolders by how long each file plays
+func (fo *FileOrganizer) OrganizeByDuration(tiers [2]time.Duration) error {
+	fmt.Println("⏱️  Starting media organization by duration...")
+	fmt.Println()
+
+	durationGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || (file.Category != "Music" && file.Category != "Videos") || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		folder := filepath.Join(fo.CategoryMap[file.Category], durationFolder(file.Path, tiers))
+		durationGroups[folder] = append(durationGroups[folder], file)
+	}
+
+	return fo.moveGroups(durationGroups, "⏱️ ", "duration-based")
+}
+
+// mediaDuration reads the duration recorded in the header of an MP4, QuickTime,
+// Matroska, WebM, AVI, WAV, AIFF, FLAC, Ogg or MP3 file. The format is told
+// from the content, and only headers are read, never the media itself.
+func mediaDuration(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, errNoDuration
+	}
+
+	switch {
+	case string(header[4:8]) == "ftyp" || string(header[4:8]) == "moov" || string(header[4:8]) == "wide":
+		return mp4Duration(file, info.Size())
+	case string(header[:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return wavDuration(file, info.Size())
+	case string(header[:4]) == "RIFF" && string(header[8:12]) == "AVI ":
+		return aviDuration(file)
+	case string(header[:4]) == "FORM" && (string(header[8:12]) == "AIFF" || string(header[8:12]) == "AIFC"):
+		return aiffDuration(file, info.Size())
+	case string(header[:4]) == "fLaC":
+		return flacDuration(file)
+	case string(header[:4]) == "OggS":
+		return oggDuration(file, info.Size())
+	case binary.BigEndian.Uint32(header[:4]) == ebmlHeader:
+		return matroskaDuration(file, info.Size())
+	default:
+		return mp3Duration(file, info.Size())
+	}
+}
+
+// scaledDuration converts a count of units, such as samples, at rate units
+// per second into a duration
+func scaledDuration(units, rate uint64) (time.Duration, error) {
+	if rate == 0 {
+		return 0, errNoDuration
+	}
+	return secondsDuration(float64(units) / float64(rate))
+}
+
+// secondsDuration converts seconds into a duration, rejecting nonsense values
+// from damaged headers
+func secondsDuration(seconds float64) (time.Duration, error) {
+	if !(seconds > 0) || seconds > float64(math.MaxInt64/int64(time.Second)) {
+		return 0, errNoDuration
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// mp4Duration reads the movie header (mvhd) inside the moov box of an MP4,
+// M4A or QuickTime file
+func mp4Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	moovStart, moovEnd, ok := findBox(r, 0, size, "moov")
+	if !ok {
+		return 0, errNoDuration
+	}
+	mvhdStart, _, ok := findBox(r, moovStart, moovEnd, "mvhd")
+	if !ok {
+		return 0, errNoDuration
+	}
+
+	mvhd := make([]byte, 32)
+	n, _ := r.ReadAt(mvhd, mvhdStart)
+	mvhd = mvhd[:n]
+	switch {
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		return scaledDuration(uint64(binary.BigEndian.Uint32(mvhd[16:20])), uint64(binary.BigEndian.Uint32(mvhd[12:16])))
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		return scaledDuration(binary.BigEndian.Uint64(mvhd[24:32]), uint64(binary.BigEndian.Uint32(mvhd[20:24])))
+	}
+	return 0, errNoDuration
+}
+
+// findBox finds the first MP4 box called name between start and end, and
+// returns where its content starts and ends
+func findBox(r io.ReaderAt, start, end int64, name string) (int64, int64, bool) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return 0, 0, false
+		}
+		boxSize, headerSize := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch boxSize {
+		case 0: // The box runs to the end of its parent
+			boxSize = end - offset
+		case 1: // A 64-bit size follows the name
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return 0, 0, false
+			}
+			boxSize, headerSize = int64(binary.BigEndian.Uint64(header[8:16])), 16
+		}
+		if boxSize < headerSize {
+			return 0, 0, false
+		}
+		boxEnd := offset + boxSize
+		if boxEnd > end || boxEnd < offset {
+			boxEnd = end // Truncated, or a size too large to be real
+		}
+		if string(header[4:8]) == name {
+			return offset + headerSize, boxEnd, true
+		}
+		offset = boxEnd
+	}
+	return 0, 0, false
+}
+
+// wavDuration divides the size of a WAV file's data chunk by the byte rate in its fmt chunk
+func wavDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	var byteRate, dataSize uint64
+	chunk := make([]byte, 20)
+	for offset := int64(12); offset+8 <= size; {
+		n, _ := r.ReadAt(chunk, offset)
+		if n < 8 {
+			break
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if n >= 20 {
+				byteRate = uint64(binary.LittleEndian.Uint32(chunk[16:20]))
+			}
+		case "data":
+			if offset+8+chunkSize > size {
+				chunkSize = size - offset - 8 // Still being written, or cut short
+			}
+			dataSize = uint64(chunkSize)
+		}
+		if byteRate != 0 && dataSize != 0 {
+			break
+		}
+		offset += 8 + chunkSize + chunkSize%2
+	}
+	return scaledDuration(dataSize, byteRate)
+}
+
+// aviDuration multiplies the frame count in an AVI file's main header by the time per frame
+func aviDuration(r io.ReaderAt) (time.Duration, error) {
+	header := make([]byte, 40)
+	if _, err := r.ReadAt(header, 12); err != nil {
+		return 0, errNoDuration
+	}
+	if string(header[:4]) != "LIST" || string(header[8:12]) != "hdrl" || string(header[12:16]) != "avih" {
+		return 0, errNoDuration
+	}
+	avih := header[20:]
+	microsPerFrame := uint64(binary.LittleEndian.Uint32(avih[0:4]))
+	frames := uint64(binary.LittleEndian.Uint32(avih[16:20]))
+	return scaledDuration(microsPerFrame*frames, 1000000)
+}
+
+// aiffDuration divides the sample frame count in an AIFF file's COMM chunk by its sample rate
+func aiffDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	chunk := make([]byte, 26)
+	for offset := int64(12); offset+8 <= size; {
+		n, _ := r.ReadAt(chunk, offset)
+		if n < 8 {
+			break
+		}
+		chunkSize := int64(binary.BigEndian.Uint32(chunk[4:8]))
+		if string(chunk[:4]) == "COMM" {
+			if n < 26 {
+				break
+			}
+			frames := binary.BigEndian.Uint32(chunk[10:14])
+			// The sample rate is an 80-bit extended precision float
+			exponent := int(binary.BigEndian.Uint16(chunk[16:18]) & 0x7FFF)
+			rate := math.Ldexp(float64(binary.BigEndian.Uint64(chunk[18:26])), exponent-16383-63)
+			if rate < 1 {
+				break
+			}
+			return secondsDuration(float64(frames) / rate)
+		}
+		offset += 8 + chunkSize + chunkSize%2
+	}
+	return 0, errNoDuration
+}
+
+// flacDuration divides the sample count in a FLAC file's STREAMINFO block by its sample rate
+func flacDuration(r io.ReaderAt) (time.Duration, error) {
+	header := make([]byte, 22)
+	if _, err := r.ReadAt(header, 4); err != nil {
+		return 0, errNoDuration
+	}
+	if header[0]&0x7F != 0 {
+		return 0, errNoDuration // STREAMINFO always comes first
+	}
+	info := header[4:]
+	sampleRate := uint64(info[10])<<12 | uint64(info[11])<<4 | uint64(info[12])>>4
+	samples := uint64(info[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(info[14:18]))
+	return scaledDuration(samples, sampleRate)
+}
+
+// oggDuration takes the sample position of the last page of an Ogg Vorbis or
+// Opus file, and the sample rate from its first page
+func oggDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	page := make([]byte, 27)
+	if _, err := r.ReadAt(page, 0); err != nil {
+		return 0, errNoDuration
+	}
+	identification := make([]byte, 19)
+	if _, err := r.ReadAt(identification, 27+int64(page[26])); err != nil {
+		return 0, errNoDuration
+	}
+	var rate, preSkip uint64
+	switch {
+	case bytes.HasPrefix(identification, []byte("\x01vorbis")):
+		rate = uint64(binary.LittleEndian.Uint32(identification[12:16]))
+	case bytes.HasPrefix(identification, []byte("OpusHead")):
+		rate = 48000 // Opus always counts samples at 48 kHz
+		preSkip = uint64(binary.LittleEndian.Uint16(identification[10:12]))
+	default:
+		return 0, errNoDuration
+	}
+
+	// The last page is within the final 64 KB
+	tailSize := size
+	if tailSize > 64*1024 {
+		tailSize = 64 * 1024
+	}
+	tail := make([]byte, tailSize)
+	if _, err := r.ReadAt(tail, size-tailSize); err != nil {
+		return 0, errNoDuration
+	}
+	last := bytes.LastIndex(tail, []byte("OggS"))
+	if last < 0 || last+14 > len(tail) {
+		return 0, errNoDuration
+	}
+	granule := binary.LittleEndian.Uint64(tail[last+6 : last+14])
+	if granule == math.MaxUint64 || granule <= preSkip {
+		return 0, errNoDuration
+	}
+	return scaledDuration(granule-preSkip, rate)
+}
+
+// matroskaDuration reads the Duration from the Info element of a Matroska or
+// WebM file's Segment, scaled by its TimecodeScale
+func matroskaDuration(r io.ReaderAt, size int64) (time.Duration, error) {
+	_, _, headerEnd, err := readEBMLElement(r, 0, size)
+	if err != nil {
+		return 0, err
+	}
+	id, segmentStart, segmentEnd, err := readEBMLElement(r, headerEnd, size)
+	if err != nil || id != ebmlSegment {
+		return 0, errNoDuration
+	}
+
+	// Info comes before the clusters holding the media
+	for offset := segmentStart; offset < segmentEnd; {
+		id, start, end, err := readEBMLElement(r, offset, segmentEnd)
+		if err != nil || id == ebmlCluster {
+			break
+		}
+		if id == ebmlInfo {
+			return matroskaInfoDuration(r, start, end)
+		}
+		offset = end
+	}
+	return 0, errNoDuration
+}
+
+// matroskaInfoDuration reads the Duration and TimecodeScale inside an Info element
+func matroskaInfoDuration(r io.ReaderAt, start, end int64) (time.Duration, error) {
+	scale, duration := uint64(1000000), 0.0 // The scale is in nanoseconds, 1 ms by default
+	for offset := start; offset < end; {
+		id, dataStart, dataEnd, err := readEBMLElement(r, offset, end)
+		if err != nil {
+			break
+		}
+		if length := dataEnd - dataStart; (id == ebmlTimecodeScale || id == ebmlDuration) && length > 0 && length <= 8 {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, dataStart); err != nil {
+				break
+			}
+			switch {
+			case id == ebmlTimecodeScale:
+				scale = 0
+				for _, b := range data {
+					scale = scale<<8 | uint64(b)
+				}
+			case length == 4:
+				duration = float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+			case length == 8:
+				duration = math.Float64frombits(binary.BigEndian.Uint64(data))
+			}
+		}
+		offset = dataEnd
+	}
+	return secondsDuration(duration * float64(scale) / float64(time.Second))
+}
+
+// readEBMLElement reads the header of the EBML element at offset and returns
+// its ID and where its data starts and ends. An element of unknown size runs
+// to the end of its parent.
+func readEBMLElement(r io.ReaderAt, offset, parentEnd int64) (uint64, int64, int64, error) {
+	header := make([]byte, 12)
+	n, _ := r.ReadAt(header, offset)
+	header = header[:n]
+
+	id, idLength, ok := readVint(header)
+	if !ok || idLength > 4 {
+		return 0, 0, 0, errNoDuration
+	}
+	size, sizeLength, ok := readVint(header[idLength:])
+	if !ok {
+		return 0, 0, 0, errNoDuration
+	}
+	id |= 1 << (7 * uint(idLength)) // IDs are written with their marker bit
+
+	start := offset + int64(idLength+sizeLength)
+	end := parentEnd
+	if size != 1<<(7*uint(sizeLength))-1 && size <= uint64(parentEnd-start) {
+		end = start + int64(size)
+	}
+	return id, start, end, nil
+}
+
+// readVint reads an EBML variable-length integer, without its length marker,
+// and returns it with the number of bytes it took
+func readVint(data []byte) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := bits.LeadingZeros8(data[0]) + 1
+	if length > len(data) {
+		return 0, 0, false
+	}
+	value := uint64(data[0]) & (0xFF >> length)
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	return value, length, true
+}
+
+// mp3Frame is what an MP3 frame header says about the stream
+type mp3Frame struct {
+	bitrate    uint64 // Bits per second
+	sampleRate uint64
+	samples    uint64 // Samples per frame
+	sideInfo   int    // Bytes of side information after the header
+	length     int    // Bytes in the frame, header included
+}
+
+// parseMP3Header parses a 4-byte MPEG Layer III frame header
+func parseMP3Header(header []byte) (mp3Frame, bool) {
+	if header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := header[1] >> 3 & 3 // 3 is MPEG-1, 2 is MPEG-2 and 0 is MPEG-2.5
+	layer := header[1] >> 1 & 3   // 1 is Layer III
+	bitrateIndex, rateIndex := header[2]>>4, header[2]>>2&3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	mono := header[3]>>6 == 3
+	frame := mp3Frame{sampleRate: mp3SampleRates[rateIndex]}
+	if version == 3 {
+		frame.bitrate, frame.samples, frame.sideInfo = mp3Bitrates[0][bitrateIndex]*1000, 1152, 32
+		if mono {
+			frame.sideInfo = 17
+		}
+	} else {
+		frame.bitrate, frame.samples, frame.sideInfo = mp3Bitrates[1][bitrateIndex]*1000, 576, 17
+		if mono {
+			frame.sideInfo = 9
+		}
+		frame.sampleRate /= 2
+		if version == 0 {
+			frame.sampleRate /= 2
+		}
+	}
+	frame.length = int(frame.samples/8*frame.bitrate/frame.sampleRate) + int(header[2]>>1&1)
+	return frame, true
+}
+
+// mp3Duration finds the first MP3 frame after any ID3 tag. Encoders that vary
+// the bit rate count the frames in a Xing or Info header there; otherwise the
+// bit rate is constant and the duration follows from the file size.
+func mp3Duration(r io.ReaderAt, size int64) (time.Duration, error) {
+	start := int64(0)
+	id3 := make([]byte, 10)
+	if _, err := r.ReadAt(id3, 0); err == nil && string(id3[:3]) == "ID3" {
+		start = 10 + int64(id3[6]&0x7F)<<21 | int64(id3[7]&0x7F)<<14 | int64(id3[8]&0x7F)<<7 | int64(id3[9]&0x7F)
+		if id3[5]&0x10 != 0 {
+			start += 10 // Footer
+		}
+	}
+
+	buf := make([]byte, 4096)
+	n, _ := r.ReadAt(buf, start)
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		frame, ok := parseMP3Header(buf[i : i+4])
+		if !ok {
+			continue
+		}
+		// Random bytes can look like a header, but not two in a row
+		if next := i + frame.length; next+4 <= len(buf) {
+			if _, ok := parseMP3Header(buf[next : next+4]); !ok {
+				continue
+			}
+		}
+
+		if xing := i + 4 + frame.sideInfo; xing+12 <= len(buf) {
+			tag := string(buf[xing : xing+4])
+			if (tag == "Xing" || tag == "Info") && buf[xing+7]&1 != 0 {
+				frames := uint64(binary.BigEndian.Uint32(buf[xing+8 : xing+12]))
+				return scaledDuration(frames*frame.samples, frame.sampleRate)
+			}
+		}
+		return scaledDuration(uint64(size-start-int64(i))*8, frame.bitrate)
+	}
+	return 0, errNoDuration
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testWAV returns a WAV file of silence at 8000 bytes per second
+func testWAV(seconds int) []byte {
+	var buf bytes.Buffer
+	data := make([]byte, 8000*seconds)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, []uint32{16, 1<<16 | 1, 8000, 8000, 8<<16 | 1})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// testMP4 returns the boxes of an MP4 file whose movie header gives the duration
+func testMP4(duration time.Duration) []byte {
+	var mvhd bytes.Buffer
+	binary.Write(&mvhd, binary.BigEndian, []uint32{0, 0, 0, 1000, uint32(duration.Milliseconds())})
+	mvhd.Write(make([]byte, 80))
+	box := func(name string, content []byte) []byte {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.BigEndian, uint32(8+len(content)))
+		buf.WriteString(name)
+		buf.Write(content)
+		return buf.Bytes()
+	}
+	file := box("ftyp", []byte("isom\x00\x00\x02\x00isom"))
+	file = append(file, box("free", nil)...)
+	return append(file, box("moov", box("mvhd", mvhd.Bytes()))...)
+}
+
+// testFLAC returns the STREAMINFO of a 44.1 kHz FLAC file
+func testFLAC(seconds int) []byte {
+	info := make([]byte, 34)
+	info[10], info[11], info[12], info[13] = 0x0A, 0xC4, 0x42, 0xF0 // 44100 Hz, stereo, 16 bits
+	binary.BigEndian.PutUint32(info[14:18], uint32(44100*seconds))
+	return append([]byte("fLaC\x00\x00\x00\x22"), info...)
+}
+
+// testMatroska returns a WebM header whose Info element gives the duration
+func testMatroska(duration time.Duration) []byte {
+	var info bytes.Buffer
+	info.Write([]byte{0x2A, 0xD7, 0xB1, 0x83, 0x0F, 0x42, 0x40}) // TimecodeScale of 1 ms
+	info.Write([]byte{0x44, 0x89, 0x88})
+	binary.Write(&info, binary.BigEndian, math.Float64bits(float64(duration.Milliseconds())))
+	var segment bytes.Buffer
+	segment.Write([]byte{0x15, 0x49, 0xA9, 0x66, 0x80 | byte(info.Len())})
+	segment.Write(info.Bytes())
+
+	file := []byte{0x1A, 0x45, 0xDF, 0xA3, 0x84, 0x42, 0x82, 0x81, 0x77}                        // EBML header
+	file = append(file, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF) // Segment of unknown size
+	return append(file, segment.Bytes()...)
+}
+
+// testMP3 returns constant bit rate MP3 frames at 128 kbit/s
+func testMP3(seconds int) []byte {
+	frame := make([]byte, 417) // 144 * 128000 / 44100 bytes
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	var buf bytes.Buffer
+	buf.WriteString("ID3\x04\x00\x00\x00\x00\x00\x0A")
+	buf.Write(make([]byte, 10))
+	for buf.Len() < 16000*seconds {
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+func TestMediaDuration(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected time.Duration
+	}{
+		{"clip.wav", testWAV(3), 3 * time.Second},
+		{"movie.mp4", testMP4(95 * time.Minute), 95 * time.Minute},
+		{"track.flac", testFLAC(241), 241 * time.Second},
+		{"talk.webm", testMatroska(42*time.Minute + 500*time.Millisecond), 42*time.Minute + 500*time.Millisecond},
+		{"song.mp3", testMP3(10), 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name)
+			if err := os.WriteFile(path, tt.content, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			duration, err := mediaDuration(path)
+			if err != nil {
+				t.Fatalf("mediaDuration() error = %v", err)
+			}
+			// MP3 durations from the file size are only close
+			if diff := duration - tt.expected; diff < -100*time.Millisecond || diff > 100*time.Millisecond {
+				t.Errorf("mediaDuration(%s) = %v, want %v", tt.name, duration, tt.expected)
+			}
+		})
+	}
+
+	// Files without a recognizable header have no duration
+	broken := filepath.Join(tmpDir, "broken.mp4")
+	if err := os.WriteFile(broken, []byte("not a video at all"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := mediaDuration(broken); err == nil {
+		t.Error("Expected an error for a file that isn't media")
+	}
+}
+
+func TestOrganizeByDuration(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"jingle.mp3", "episode.mp3", "concert.mkv", "corrupt.mp4", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"jingle.mp3":  30 * time.Second,
+		"episode.mp3": 10 * time.Minute,
+		"concert.mkv": 2 * time.Hour,
+	}
+	original := readMediaDuration
+	readMediaDuration = func(path string) (time.Duration, error) {
+		if duration, ok := durations[filepath.Base(path)]; ok {
+			return duration, nil
+		}
+		return 0, errors.New("no duration")
+	}
+	defer func() { readMediaDuration = original }()
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	tiers, err := parseDurationTiers(defaultDurationTiers)
+	if err != nil {
+		t.Fatalf("parseDurationTiers() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByDuration(tiers); err != nil {
+		t.Fatalf("OrganizeByDuration() error = %v", err)
+	}
+
+	expected := []string{
+		filepath.Join("Music", "Short", "jingle.mp3"),
+		filepath.Join("Music", "Medium", "episode.mp3"),
+		filepath.Join("Videos", "Long", "concert.mkv"),
+		filepath.Join("Videos", "Unknown", "corrupt.mp4"),
+		"notes.txt", // Not music or video, so left alone
+	}
+	for _, path := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, path)); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+}
+
+func TestParseDurationTiers(t *testing.T) {
+	tiers, err := parseDurationTiers("30s, 5m")
+	if err != nil {
+		t.Fatalf("parseDurationTiers() error = %v", err)
+	}
+	if tiers != [2]time.Duration{30 * time.Second, 5 * time.Minute} {
+		t.Errorf("Expected 30s and 5m, got %v", tiers)
+	}
+
+	for _, value := range []string{"5m", "5m,30s", "5m,5m", "short,long", "0s,5m", "1m,2m,3m"} {
+		if _, err := parseDurationTiers(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
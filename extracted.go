@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExtractedCopy is a loose file whose content is also stored in a zip that
+// was part of the same scan, such as a file left behind after unpacking it
+type ExtractedCopy struct {
+	File    FileInfo
+	Archive FileInfo // The zip holding the same content
+	Entry   string   // Name of the matching entry in the zip
+}
+
+// archiveEntry is an entry of a zip in the scan
+type archiveEntry struct {
+	archive FileInfo
+	name    string
+}
+
+// FindExtractedCopies hashes the entries of every zip in the scan and returns
+// the loose files whose content matches one of them. Only entries the same
+// size as some loose file are read, but that can still mean unpacking most of
+// every zip, so this is opt-in. Zips too big to safely unpack are left out.
+func (s *Scanner) FindExtractedCopies() []ExtractedCopy {
+	var archives, loose []FileInfo
+	sizes := make(map[int64]bool)
+	for _, file := range s.Files {
+		if strings.ToLower(file.Extension) == ".zip" {
+			archives = append(archives, file)
+		} else if file.Size > 0 {
+			loose = append(loose, file)
+			sizes[file.Size] = true
+		}
+	}
+	if len(archives) == 0 || len(loose) == 0 {
+		return nil
+	}
+
+	entries := make(map[string]archiveEntry)
+	entrySizes := make(map[int64]bool)
+	for _, archive := range archives {
+		if err := hashArchiveEntries(archive, sizes, entries, entrySizes); err != nil {
+			fmt.Printf("⚠️  Could not read %s: %v\n", archive.Path, err)
+		}
+	}
+
+	var copies []ExtractedCopy
+	for _, file := range loose {
+		if !entrySizes[file.Size] {
+			continue
+		}
+		hash := file.Hash
+		if hash == "" || strings.HasPrefix(hash, archiveHashPrefix) {
+			var err error
+			if hash, err = s.calculateFileHash(file.Path); err != nil {
+				fmt.Printf("⚠️  Could not calculate hash for %s: %v\n", file.Path, err)
+				continue
+			}
+			file.Hash = hash
+		}
+		if entry, ok := entries[hash]; ok {
+			copies = append(copies, ExtractedCopy{File: file, Archive: entry.archive, Entry: entry.name})
+		}
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].File.Path < copies[j].File.Path })
+	return copies
+}
+
+// hashArchiveEntries adds the MD5 of each entry of a zip whose size is in
+// sizes to entries, keeping the first zip found for each hash, and records the
+// sizes it hashed in entrySizes. It applies the same limits as archiveFingerprint.
+func hashArchiveEntries(archive FileInfo, sizes map[int64]bool, entries map[string]archiveEntry, entrySizes map[int64]bool) error {
+	r, err := zip.OpenReader(archive.Path)
+	if err != nil {
+		return fmt.Errorf("cannot open zip file: %v", err)
+	}
+	defer r.Close()
+
+	if len(r.File) > defaultMaxZipEntries {
+		return fmt.Errorf("zip file has too many entries (%d)", len(r.File))
+	}
+	totalSize := uint64(0)
+	for _, f := range r.File {
+		totalSize += f.UncompressedSize64
+	}
+	if totalSize > defaultMaxZipSize*10 {
+		return fmt.Errorf("zip file would expand to too large size (%d bytes)", totalSize)
+	}
+
+	for _, f := range r.File {
+		size := int64(f.UncompressedSize64)
+		if f.FileInfo().IsDir() || !sizes[size] {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %v", f.Name, err)
+		}
+		hash := md5.New()
+		_, err = io.Copy(hash, io.LimitReader(rc, size+1))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %v", f.Name, err)
+		}
+
+		sum := hex.EncodeToString(hash.Sum(nil))
+		if _, ok := entries[sum]; !ok {
+			entries[sum] = archiveEntry{archive: archive, name: f.Name}
+		}
+		entrySizes[size] = true
+	}
+	return nil
+}
+
+// RemoveExtractedCopies removes loose files whose content is also in a zip
+// found by FindExtractedCopies, keeping the zip. A file is left alone if its
+// zip changed or vanished since the scan.
+func (dh *DuplicateHandler) RemoveExtractedCopies(copies []ExtractedCopy) error {
+	if len(copies) == 0 {
+		fmt.Println("✅ No loose files found that are also stored in a zip!")
+		return nil
+	}
+
+	fmt.Println("🔄 Removing loose files that are also stored in a zip...")
+
+	totalRemoved := 0
+	totalSpaceSaved := int64(0)
+
+	for _, extracted := range copies {
+		file := extracted.File
+		kept := fmt.Sprintf("%s (%s)", extracted.Archive.Path, extracted.Entry)
+
+		if dh.DryRun {
+			warningColor.Printf("   🗑️  Would remove: %s (%.2f MB), also in %s\n", file.Name, float64(file.Size)/1024/1024, kept)
+			dh.Plan.Add("remove", file.Path, "")
+		} else {
+			// Duplicate removal may already have taken care of it
+			if _, err := os.Lstat(file.Path); os.IsNotExist(err) {
+				continue
+			}
+			if _, err := os.Stat(extracted.Archive.Path); err != nil || changedSinceScan(extracted.Archive) {
+				warningColor.Printf("   ⚠️  %s changed since scan, leaving %s alone\n", extracted.Archive.Name, file.Name)
+				continue
+			}
+			if !dh.Deadline.Allow(file.Path) || !dh.Approver.Approve(fmt.Sprintf("Remove %s", file.Path)) || changedSinceScan(file) {
+				continue
+			}
+			fmt.Printf("   🗑️  Removing: %s (%.2f MB), also in %s\n", file.Name, float64(file.Size)/1024/1024, kept)
+			if err := dh.removeFile(file); err != nil {
+				warningColor.Printf("   ⚠️  Failed to remove %s: %v\n", file.Name, err)
+				continue
+			}
+		}
+
+		totalRemoved++
+		totalSpaceSaved += reclaimedSize(file)
+		dh.Removed = append(dh.Removed, DeletionRecord{
+			OriginalPath: file.Path,
+			Size:         file.Size,
+			Hash:         file.Hash,
+			Survivor:     kept,
+			RemovedAt:    time.Now(),
+		})
+		dh.Scanner.markDuplicate(file.Path, file.Hash)
+	}
+	fmt.Println()
+
+	dh.TotalRemoved += totalRemoved
+	dh.TotalSpaceSaved += totalSpaceSaved
+
+	if totalRemoved > 0 {
+		successColor.Printf("✅ Removed %d loose files already stored in a zip!\n", totalRemoved)
+		successColor.Printf("💾 Space saved: %.2f MB\n", float64(totalSpaceSaved)/1024/1024)
+	} else {
+		fmt.Println("✅ No files were removed.")
+	}
+
+	return nil
+}
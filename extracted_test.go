@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveExtractedCopies(t *testing.T) {
+	tmpDir := t.TempDir()
+	archive := filepath.Join(tmpDir, "photos.zip")
+	if err := createTestZip(archive, map[string]string{
+		"photos/beach.jpg": "beach photo data",
+		"photos/hills.jpg": "hills photo data",
+	}); err != nil {
+		t.Fatalf("Failed to create test zip: %v", err)
+	}
+
+	// beach.jpg was unpacked from the zip; sunset.jpg has the same size but isn't in it
+	files := map[string]string{
+		"beach.jpg":  "beach photo data",
+		"sunset.jpg": "sunst photo data",
+		"notes.txt":  "unrelated",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	copies := scanner.FindExtractedCopies()
+	if len(copies) != 1 || copies[0].File.Name != "beach.jpg" {
+		t.Fatalf("Expected only beach.jpg to be flagged, got %v", copies)
+	}
+	if copies[0].Archive.Path != archive || copies[0].Entry != "photos/beach.jpg" {
+		t.Errorf("Expected beach.jpg to match photos/beach.jpg in %s, got %s in %s", archive, copies[0].Entry, copies[0].Archive.Path)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveExtractedCopies(copies); err != nil {
+		t.Fatalf("RemoveExtractedCopies() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "beach.jpg")); !os.IsNotExist(err) {
+		t.Error("Expected beach.jpg to be removed")
+	}
+	for _, name := range []string{"photos.zip", "sunset.jpg", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s to be kept: %v", name, err)
+		}
+	}
+	if len(handler.Removed) != 1 || handler.Removed[0].Survivor != archive+" (photos/beach.jpg)" {
+		t.Errorf("Expected the removal to be recorded against the zip entry, got %v", handler.Removed)
+	}
+}
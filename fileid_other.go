@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// fileID identifies the data behind a file, which this platform's stat info
+// doesn't include, so hard links are treated like copies
+func fileID(info os.FileInfo) (string, bool) {
+	return "", false
+}
+
+// openNoFollow is 0 as this platform has no flag for it; shredFile checks
+// for symlinks before opening instead
+const openNoFollow = 0
+
+// linkCount can't tell hard links apart on this platform, so it reports one
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
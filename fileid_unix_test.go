@@ -0,0 +1,57 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHardLinksAreNotDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	original := filepath.Join(tmpDir, "report.pdf")
+	link := filepath.Join(tmpDir, "report (1).pdf")
+	if err := os.WriteFile(original, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Link(original, link); err != nil {
+		t.Skipf("Hard links not supported here: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected hard links not to be reported as duplicates, got %d groups", len(scanner.Duplicates))
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicatesByPattern(); err != nil {
+		t.Fatalf("RemoveDuplicatesByPattern() error = %v", err)
+	}
+	for _, path := range []string{original, link} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", filepath.Base(path), err)
+		}
+	}
+
+	// A real copy alongside the links is still a duplicate, counted once for the links
+	extra := filepath.Join(tmpDir, "report copy.pdf")
+	if err := os.WriteFile(extra, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	scanner = NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	for _, files := range scanner.Duplicates {
+		if len(files) != 2 {
+			t.Errorf("Expected the linked pair to count as one file, got %d files", len(files))
+		}
+	}
+	if len(scanner.Duplicates) != 1 {
+		t.Errorf("Expected 1 duplicate group, got %d", len(scanner.Duplicates))
+	}
+}
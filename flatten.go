@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// isManagedFolder reports whether a top-level folder is one that organizing creates:
+// a category folder, a year-month date folder or a size folder
+func (fo *FileOrganizer) isManagedFolder(name string) bool {
+	for _, folder := range fo.CategoryMap {
+		if name == folder {
+			return true
+		}
+	}
+	for _, sizeCat := range fo.SizeBuckets {
+		if name == sizeCat.Name {
+			return true
+		}
+	}
+	if _, err := time.Parse("2006-01", name); err == nil {
+		return true
+	}
+	return false
+}
+
+// Flatten moves files out of managed folders back into the base path, undoing
+// an organize run. Name collisions are resolved with a "(n)" suffix, and
+// emptied folders are removed when prune is set.
+func (fo *FileOrganizer) Flatten(prune bool) error {
+	fmt.Println("🫓 Starting to flatten organized folders...")
+	fmt.Println()
+
+	managed, err := fo.ManagedFolders()
+	if err != nil {
+		return err
+	}
+	return fo.flattenFolders(managed, prune)
+}
+
+// ManagedFolders returns the paths of the managed folders in the base path,
+// the ones earlier organize runs moved files into
+func (fo *FileOrganizer) ManagedFolders() ([]string, error) {
+	entries, err := os.ReadDir(fo.BasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", fo.BasePath, err)
+	}
+
+	var managed []string
+	for _, entry := range entries {
+		if entry.IsDir() && fo.isManagedFolder(entry.Name()) {
+			managed = append(managed, filepath.Join(fo.BasePath, entry.Name()))
+		}
+	}
+	return managed, nil
+}
+
+// UnsortCategory moves the files in one category's folder back into the base
+// path, leaving the other organized folders as they are. The emptied folder
+// is removed when prune is set.
+func (fo *FileOrganizer) UnsortCategory(category string, prune bool) error {
+	categories, err := fo.parseCategories([]string{category})
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return fmt.Errorf("no category given")
+	}
+	for name := range categories {
+		category = name
+	}
+
+	folderName := fo.CategoryMap[category]
+	fmt.Printf("🫓 Starting to flatten the %s folder...\n", folderName)
+	fmt.Println()
+
+	folderPath := fo.folderPath(folderName)
+	if info, err := os.Stat(folderPath); err != nil || !info.IsDir() {
+		fmt.Printf("ℹ️  No %s folder found to flatten\n", folderName)
+		return nil
+	}
+	return fo.flattenFolders([]string{folderPath}, prune)
+}
+
+// flattenFolders moves every file inside the given folders into the base path
+func (fo *FileOrganizer) flattenFolders(folders []string, prune bool) error {
+	totalMoved := 0
+	totalSkipped := 0
+	claimed := make(map[string]bool) // Names handed out so far, which a dry run doesn't create
+
+	for _, folderPath := range folders {
+		folderName, _ := filepath.Rel(fo.BasePath, folderPath)
+
+		// Collect everything inside, including subfolders like Images/Landscape,
+		// leaving out what a scan never touches
+		var files []string
+		err := filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				warningColor.Printf("⚠️  Cannot access %s: %v\n", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				if path != folderPath && (skippedDir(d.Name()) || isInProgressDownload(d.Name())) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// Leave unfinished downloads where they are so the download can complete
+			if isInProgressDownload(d.Name()) {
+				fmt.Printf("   ⏳ %s is still downloading, leaving it in place\n", d.Name())
+				return nil
+			}
+			if !skippedFile(d.Name()) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			warningColor.Printf("⚠️  Failed to read folder %s: %v\n", folderName, err)
+			continue
+		}
+
+		infoColor.Printf("📂 Processing %s (%d files)...\n", folderName, len(files))
+
+		for _, src := range files {
+			name := filepath.Base(src)
+			if fo.Scanner.protectedFile(src) {
+				fmt.Printf("   🛡️  %s is protected, leaving it in place\n", name)
+				continue
+			}
+			destPath := filepath.Join(fo.BasePath, name)
+			if _, err := os.Lstat(destPath); err == nil || claimed[destPath] {
+				destPath = nextUnclaimedName(fo.BasePath, name, claimed)
+				fmt.Printf("   ✏️  %s already exists, using: %s\n", name, filepath.Base(destPath))
+			}
+			claimed[destPath] = true
+
+			if fo.DryRun {
+				fmt.Printf("   📁 Would move: %s -> %s\n", name, filepath.Base(destPath))
+				fo.Plan.Add("move", src, destPath)
+			} else {
+				if !fo.Deadline.Allow(src) {
+					delete(claimed, destPath)
+					continue
+				}
+				fmt.Printf("   📁 Moving: %s\n", name)
+				err := fo.moveTracked(src, destPath)
+				fo.Plan.Record("move", src, destPath, err)
+				if err != nil {
+					if isFatalMoveError(err) {
+						return fo.rollback(err, totalMoved)
+					}
+					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", name, err)
+					totalSkipped++
+					continue
+				}
+			}
+			totalMoved++
+		}
+		fmt.Println()
+	}
+
+	fo.TotalMoved += totalMoved
+
+	if prune {
+		for _, folderPath := range folders {
+			fo.pruneEmptyFolders(folderPath)
+		}
+	}
+
+	if totalMoved > 0 {
+		successColor.Printf("✅ Moved %d files back to %s!\n", totalMoved, fo.BasePath)
+	} else {
+		fmt.Println("ℹ️  No organized folders found to flatten")
+	}
+	if totalSkipped > 0 {
+		fmt.Printf("ℹ️  Skipped %d files (errors)\n", totalSkipped)
+	}
+
+	return nil
+}
+
+// pruneEmptyFolders removes a folder and its subfolders once they hold no files
+func (fo *FileOrganizer) pruneEmptyFolders(folderPath string) {
+	if fo.DryRun {
+		fmt.Printf("🗑️  Would remove folder: %s\n", filepath.Base(folderPath))
+		return
+	}
+
+	var dirs []string
+	filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	// Remove the deepest folders first; os.Remove refuses folders that still hold anything
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err == nil && dirs[i] == folderPath {
+			fmt.Printf("🗑️  Removed empty folder: %s\n", filepath.Base(folderPath))
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlattenAfterOrganize(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"photo.jpg":  "fake image data",
+		"report.pdf": "fake pdf data",
+		"song.mp3":   "fake music data",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if err := NewFileOrganizer(scanner, false, tmpDir).OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Images", "photo.jpg")); err != nil {
+		t.Fatalf("Expected photo.jpg to be organized first: %v", err)
+	}
+
+	// A folder the tool didn't create must be left alone
+	projectDir := filepath.Join(tmpDir, "My Project")
+	if err := os.Mkdir(projectDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "notes.txt"), []byte("keep me"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// A new download with the same name as an organized file
+	if err := os.WriteFile(filepath.Join(tmpDir, "report.pdf"), []byte("newer pdf data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	flattener := NewFileOrganizer(NewScanner(), false, tmpDir)
+	if err := flattener.Flatten(true); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	for _, name := range []string{"photo.jpg", "song.mp3", "report.pdf", "report (1).pdf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s back in the root: %v", name, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "report.pdf")); string(data) != "newer pdf data" {
+		t.Errorf("Expected the existing report.pdf to be kept, got %q", data)
+	}
+
+	for _, folder := range []string{"Images", "Documents", "Music"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder)); !os.IsNotExist(err) {
+			t.Errorf("Expected empty folder %s to be pruned", folder)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "notes.txt")); err != nil {
+		t.Errorf("Expected unmanaged folder to be untouched: %v", err)
+	}
+	if flattener.TotalMoved != 3 {
+		t.Errorf("Expected 3 files moved, got %d", flattener.TotalMoved)
+	}
+}
+
+func TestIsManagedFolder(t *testing.T) {
+	organizer := NewFileOrganizer(NewScanner(), true, t.TempDir())
+
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"Images", true},
+		{"Disk Images", true},
+		{"2024-05", true},
+		{"Huge", true},
+		{"2024", false},
+		{"My Project", false},
+		{"images", false},
+	}
+
+	for _, tt := range tests {
+		if result := organizer.isManagedFolder(tt.name); result != tt.expected {
+			t.Errorf("isManagedFolder(%q) = %v, want %v", tt.name, result, tt.expected)
+		}
+	}
+}
+
+func TestUnsortCategory(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join("Images", "photo.jpg"),
+		filepath.Join("Images", "Landscape", "beach.png"),
+		filepath.Join("Documents", "report.pdf"),
+		filepath.Join("Music", "song.mp3"),
+	} {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(filepath.Base(path)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	// A loose file with the same name as one being pulled back
+	if err := os.WriteFile(filepath.Join(tmpDir, "photo.jpg"), []byte("newer photo"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	organizer := NewFileOrganizer(NewScanner(), false, tmpDir)
+	if err := organizer.UnsortCategory("images", true); err != nil {
+		t.Fatalf("UnsortCategory() error = %v", err)
+	}
+
+	for _, name := range []string{"photo.jpg", "photo (1).jpg", "beach.png"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s back in the root: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Images")); !os.IsNotExist(err) {
+		t.Error("Expected the emptied Images folder to be pruned")
+	}
+
+	// The other categories stay organized
+	for _, path := range []string{filepath.Join("Documents", "report.pdf"), filepath.Join("Music", "song.mp3")} {
+		if _, err := os.Stat(filepath.Join(tmpDir, path)); err != nil {
+			t.Errorf("Expected %s to stay organized: %v", path, err)
+		}
+	}
+
+	if err := organizer.UnsortCategory("Holograms", false); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+func TestFlattenSkipsHiddenAndBundles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join("Documents", "report.pdf"),
+		filepath.Join("Documents", ".DS_Store"),
+		filepath.Join("Documents", ".cache", "index.db"),
+		filepath.Join("Applications", "Tool.app", "Contents", "Info.plist"),
+		filepath.Join("Images", "report.pdf"),
+	} {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Two files with the same name get different targets even in a dry run
+	output := captureStdout(t, func() {
+		if err := NewFileOrganizer(NewScanner(), true, tmpDir).Flatten(false); err != nil {
+			t.Fatalf("Flatten() error = %v", err)
+		}
+	})
+	for _, want := range []string{"report.pdf -> report.pdf", "report.pdf -> report (1).pdf"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected the dry run to show %q, got:\n%s", want, output)
+		}
+	}
+
+	if err := NewFileOrganizer(NewScanner(), false, tmpDir).Flatten(false); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+	for _, name := range []string{".DS_Store", "index.db", "Info.plist"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be left where it was", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Applications", "Tool.app", "Contents", "Info.plist")); err != nil {
+		t.Errorf("Expected the app bundle to stay whole: %v", err)
+	}
+}
+
+func TestFlattenSkipsInProgressDownloads(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join("Videos", "movie.mp4"),
+		filepath.Join("Videos", "episode.mp4.crdownload"),
+		filepath.Join("Videos", "trailer.mkv.part"),
+	} {
+		path = filepath.Join(tmpDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	organizer := NewFileOrganizer(NewScanner(), false, tmpDir)
+	if err := organizer.Flatten(false); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "movie.mp4")); err != nil {
+		t.Errorf("Expected movie.mp4 back in the root: %v", err)
+	}
+	for _, name := range []string{"episode.mp4.crdownload", "trailer.mkv.part"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Videos", name)); err != nil {
+			t.Errorf("Expected %s to be left while it downloads: %v", name, err)
+		}
+	}
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected 1 file moved, got %d", organizer.TotalMoved)
+	}
+}
+
+func TestFlattenDryRunPlan(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{"Images/photo.jpg": "fake image data"})
+
+	flattener := NewFileOrganizer(NewScanner(), true, tmpDir)
+	flattener.Plan = &Plan{}
+	if err := flattener.Flatten(false); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	want := PlannedOperation{Action: "move", Source: filepath.Join(tmpDir, "Images", "photo.jpg"), Destination: filepath.Join(tmpDir, "photo.jpg")}
+	if len(flattener.Plan.Operations) != 1 || flattener.Plan.Operations[0] != want {
+		t.Errorf("Expected the plan to hold %v, got %v", want, flattener.Plan.Operations)
+	}
+}
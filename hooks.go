@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// MoveHook is a command run for each file the organizer moves. It is run
+// directly rather than through a shell, so file names are never interpreted
+// as shell syntax. In its arguments {} stands for the file, {src} for where it
+// is moved from and {dst} for where it is moved to. Without any of them, the
+// file's path is passed as the last argument.
+type MoveHook struct {
+	Args []string
+}
+
+// ParseMoveHook splits a command line into a hook. Arguments are separated by
+// spaces, and can be grouped with single or double quotes.
+func ParseMoveHook(command string) (*MoveHook, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return &MoveHook{Args: args}, nil
+}
+
+// splitCommand splits a command line into arguments, honouring quotes and
+// backslash escapes outside single quotes. A backslash only escapes a quote,
+// another backslash or, outside quotes, a space, so Windows paths like
+// C:\Tools\scan.exe keep their backslashes.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	runes := []rune(command)
+	for i, r := range runes {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'' && i+1 < len(runes) && escapable(runes[i+1], quote):
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// escapable reports whether a backslash before r escapes it, inside the given
+// quote or outside quotes when quote is 0
+func escapable(r, quote rune) bool {
+	switch r {
+	case '"', '\\':
+		return true
+	case '\'':
+		return quote == 0
+	}
+	return quote == 0 && unicode.IsSpace(r)
+}
+
+// Run runs the hook for a file at path being moved from src to dst, returning
+// an error if the command couldn't start or exited with a non-zero status
+func (h *MoveHook) Run(path, src, dst string) error {
+	args := make([]string, 0, len(h.Args)+1)
+	substituted := false
+	for _, arg := range h.Args {
+		replaced := strings.NewReplacer("{}", path, "{src}", src, "{dst}", dst).Replace(arg)
+		if replaced != arg {
+			substituted = true
+		}
+		args = append(args, replaced)
+	}
+	if !substituted {
+		args = append(args, path)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
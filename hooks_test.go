@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{command: "clamscan --no-summary {}", want: []string{"clamscan", "--no-summary", "{}"}},
+		{command: `log-move "my log.txt" '{src}' {dst}`, want: []string{"log-move", "my log.txt", "{src}", "{dst}"}},
+		{command: `echo it\'s "a \"quote\""`, want: []string{"echo", "it's", `a "quote"`}},
+		{command: `echo ''`, want: []string{"echo", ""}},
+		{command: `"C:\Tools\scan.exe" {}`, want: []string{`C:\Tools\scan.exe`, "{}"}},
+		{command: `C:\Tools\scan.exe "D:\Logs\\" my\ file`, want: []string{`C:\Tools\scan.exe`, `D:\Logs\`, "my file"}},
+		{command: `echo "unterminated`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitCommand(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestMoveHooks(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("No sh to run the stub hook with")
+	}
+
+	tmpDir := t.TempDir()
+	downloads := filepath.Join(tmpDir, "Downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	// The names would run commands if they ever reached a shell
+	names := []string{"report.pdf", "virus; touch pwned.pdf", "$(touch pwned).txt"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	// The stub vetoes anything with "virus" in its name and logs what was moved
+	veto := filepath.Join(tmpDir, "veto.sh")
+	if err := os.WriteFile(veto, []byte("case \"$1\" in *virus*) exit 1;; esac\n"), 0644); err != nil {
+		t.Fatalf("Failed to create stub hook: %v", err)
+	}
+	logFile := filepath.Join(tmpDir, "moved.log")
+	logger := filepath.Join(tmpDir, "log.sh")
+	if err := os.WriteFile(logger, []byte("echo \"$1\" >> \"$2\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create stub hook: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, downloads)
+	if organizer.PreMoveHook, err = ParseMoveHook(sh + " " + veto); err != nil {
+		t.Fatalf("ParseMoveHook() error = %v", err)
+	}
+	if organizer.PostMoveHook, err = ParseMoveHook(sh + " " + logger + " {dst} " + logFile); err != nil {
+		t.Fatalf("ParseMoveHook() error = %v", err)
+	}
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(downloads, "virus; touch pwned.pdf")); err != nil {
+		t.Errorf("Expected the vetoed file to stay in place: %v", err)
+	}
+	for _, path := range []string{"Documents/report.pdf", "Documents/$(touch pwned).txt"} {
+		if _, err := os.Stat(filepath.Join(downloads, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+	for _, dir := range []string{".", tmpDir, downloads} {
+		if matches, _ := filepath.Glob(filepath.Join(dir, "pwned*")); len(matches) > 0 {
+			t.Errorf("A file name was run as a command: %v", matches)
+		}
+	}
+
+	logged, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Expected the post-move hook to run: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(logged)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected 2 logged moves, got %q", lines)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+)
+
+// Ways to split up the Images category
+const (
+	ImagesByOrientation = "orientation"
+	ImagesByResolution  = "resolution"
+)
+
+// unknownImageFolder holds images whose dimensions can't be read
+const unknownImageFolder = "Unknown"
+
+// imageDimensions reads an image's width and height from its header without decoding the pixels
+func imageDimensions(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// orientationFolder returns the folder for an image of the given dimensions
+func orientationFolder(width, height int) string {
+	switch {
+	case width > height:
+		return "Landscape"
+	case height > width:
+		return "Portrait"
+	default:
+		return "Square"
+	}
+}
+
+// resolutionFolder returns the resolution tier for an image, based on its longest edge
+func resolutionFolder(width, height int) string {
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	switch {
+	case longest < 1024:
+		return "Small"
+	case longest < 2048:
+		return "Medium"
+	case longest < 4096:
+		return "Large"
+	default:
+		return "Very Large"
+	}
+}
+
+// imageFolder returns the subfolder an image belongs in for the given mode
+func imageFolder(path, mode string) string {
+	width, height, err := imageDimensions(path)
+	if err != nil {
+		return unknownImageFolder
+	}
+	if mode == ImagesByResolution {
+		return resolutionFolder(width, height)
+	}
+	return orientationFolder(width, height)
+}
+
+// OrganizeImagesBy sorts the Images category into subfolders by orientation or resolution
+func (fo *FileOrganizer) OrganizeImagesBy(mode string) error {
+	if mode != ImagesByOrientation && mode != ImagesByResolution {
+		return fmt.Errorf("unknown image organization mode %q (use %s or %s)", mode, ImagesByOrientation, ImagesByResolution)
+	}
+
+	fmt.Printf("🖼️  Starting image organization by %s...\n", mode)
+	fmt.Println()
+
+	imagesFolder := fo.CategoryMap["Images"]
+	imageGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || file.Category != "Images" || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		folder := filepath.Join(imagesFolder, imageFolder(file.Path, mode))
+		imageGroups[folder] = append(imageGroups[folder], file)
+	}
+
+	return fo.moveGroups(imageGroups, "🖼️ ", mode+"-based")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestPNG writes a blank PNG of the given size
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode %s: %v", path, err)
+	}
+}
+
+func TestImageFolder(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		orientation string
+		resolution  string
+	}{
+		{"wide.png", 40, 20, "Landscape", "Small"},
+		{"tall.png", 20, 40, "Portrait", "Small"},
+		{"square.png", 30, 30, "Square", "Small"},
+		{"banner.png", 1500, 10, "Landscape", "Medium"},
+		{"poster.png", 10, 2048, "Portrait", "Large"},
+		{"panorama.png", 5000, 1, "Landscape", "Very Large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.name)
+			writeTestPNG(t, path, tt.width, tt.height)
+
+			if result := imageFolder(path, ImagesByOrientation); result != tt.orientation {
+				t.Errorf("imageFolder(%s, orientation) = %s, want %s", tt.name, result, tt.orientation)
+			}
+			if result := imageFolder(path, ImagesByResolution); result != tt.resolution {
+				t.Errorf("imageFolder(%s, resolution) = %s, want %s", tt.name, result, tt.resolution)
+			}
+		})
+	}
+
+	// Files that aren't really images go to Unknown
+	broken := filepath.Join(tmpDir, "broken.jpg")
+	if err := os.WriteFile(broken, []byte("not an image"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if result := imageFolder(broken, ImagesByOrientation); result != unknownImageFolder {
+		t.Errorf("imageFolder(broken.jpg) = %s, want %s", result, unknownImageFolder)
+	}
+}
+
+func TestOrganizeImagesBy(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestPNG(t, filepath.Join(tmpDir, "wide.png"), 40, 20)
+	writeTestPNG(t, filepath.Join(tmpDir, "tall.png"), 20, 40)
+	if err := os.WriteFile(filepath.Join(tmpDir, "broken.jpg"), []byte("not an image"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("not an image either"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeImagesBy(ImagesByOrientation); err != nil {
+		t.Fatalf("OrganizeImagesBy() error = %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join(tmpDir, "Images", "Landscape", "wide.png"),
+		filepath.Join(tmpDir, "Images", "Portrait", "tall.png"),
+		filepath.Join(tmpDir, "Images", unknownImageFolder, "broken.jpg"),
+		filepath.Join(tmpDir, "notes.txt"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to exist: %v", path, err)
+		}
+	}
+
+	if err := organizer.OrganizeImagesBy("colour"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sinceLayouts are the timestamp formats --since accepts, in local time unless they say otherwise
+var sinceLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// HighWaterMarks remember, for each scanned folder, when the last incremental
+// run started, so the next one only has to look at files modified since then
+type HighWaterMarks struct {
+	Marks map[string]time.Time `json:"marks"`
+}
+
+// getHighWaterMarksPath returns the path of the local high-water mark file
+func getHighWaterMarksPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "incremental.json"), nil
+}
+
+// LoadHighWaterMarks reads the high-water marks from a file, returning none if it doesn't exist yet
+func LoadHighWaterMarks(path string) (*HighWaterMarks, error) {
+	marks := &HighWaterMarks{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read high-water mark file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, marks); err != nil {
+			return nil, fmt.Errorf("cannot parse high-water mark file: %v", err)
+		}
+	}
+	if marks.Marks == nil {
+		marks.Marks = make(map[string]time.Time)
+	}
+	return marks, nil
+}
+
+// Save writes the high-water marks to a file, creating its directory if needed
+func (hw *HighWaterMarks) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create high-water mark directory: %v", err)
+	}
+
+	if err := writeJSONAtomic(path, hw); err != nil {
+		return fmt.Errorf("cannot write high-water mark file: %v", err)
+	}
+	return nil
+}
+
+// highWaterKey is the key a folder's mark is kept under, its absolute path
+func highWaterKey(root string) string {
+	if abs, err := filepath.Abs(root); err == nil {
+		return abs
+	}
+	return root
+}
+
+// Get returns the mark for a folder, zero if it has never had an incremental run
+func (hw *HighWaterMarks) Get(root string) time.Time {
+	return hw.Marks[highWaterKey(root)]
+}
+
+// Set records the mark for a folder
+func (hw *HighWaterMarks) Set(root string, mark time.Time) {
+	hw.Marks[highWaterKey(root)] = mark
+}
+
+// RecordHighWaterMark stores mark as the point the next incremental run of root starts from
+func RecordHighWaterMark(path, root string, mark time.Time) error {
+	marks, err := LoadHighWaterMarks(path)
+	if err != nil {
+		return err
+	}
+	marks.Set(root, mark)
+	return marks.Save(path)
+}
+
+// parseSince parses a --since timestamp like "2024-03-01" or "2024-03-01 09:30"
+func parseSince(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range sinceLayouts {
+		if since, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return since, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q (use a date like 2024-03-01 or 2024-03-01 09:30)", value)
+}
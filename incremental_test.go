@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIncrementalScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	marksPath := filepath.Join(t.TempDir(), "incremental.json")
+
+	mark := time.Now().Add(-time.Hour)
+	files := map[string]time.Time{
+		"old.pdf":   mark.Add(-24 * time.Hour),
+		"new.pdf":   mark.Add(10 * time.Minute),
+		"newer.jpg": mark.Add(30 * time.Minute),
+	}
+	for name, modTime := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set time on %s: %v", name, err)
+		}
+	}
+	if err := RecordHighWaterMark(marksPath, tmpDir, mark); err != nil {
+		t.Fatalf("RecordHighWaterMark() error = %v", err)
+	}
+
+	marks, err := LoadHighWaterMarks(marksPath)
+	if err != nil {
+		t.Fatalf("LoadHighWaterMarks() error = %v", err)
+	}
+	scanner := NewScanner()
+	scanner.Since = marks.Get(tmpDir)
+	if !scanner.Since.Equal(mark) {
+		t.Fatalf("Stored mark = %v, want %v", scanner.Since, mark)
+	}
+	runStart := time.Now()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	scanned := make(map[string]bool)
+	for _, file := range scanner.Files {
+		scanned[file.Name] = true
+	}
+	if len(scanned) != 2 || !scanned["new.pdf"] || !scanned["newer.jpg"] {
+		t.Errorf("Scanned %v, want only new.pdf and newer.jpg", scanned)
+	}
+	if scanner.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", scanner.Unchanged)
+	}
+
+	// A successful run moves the mark up to when it started
+	if err := RecordHighWaterMark(marksPath, tmpDir, runStart); err != nil {
+		t.Fatalf("RecordHighWaterMark() error = %v", err)
+	}
+	marks, err = LoadHighWaterMarks(marksPath)
+	if err != nil {
+		t.Fatalf("LoadHighWaterMarks() error = %v", err)
+	}
+	if got := marks.Get(tmpDir); !got.Equal(runStart) {
+		t.Errorf("Mark after run = %v, want %v", got, runStart)
+	}
+	if got := marks.Get(t.TempDir()); !got.IsZero() {
+		t.Errorf("Mark for another folder = %v, want zero", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	want := time.Date(2024, 3, 1, 9, 30, 0, 0, time.Local)
+	for _, value := range []string{"2024-03-01 09:30", "2024-03-01T09:30:00", "2024-03-01 09:30:00"} {
+		got, err := parseSince(value)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseSince(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	if _, err := parseSince("last tuesday"); err == nil {
+		t.Error("Expected an error for an unparseable time")
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IndexEntry records where an organized file went
+type IndexEntry struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	OriginalPath string    `json:"original_path"`
+	Category     string    `json:"category"`
+	Hash         string    `json:"hash,omitempty"`
+	MovedAt      time.Time `json:"moved_at"`
+}
+
+// FileIndex is a local, searchable record of organized files, kept up to date
+// across runs so `elf-cli find` can tell where a file ended up
+type FileIndex struct {
+	Entries []IndexEntry `json:"entries"`
+}
+
+// getIndexPath returns the path of the local file index
+func getIndexPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "index.json"), nil
+}
+
+// LoadIndex reads a file index, returning an empty one if it doesn't exist yet
+func LoadIndex(path string) (*FileIndex, error) {
+	index := &FileIndex{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read index file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("cannot parse index file: %v", err)
+	}
+	return index, nil
+}
+
+// Save writes the index to a file, creating its directory if needed
+func (idx *FileIndex) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create index directory: %v", err)
+	}
+
+	if err := writeJSONAtomic(path, idx); err != nil {
+		return fmt.Errorf("cannot write index file: %v", err)
+	}
+	return nil
+}
+
+// AddMoves records the moves an organizer made. A file that was already indexed
+// keeps its original location and just gets its new path.
+func (idx *FileIndex) AddMoves(moves []fileMove, files []FileInfo, when time.Time) {
+	scanned := make(map[string]FileInfo, len(files))
+	for _, file := range files {
+		scanned[file.Path] = file
+	}
+	byPath := make(map[string]int, len(idx.Entries))
+	for i, entry := range idx.Entries {
+		byPath[entry.Path] = i
+	}
+
+	for _, move := range moves {
+		// Sidecars and other files the scan didn't see aren't worth indexing
+		file, ok := scanned[move.Src]
+		if !ok {
+			continue
+		}
+		if i, ok := byPath[move.Src]; ok {
+			idx.Entries[i].Path = move.Dst
+			idx.Entries[i].Name = filepath.Base(move.Dst)
+			idx.Entries[i].Category = file.Category
+			idx.Entries[i].MovedAt = when
+			delete(byPath, move.Src)
+			byPath[move.Dst] = i
+			continue
+		}
+		byPath[move.Dst] = len(idx.Entries)
+		idx.Entries = append(idx.Entries, IndexEntry{
+			Name:         filepath.Base(move.Dst),
+			Path:         move.Dst,
+			OriginalPath: move.Src,
+			Category:     file.Category,
+			Hash:         file.Hash,
+			MovedAt:      when,
+		})
+	}
+}
+
+// RecordMoves adds an organizer's moves to the index file at path
+func RecordMoves(path string, fo *FileOrganizer) error {
+	index, err := LoadIndex(path)
+	if err != nil {
+		return err
+	}
+	index.AddMoves(fo.moves, fo.Scanner.Files, time.Now())
+	return index.Save(path)
+}
+
+// Find returns the entries whose name contains query, ignoring case, or
+// whose hash starts with it
+func (idx *FileIndex) Find(query string) []IndexEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var found []IndexEntry
+	for _, entry := range idx.Entries {
+		hash := strings.ToLower(strings.TrimPrefix(entry.Hash, archiveHashPrefix))
+		if strings.Contains(strings.ToLower(entry.Name), query) || (hash != "" && strings.HasPrefix(hash, query)) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// PrintIndexEntries prints search results from the index
+func PrintIndexEntries(entries []IndexEntry) {
+	if len(entries) == 0 {
+		fmt.Println("🔍 No organized files match")
+		return
+	}
+
+	infoColor.Printf("🔍 Found %d organized files:\n", len(entries))
+	for _, entry := range entries {
+		fmt.Printf("  %s\n", entry.Path)
+		fmt.Printf("    from: %s\n", entry.OriginalPath)
+		fmt.Printf("    category: %s, moved: %s\n", entry.Category, entry.MovedAt.Format("2006-01-02 15:04:05"))
+		if entry.Hash != "" {
+			fmt.Printf("    hash: %s\n", entry.Hash)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordMovesAndFind(t *testing.T) {
+	tmpDir := t.TempDir()
+	indexPath := filepath.Join(tmpDir, "elf-cli", "index.json")
+	downloads := filepath.Join(tmpDir, "downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, name := range []string{"Invoice-March.pdf", "holiday.jpg"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, downloads)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if err := RecordMoves(indexPath, organizer); err != nil {
+		t.Fatalf("RecordMoves() error = %v", err)
+	}
+
+	index, err := LoadIndex(indexPath)
+	if err != nil {
+		t.Fatalf("LoadIndex() error = %v", err)
+	}
+	if len(index.Entries) != 2 {
+		t.Fatalf("Expected 2 indexed files, got %d", len(index.Entries))
+	}
+
+	// By part of the name, ignoring case
+	found := index.Find("invoice")
+	if len(found) != 1 {
+		t.Fatalf("Expected 1 match for invoice, got %d", len(found))
+	}
+	invoice := found[0]
+	if invoice.Path != filepath.Join(downloads, "Documents", "Invoice-March.pdf") || invoice.OriginalPath != filepath.Join(downloads, "Invoice-March.pdf") {
+		t.Errorf("Unexpected entry for the invoice: %+v", invoice)
+	}
+	if invoice.Category != "Documents" || invoice.MovedAt.IsZero() {
+		t.Errorf("Expected the category and move time to be recorded: %+v", invoice)
+	}
+
+	// By the start of the hash
+	var photoHash string
+	for _, file := range scanner.Files {
+		if file.Name == "holiday.jpg" {
+			photoHash = file.Hash
+		}
+	}
+	found = index.Find(photoHash[:8])
+	if len(found) != 1 || found[0].Name != "holiday.jpg" {
+		t.Errorf("Expected the hash prefix to find holiday.jpg, got %+v", found)
+	}
+	if found := index.Find("nothing-like-this"); len(found) != 0 {
+		t.Errorf("Expected no matches, got %+v", found)
+	}
+}
+
+func TestAddMovesKeepsOriginalLocation(t *testing.T) {
+	index := &FileIndex{}
+	file := FileInfo{Path: "/d/report.pdf", Name: "report.pdf", Category: "Documents", Hash: "abc123"}
+	index.AddMoves([]fileMove{{Src: "/d/report.pdf", Dst: "/d/Documents/report.pdf"}}, []FileInfo{file}, time.Now())
+
+	// A later run moves it again, say by date
+	moved := FileInfo{Path: "/d/Documents/report.pdf", Name: "report.pdf", Category: "Documents", Hash: "abc123"}
+	index.AddMoves([]fileMove{{Src: "/d/Documents/report.pdf", Dst: "/d/2024-03/report.pdf"}}, []FileInfo{moved}, time.Now())
+
+	if len(index.Entries) != 1 {
+		t.Fatalf("Expected the second move to update the entry, got %d entries", len(index.Entries))
+	}
+	if entry := index.Entries[0]; entry.Path != "/d/2024-03/report.pdf" || entry.OriginalPath != "/d/report.pdf" {
+		t.Errorf("Unexpected entry after moving again: %+v", entry)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	languageSampleSize    = 4096 // Bytes read from each file for detection
+	minLanguageConfidence = 0.5  // Below this, files go to the Unknown folder
+	unknownLanguageFolder = "Unknown"
+)
+
+// codeExtensions are source files that are bucketed by language along with text documents
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".c": true, ".h": true,
+	".cpp": true, ".rb": true, ".rs": true, ".sh": true, ".php": true, ".swift": true, ".kt": true,
+}
+
+// LanguageDetector guesses the human or programming language of a text sample,
+// along with a confidence between 0 and 1
+type LanguageDetector interface {
+	Detect(sample []byte) (string, float64)
+}
+
+// keywordDetector is a lightweight detector that counts telltale words
+type keywordDetector struct {
+	languages map[string][]string
+}
+
+// newKeywordDetector returns the built-in detector
+func newKeywordDetector() keywordDetector {
+	return keywordDetector{languages: map[string][]string{
+		"English":    {"the", "and", "is", "of", "to", "that", "with"},
+		"Spanish":    {"el", "los", "que", "y", "es", "por", "una"},
+		"French":     {"le", "les", "et", "est", "une", "des", "pour"},
+		"German":     {"der", "die", "und", "ist", "das", "nicht", "mit"},
+		"Go":         {"package", "func", ":=", "err", "nil"},
+		"Python":     {"def", "self", "elif", "None", "import"},
+		"JavaScript": {"function", "const", "=>", "let", "undefined"},
+		"Java":       {"public", "class", "void", "static", "private"},
+	}}
+}
+
+// Detect scores the sample against each language's keywords and returns the best
+// match, with its share of all matches as the confidence
+func (kd keywordDetector) Detect(sample []byte) (string, float64) {
+	counts := make(map[string]int)
+	for _, word := range strings.Fields(string(sample)) {
+		word = strings.Trim(word, ".,;:!?()[]{}\"'")
+		counts[word]++
+	}
+
+	best, bestScore, total := "", 0, 0
+	for language, keywords := range kd.languages {
+		score := 0
+		for _, keyword := range keywords {
+			score += counts[keyword]
+		}
+		total += score
+		if score > bestScore || (score == bestScore && score > 0 && language < best) {
+			best, bestScore = language, score
+		}
+	}
+	if total == 0 {
+		return "", 0
+	}
+	return best, float64(bestScore) / float64(total)
+}
+
+// readTextSample reads the start of a file, returning false if it doesn't look like text
+func readTextSample(path string) ([]byte, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	sample := make([]byte, languageSampleSize)
+	n, _ := file.Read(sample)
+	sample = sample[:n]
+	if n == 0 || bytes.IndexByte(sample, 0) >= 0 {
+		return nil, false
+	}
+	// Don't reject text just because the sample cut a character in half
+	for i := 0; i < utf8.UTFMax && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	return sample, utf8.Valid(sample)
+}
+
+// OrganizeByLanguage sorts text documents and source code into Documents/<language>
+// subfolders. Binary documents like PDFs are left where they are.
+func (fo *FileOrganizer) OrganizeByLanguage(detector LanguageDetector) error {
+	fmt.Println("🌍 Starting language-based organization...")
+	fmt.Println()
+
+	documentsFolder := fo.CategoryMap["Documents"]
+	languageGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		if file.Category != "Documents" && !codeExtensions[file.Extension] {
+			continue
+		}
+
+		sample, ok := readTextSample(file.Path)
+		if !ok {
+			continue
+		}
+		language, confidence := detector.Detect(sample)
+		if language == "" || confidence < minLanguageConfidence {
+			language = unknownLanguageFolder
+		}
+		folder := filepath.Join(documentsFolder, language)
+		languageGroups[folder] = append(languageGroups[folder], file)
+	}
+
+	return fo.moveGroups(languageGroups, "🌍", "language-based")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubDetector returns a fixed answer for samples containing a marker word
+type stubDetector map[string]struct {
+	language   string
+	confidence float64
+}
+
+func (sd stubDetector) Detect(sample []byte) (string, float64) {
+	for marker, result := range sd {
+		if strings.Contains(string(sample), marker) {
+			return result.language, result.confidence
+		}
+	}
+	return "", 0
+}
+
+func TestOrganizeByLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"letter.txt":  "HOLA amigos",
+		"notes.md":    "BONJOUR tout le monde",
+		"main.go":     "GOCODE here",
+		"vague.txt":   "MAYBE something",
+		"mystery.txt": "nothing recognizable",
+		"scan.pdf":    "\x00binary pdf data",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	detector := stubDetector{
+		"HOLA":    {"Spanish", 0.9},
+		"BONJOUR": {"French", 0.8},
+		"GOCODE":  {"Go", 1},
+		"MAYBE":   {"German", 0.2},
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByLanguage(detector); err != nil {
+		t.Fatalf("OrganizeByLanguage() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"letter.txt":  filepath.Join("Documents", "Spanish"),
+		"main.go":     filepath.Join("Documents", "Go"),
+		"vague.txt":   filepath.Join("Documents", unknownLanguageFolder),
+		"mystery.txt": filepath.Join("Documents", unknownLanguageFolder),
+		"scan.pdf":    "", // Binary, so left alone
+	}
+	for name, folder := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder, name)); err != nil {
+			t.Errorf("Expected %s in %q: %v", name, folder, err)
+		}
+	}
+
+	// .md isn't in the Documents category, so it isn't touched either
+	if _, err := os.Stat(filepath.Join(tmpDir, "notes.md")); err != nil {
+		t.Errorf("Expected notes.md to be left alone: %v", err)
+	}
+}
+
+func TestKeywordDetector(t *testing.T) {
+	detector := newKeywordDetector()
+
+	tests := []struct {
+		sample   string
+		expected string
+	}{
+		{"The report is ready and the numbers add up to what we expected with the team.", "English"},
+		{"package main\n\nfunc main() {\n\tif err != nil {\n\t\treturn nil\n\t}\n}", "Go"},
+		{"Der Hund und die Katze ist nicht das Problem, sagt der Mann mit dem Hut.", "German"},
+	}
+
+	for _, tt := range tests {
+		language, confidence := detector.Detect([]byte(tt.sample))
+		if language != tt.expected || confidence < minLanguageConfidence {
+			t.Errorf("Detect(%q) = %s (%.2f), want %s", tt.sample, language, confidence, tt.expected)
+		}
+	}
+
+	if language, _ := detector.Detect([]byte("12345 67890")); language != "" {
+		t.Errorf("Expected no language for a sample without words, got %s", language)
+	}
+}
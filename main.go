@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
@@ -47,6 +51,50 @@ func validatePath(path string) error {
 	return nil
 }
 
+// defaultMaxScanFiles is how many files a folder can hold before the scan refuses it
+const defaultMaxScanFiles = 100000
+
+// checkScanRoot refuses to clean the home directory itself by accident, which
+// needs --i-know-what-im-doing to go ahead. Folders with too many files are
+// refused by the scan, through Scanner.MaxFiles.
+func checkScanRoot(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %v", err)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil && filepath.Clean(absPath) == filepath.Clean(homeDir) {
+		return fmt.Errorf("%s is your whole home directory; point --path at a folder inside it, or add --i-know-what-im-doing", absPath)
+	}
+	return nil
+}
+
+// parseByteSize parses a size like "512", "32KB" or "1.5GB" into bytes
+func parseByteSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	s = strings.TrimSuffix(s, "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1024 * 1024 * 1024
+	case strings.HasSuffix(s, "T"):
+		multiplier = 1024 * 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
 // getDefaultDownloadsPath returns the default downloads folder path based on the operating system
 func getDefaultDownloadsPath() (string, error) {
 	home, err := os.UserHomeDir()
@@ -77,12 +125,6 @@ func getDefaultDownloadsPath() (string, error) {
 }
 
 func main() {
-	// Define color schemes for friendly output
-	successColor := color.New(color.FgGreen, color.Bold)
-	infoColor := color.New(color.FgCyan)
-	warningColor := color.New(color.FgYellow)
-	errorColor := color.New(color.FgRed, color.Bold)
-
 	app := &cli.App{
 		Name:        "elf-cli",
 		Usage:       "A friendly tool to clean up your downloads folder",
@@ -97,12 +139,206 @@ func main() {
 		UsageText: `elf-cli clean [options]
    elf-cli clean --dry-run --organize --remove-duplicates
    elf-cli clean --path /custom/path --organize-by-date`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Print plain text without colors (also turned off automatically on consoles that can't show them)",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			configureColor(c.Bool("no-color"))
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "clean",
 				Aliases: []string{"c"},
 				Usage:   "Clean up your downloads folder",
 				Action: func(c *cli.Context) error {
+					// --preview-json plans a real run first, with the same options as a
+					// dry run, so the plan is printed before anything is changed
+					if c.Bool("preview-json") && !c.Bool("dry-run") {
+						notify := c.Bool("notify")
+						c.Set("dry-run", "true")
+						c.Set("notify", "false")
+						err := c.Command.Action(c)
+						c.Set("dry-run", "false")
+						c.Set("notify", strconv.FormatBool(notify))
+						if err != nil {
+							return err
+						}
+					}
+
+					audit := c.Bool("audit-duplicates")
+					reportOutput := os.Stdout
+					scriptPath := c.String("emit-script")
+					// A plain --remove-duplicates run reports what it removed
+					plainRemove := c.Bool("remove-duplicates") && !c.Bool("interactive-duplicates") && !c.Bool("pattern-duplicates") && c.String("move-duplicates") == "" && scriptPath == ""
+					if c.Bool("json") {
+						if !audit && !plainRemove {
+							errorColor.Printf("❌ --json only applies to --audit-duplicates and --remove-duplicates\n")
+							return fmt.Errorf("--json requires --audit-duplicates or --remove-duplicates")
+						}
+						// Keep stdout for the JSON report and send progress messages to stderr
+						colorOutput := color.Output
+						os.Stdout, color.Output = os.Stderr, os.Stderr
+						defer func() {
+							os.Stdout, color.Output = reportOutput, colorOutput
+						}()
+					}
+					if c.Bool("preview-json") {
+						if c.Bool("json") {
+							errorColor.Printf("❌ --preview-json and --json both write to standard output, so use one or the other\n")
+							return fmt.Errorf("conflicting flags: --preview-json and --json")
+						}
+						// Keep stdout for the plan and send progress messages to stderr
+						colorOutput := color.Output
+						os.Stdout, color.Output = os.Stderr, os.Stderr
+						defer func() {
+							os.Stdout, color.Output = reportOutput, colorOutput
+						}()
+					}
+					if audit && (c.Bool("remove-duplicates") || c.Bool("interactive-duplicates") || c.Bool("pattern-duplicates") || c.String("move-duplicates") != "") {
+						errorColor.Printf("❌ --audit-duplicates only reports, so it can't be combined with options that remove or move duplicates\n")
+						return fmt.Errorf("conflicting flags: --audit-duplicates with a duplicate removal option")
+					}
+
+					if scriptPath != "" && (c.Bool("interactive-duplicates") || c.String("move-duplicates") != "" || c.Bool("remove-extracted")) {
+						errorColor.Printf("❌ --emit-script only writes the commands for you to run, so it can't be combined with options that move or remove files themselves\n")
+						return fmt.Errorf("conflicting flags: --emit-script with a duplicate removal option")
+					}
+
+					// Hashing every file is only worth it when something looks at duplicates
+					dedupe := c.Bool("remove-duplicates") || c.Bool("interactive-duplicates") || c.Bool("pattern-duplicates") || c.String("move-duplicates") != "" || scriptPath != ""
+					nameSize := c.Bool("dedupe-by-name-size")
+					if c.Bool("verify-content") && !nameSize {
+						errorColor.Printf("❌ --verify-content only applies to --dedupe-by-name-size\n")
+						return fmt.Errorf("--verify-content without --dedupe-by-name-size")
+					}
+					if nameSize && dedupe && !c.Bool("verify-content") {
+						errorColor.Printf("❌ --dedupe-by-name-size only finds probable duplicates; add --verify-content to remove or move them\n")
+						return fmt.Errorf("--dedupe-by-name-size removal without --verify-content")
+					}
+					if nameSize && audit {
+						errorColor.Printf("❌ --audit-duplicates can't be combined with --dedupe-by-name-size\n")
+						return fmt.Errorf("conflicting flags: --audit-duplicates with --dedupe-by-name-size")
+					}
+					// Verified name and size matches are removed like any other duplicates,
+					// but only they get hashed
+					dedupe = dedupe || (nameSize && c.Bool("verify-content"))
+					removeExtracted := c.Bool("remove-extracted")
+					deferDeletions := c.Bool("defer-deletions")
+					if deferDeletions && c.Bool("remove-duplicate-dirs") {
+						errorColor.Printf("❌ --defer-deletions can't be combined with --remove-duplicate-dirs, which asks before removing each folder\n")
+						return fmt.Errorf("conflicting flags: --defer-deletions with --remove-duplicate-dirs")
+					}
+					if removeExtracted && audit {
+						errorColor.Printf("❌ --audit-duplicates only reports, so it can't be combined with --remove-extracted\n")
+						return fmt.Errorf("conflicting flags: --audit-duplicates with --remove-extracted")
+					}
+					needsHashes := (dedupe && !nameSize) || audit || c.Bool("find-partial-duplicates") || c.Bool("find-name-variants") || c.Bool("find-duplicate-dirs") || c.Bool("remove-duplicate-dirs") || c.Bool("shard-by-hash") || c.Bool("index") || c.Bool("post-verify") || c.String("remote-manifest") != "" || c.String("ignore-hashes") != ""
+					if c.Bool("no-dedupe-scan") && needsHashes {
+						errorColor.Printf("❌ --no-dedupe-scan can't be combined with options that look for duplicates\n")
+						return fmt.Errorf("conflicting flags: --no-dedupe-scan with a duplicate option")
+					}
+					if c.Bool("crc-prefilter") {
+						// These compare or record the hash of every file, not just likely duplicates
+						for _, flag := range []string{"find-partial-duplicates", "find-duplicate-dirs", "remove-duplicate-dirs", "shard-by-hash", "index", "post-verify", "remote-manifest", "ignore-hashes"} {
+							if c.IsSet(flag) {
+								errorColor.Printf("❌ --crc-prefilter leaves files without a duplicate unhashed, so it can't be combined with --%s\n", flag)
+								return fmt.Errorf("conflicting flags: --crc-prefilter with --%s", flag)
+							}
+						}
+					}
+					organize := c.Bool("organize") || c.Bool("organize-by-date") || c.Bool("organize-by-size") || c.Bool("organize-by-tag") || c.Bool("group-by-source-app") || c.String("organize-images-by") != "" || c.Bool("organize-media-by-duration") || c.Bool("organize-by-mime") || c.Bool("organize-by-language") || c.Bool("organize-by-session") || c.Bool("organize-by-access") || c.Int("rare-threshold") > 0 || c.String("map-file") != "" || c.Bool("shard-by-hash") || c.Bool("process-zips")
+					// The script's paths are the ones found by the scan, so nothing may move them first
+					if scriptPath != "" && (organize || c.Bool("normalize-names") || c.Bool("remove-duplicate-dirs")) {
+						errorColor.Printf("❌ --emit-script lists files where the scan found them, so it can't be combined with options that organize, rename or remove folders\n")
+						return fmt.Errorf("conflicting flags: --emit-script with an option that moves files")
+					}
+					dateSources, err := parseDateSources(c.StringSlice("date-source"))
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+					if len(dateSources) > 0 && !c.Bool("organize-by-date") {
+						errorColor.Printf("❌ --date-source only applies to --organize-by-date\n")
+						return fmt.Errorf("--date-source without --organize-by-date")
+					}
+					for _, source := range dateSources {
+						if source == "downloaded" && runtime.GOOS != "darwin" {
+							warningColor.Printf("⚠️  Only macOS records when a file was downloaded, so the downloaded date source will be skipped\n")
+						}
+					}
+					if c.Bool("post-verify") && !organize {
+						errorColor.Printf("❌ --post-verify only applies to options that organize files\n")
+						return fmt.Errorf("--post-verify without an organize option")
+					}
+					if c.String("dest") != "" && !organize {
+						errorColor.Printf("❌ --dest only applies to options that organize files\n")
+						return fmt.Errorf("--dest without an organize option")
+					}
+					var moveMap map[string]string
+					if mapFile := c.String("map-file"); mapFile != "" {
+						if moveMap, err = LoadMoveMap(mapFile); err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+					} else if c.Bool("map-only") {
+						errorColor.Printf("❌ --map-only only applies to --map-file\n")
+						return fmt.Errorf("--map-only without --map-file")
+					}
+					if c.Bool("restore-names") && c.String("move-duplicates") == "" {
+						errorColor.Printf("❌ --restore-names only applies to --move-duplicates\n")
+						return fmt.Errorf("--restore-names without --move-duplicates")
+					}
+					if c.Bool("group-duplicates-output") && c.String("move-duplicates") == "" {
+						errorColor.Printf("❌ --group-duplicates-output only applies to --move-duplicates\n")
+						return fmt.Errorf("--group-duplicates-output without --move-duplicates")
+					}
+					if c.Bool("pack-tiny") && !c.Bool("organize-by-size") {
+						errorColor.Printf("❌ --pack-tiny only applies to --organize-by-size\n")
+						return fmt.Errorf("--pack-tiny without --organize-by-size")
+					}
+					if c.Int("rare-threshold") < 0 {
+						errorColor.Printf("❌ --rare-threshold can't be negative\n")
+						return fmt.Errorf("invalid rare-threshold: %d", c.Int("rare-threshold"))
+					}
+					var since time.Time
+					if c.String("since") != "" {
+						if since, err = parseSince(c.String("since")); err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+					}
+					if c.Bool("compare-last") && (!since.IsZero() || c.Bool("incremental") || c.Int("trial") > 0) {
+						errorColor.Printf("❌ --compare-last compares whole folders, so it can't be combined with --since, --incremental or --trial\n")
+						return fmt.Errorf("conflicting flags: --compare-last with a partial scan")
+					}
+					durationTiers, err := parseDurationTiers(c.String("duration-tiers"))
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+					sessionGap, err := time.ParseDuration(c.String("session-gap"))
+					if err != nil || sessionGap <= 0 {
+						errorColor.Printf("❌ Invalid --session-gap %q: use a duration like 10m or 1h30m\n", c.String("session-gap"))
+						return fmt.Errorf("invalid session gap: %s", c.String("session-gap"))
+					}
+					var maxRuntime time.Duration
+					if c.String("max-runtime") != "" {
+						maxRuntime, err = time.ParseDuration(c.String("max-runtime"))
+						if err != nil || maxRuntime <= 0 {
+							errorColor.Printf("❌ Invalid --max-runtime %q: use a duration like 5m or 1h\n", c.String("max-runtime"))
+							return fmt.Errorf("invalid max-runtime: %s", c.String("max-runtime"))
+						}
+					}
+					countOnly := c.Bool("count-only")
+					if countOnly && (needsHashes || nameSize || removeExtracted || organize || c.Bool("normalize-names") || c.Bool("empty-quarantine")) {
+						errorColor.Printf("❌ --count-only just counts, so it can't be combined with options that hash, move or delete files\n")
+						return fmt.Errorf("conflicting flags: --count-only with another action")
+					}
+
 					downloadsPath := c.String("path")
 					if downloadsPath == "" {
 						// Try to get the default downloads folder
@@ -120,6 +356,12 @@ func main() {
 						errorColor.Printf("❌ Invalid path: %v\n", err)
 						return err
 					}
+					// A deletion script is run later, maybe from another folder, so it needs absolute paths
+					if scriptPath != "" {
+						if abs, err := filepath.Abs(downloadsPath); err == nil {
+							downloadsPath = abs
+						}
+					}
 
 					infoColor.Printf("🧹 Starting to clean up your downloads folder...\n")
 					infoColor.Printf("📂 Looking at: %s\n", downloadsPath)
@@ -130,18 +372,111 @@ func main() {
 						return fmt.Errorf("downloads folder not found")
 					}
 
+					// Make sure nobody tidies up their whole home directory by accident
+					if !c.Bool("i-know-what-im-doing") {
+						if err := checkScanRoot(downloadsPath); err != nil {
+							errorColor.Printf("❌ Refusing to continue: %v\n", err)
+							return err
+						}
+					}
+
 					dryRun := c.Bool("dry-run")
+
+					// Previewing a category is a dry run of organizing just that category
+					previewCategory := c.String("preview-category")
+					if previewCategory != "" {
+						if dedupe || c.Bool("normalize-names") || c.Bool("remove-duplicate-dirs") || len(c.StringSlice("only-categories")) > 0 || len(c.StringSlice("skip-categories")) > 0 {
+							errorColor.Printf("❌ --preview-category only previews organizing, so it can't be combined with duplicate, renaming or category filter options\n")
+							return fmt.Errorf("conflicting flags: --preview-category with another action")
+						}
+						dryRun = true
+						organize = true
+					}
+
+					// Checking that a folder is already tidy is a dry run that fails if anything would change
+					assertClean := c.Bool("assert-clean")
+					if assertClean {
+						if c.Bool("dry-run-interactive") {
+							errorColor.Printf("❌ --assert-clean only checks, so it can't be combined with --dry-run-interactive\n")
+							return fmt.Errorf("conflicting flags: --assert-clean and --dry-run-interactive")
+						}
+						dryRun = true
+					}
+
+					destExistsStrategy := c.String("dest-exists-strategy")
+					if destExistsStrategy != DestExistsSkip && destExistsStrategy != DestExistsMerge {
+						errorColor.Printf("❌ Unknown --dest-exists-strategy %q (use %s or %s)\n", destExistsStrategy, DestExistsSkip, DestExistsMerge)
+						return fmt.Errorf("invalid dest-exists-strategy: %s", destExistsStrategy)
+					}
+					identicalDestStrategy := c.String("identical-dest-strategy")
+					if identicalDestStrategy != IdenticalDestSkip && identicalDestStrategy != IdenticalDestRemove {
+						errorColor.Printf("❌ Unknown --identical-dest-strategy %q (use %s or %s)\n", identicalDestStrategy, IdenticalDestSkip, IdenticalDestRemove)
+						return fmt.Errorf("invalid identical-dest-strategy: %s", identicalDestStrategy)
+					}
+
+					// Ask before each change instead of all at once
+					var approver *Approver
+					if c.Bool("dry-run-interactive") {
+						if dryRun {
+							errorColor.Printf("❌ --dry-run-interactive can't be combined with --dry-run\n")
+							return fmt.Errorf("conflicting flags: --dry-run-interactive and --dry-run")
+						}
+						if !isTerminal(os.Stdin) {
+							errorColor.Printf("❌ --dry-run-interactive needs an interactive terminal to ask you questions\n")
+							return fmt.Errorf("--dry-run-interactive requires a terminal")
+						}
+						approver = NewApprover(os.Stdin, os.Stdout)
+					}
+
+					// Record the plan to save it or compare it with an earlier one
+					var plan, previousPlan *Plan
+					if c.String("save-plan") != "" || c.String("diff-plan") != "" {
+						if !dryRun {
+							errorColor.Printf("❌ --save-plan and --diff-plan only work with --dry-run\n")
+							return fmt.Errorf("--save-plan and --diff-plan require --dry-run")
+						}
+						plan = &Plan{}
+						if previousPath := c.String("diff-plan"); previousPath != "" {
+							var err error
+							previousPlan, err = LoadPlan(previousPath)
+							if err != nil {
+								errorColor.Printf("❌ %v\n", err)
+								return err
+							}
+						}
+					}
+
+					if (c.Bool("preview-json") || assertClean) && plan == nil {
+						plan = &Plan{}
+					}
+					// Written however the run ends, so a failed run still reports what it did
+					if c.Bool("preview-json") {
+						defer func() {
+							if err := plan.WriteJSON(reportOutput); err != nil {
+								warningColor.Printf("⚠️  Could not write the plan: %v\n", err)
+							}
+						}()
+					}
+
+					if c.Bool("quarantine") && c.Bool("shred") {
+						errorColor.Printf("❌ --quarantine keeps removed duplicates, so it can't be combined with --shred\n")
+						return fmt.Errorf("conflicting flags: --quarantine and --shred")
+					}
 					
 					// Show prominent warning about destructive operations
-					errorColor.Printf("⚠️  WARNING: This tool performs DESTRUCTIVE file operations!\n")
-					errorColor.Printf("⚠️  Files may be DELETED or MOVED permanently.\n")
+					if !audit && !countOnly {
+						errorColor.Printf("⚠️  WARNING: This tool performs DESTRUCTIVE file operations!\n")
+						errorColor.Printf("⚠️  Files may be DELETED or MOVED permanently.\n")
+					}
 					
-					if !dryRun {
+					if !dryRun && !audit && !countOnly {
 						errorColor.Printf("⚠️  Use --dry-run first to preview changes safely.\n")
 						fmt.Println()
 						
-						// Skip confirmation if --force flag is used
-						if !c.Bool("force") {
+						// Skip confirmation if --force flag is used, or if each change will be confirmed anyway
+						if approver != nil {
+							infoColor.Printf("🙋 You'll be asked before each change\n")
+						} else if !c.Bool("force") {
 							// Ask for confirmation before proceeding
 							fmt.Print("🤔 Do you want to continue? (y/N): ")
 							var response string
@@ -162,60 +497,558 @@ func main() {
 						warningColor.Printf("⚠️  Dry run mode enabled - no files will be moved or deleted\n")
 					}
 
+					// Stop scanning and starting new moves and removals once the time
+					// budget is used up, which starts counting once the run is confirmed
+					var deadline *Deadline
+					if maxRuntime > 0 {
+						var cancel context.CancelFunc
+						deadline, cancel = NewDeadline(maxRuntime)
+						defer cancel()
+					}
+
+					// Emptying the quarantine is a job on its own
+					if c.Bool("empty-quarantine") {
+						quarantine, err := OpenQuarantine(downloadsPath)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						count, sizeMB := len(quarantine.Entries), float64(quarantine.TotalSize())/1024/1024
+						if dryRun {
+							warningColor.Printf("🗑️  Would permanently delete %d quarantined files (%.2f MB) in %s\n", count, sizeMB, quarantine.Dir)
+							return nil
+						}
+						if err := quarantine.Empty(); err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						successColor.Printf("✅ Permanently deleted %d quarantined files (%.2f MB)\n", count, sizeMB)
+						return nil
+					}
+
+					// Load the user's category rules, if they have any
+					config, err := loadUserConfig()
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+
 					// Create a new scanner and scan the directory
 					scanner := NewScanner()
+					config.ApplyToScanner(scanner)
+					scanner.Deadline = deadline
+					if !c.Bool("i-know-what-im-doing") {
+						scanner.MaxFiles = c.Int("max-scan-files")
+					}
+
+					// Validate the folders files are moved into now, so the ones inside
+					// the folder being cleaned can be kept out of the scan
+					var outputDirs []string
+					if moveFolder := c.String("move-duplicates"); moveFolder != "" {
+						if err := validatePath(moveFolder); err != nil {
+							errorColor.Printf("❌ Invalid move folder path: %v\n", err)
+							return err
+						}
+						moveDest, _, err := checkMoveDestination(downloadsPath, moveFolder)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						outputDirs = append(outputDirs, moveDest)
+					}
+					destPath := downloadsPath
+					if dest := c.String("dest"); dest != "" {
+						if err := validatePath(dest); err != nil {
+							errorColor.Printf("❌ Invalid destination path: %v\n", err)
+							return err
+						}
+						destPath = dest
+						outputDirs = append(outputDirs, dest)
+					}
+					for _, dir := range scanner.ExcludeOutputDirs(downloadsPath, outputDirs...) {
+						infoColor.Printf("📁 %s is inside the folder being cleaned, so it won't be scanned\n", dir)
+					}
+					blockSize, err := parseByteSize(c.String("hash-block-size"))
+					if err != nil || blockSize < 1024 || blockSize > 64*1024*1024 {
+						errorColor.Printf("❌ Invalid --hash-block-size %q (use something between 1KB and 64MB)\n", c.String("hash-block-size"))
+						return fmt.Errorf("invalid hash-block-size: %s", c.String("hash-block-size"))
+					}
+					scanner.HashBlockSize = int(blockSize)
+					if rate := c.String("throttle"); rate != "" {
+						bytesPerSecond, err := ParseThrottle(rate)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						scanner.Throttle = NewRateLimiter(bytesPerSecond)
+						infoColor.Printf("🐢 Reading and copying at most %.2f MB/s\n", float64(bytesPerSecond)/1024/1024)
+					}
+					scanner.IncludeInProgress = c.Bool("include-in-progress")
+					if c.Bool("plugins") {
+						pluginsDir, err := getPluginsDir()
+						if err != nil {
+							errorColor.Printf("❌ Couldn't find the config directory: %v\n", err)
+							return err
+						}
+						registry, err := LoadPlugins(pluginsDir)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						scanner.Plugins = registry
+						infoColor.Printf("🧩 Using %d plugins from %s\n", len(registry.Plugins), pluginsDir)
+					}
+					scanner.MaxDepth = c.Int("max-depth")
+					scanner.MaxPathLength = c.Int("max-path-length")
+					if trial := c.Int("trial"); trial < 0 {
+						errorColor.Printf("❌ --trial must be at least 1\n")
+						return fmt.Errorf("invalid trial: %d", trial)
+					} else if trial > 0 {
+						scanner.TrialLimit = trial
+						warningColor.Printf("🧪 Trial mode: limited to %d files\n", trial)
+					}
+					scanner.NoCacheHashing = c.Bool("hash-no-cache")
+					scanner.CRCPrefilter = c.Bool("crc-prefilter")
+					if c.Bool("preserve-xattrs") && !xattrCopySupported {
+						warningColor.Printf("⚠️  --preserve-xattrs only works on Linux and macOS; extended attributes won't be copied here\n")
+					}
+					scanner.SplitInstallers = c.Bool("split-installers")
+					scanner.Verbose = c.Bool("verbose")
+					scanner.SkipHashing = !needsHashes
+					scanner.CompareArchiveContents = c.Bool("compare-archive-contents")
+					if hashListPath := c.String("ignore-hashes"); hashListPath != "" {
+						protected, algorithms, err := LoadHashList(hashListPath)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						scanner.ProtectedHashes = protected
+						scanner.HashAlgorithms = algorithms
+						infoColor.Printf("🛡️  Protecting files with one of %d hashes from %s\n", len(protected), hashListPath)
+					}
+					if c.Bool("find-partial-duplicates") {
+						threshold := c.Float64("partial-threshold")
+						if threshold <= 0 || threshold > 1 {
+							errorColor.Printf("❌ Invalid --partial-threshold %v (use a fraction between 0 and 1)\n", threshold)
+							return fmt.Errorf("invalid partial-threshold: %v", threshold)
+						}
+						scanner.PartialThreshold = threshold
+					}
+					for _, pattern := range append(c.StringSlice("include"), c.StringSlice("exclude")...) {
+						if _, err := filepath.Match(pattern, ""); err != nil {
+							errorColor.Printf("❌ Invalid pattern %q: %v\n", pattern, err)
+							return fmt.Errorf("invalid pattern: %s", pattern)
+						}
+					}
+					scanner.IncludePatterns = c.StringSlice("include")
+					scanner.ExcludePatterns = c.StringSlice("exclude")
+					scanner.IncludeWins = c.Bool("include-wins")
+					scanner.CategoryFromParent = c.Bool("category-from-parent")
+					if patterns := c.StringSlice("screenshot-pattern"); len(patterns) > 0 {
+						scanner.ScreenshotPatterns = patterns
+					} else if c.Bool("screenshots") {
+						scanner.ScreenshotPatterns = defaultScreenshotPatterns
+					}
+
+					// Fetch the remote manifest first so a bad URL fails before a long scan
+					var archivedHashes map[string]bool
+					if url := c.String("remote-manifest"); url != "" {
+						infoColor.Printf("☁️  Fetching remote manifest from %s\n", url)
+						archivedHashes, err = FetchRemoteManifest(url, remoteManifestTimeout)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+					}
+
+					// An incremental run picks up where the last one of this folder started
+					runStart := time.Now()
+					if !since.IsZero() {
+						scanner.Since = since
+					} else if c.Bool("incremental") {
+						marksPath, err := getHighWaterMarksPath()
+						if err == nil {
+							var marks *HighWaterMarks
+							if marks, err = LoadHighWaterMarks(marksPath); err == nil {
+								scanner.Since = marks.Get(downloadsPath)
+							}
+						}
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+					}
+					if !scanner.Since.IsZero() {
+						infoColor.Printf("⏭️  Only looking at files modified since %s\n", scanner.Since.Format("2006-01-02 15:04:05"))
+					} else if c.Bool("incremental") {
+						infoColor.Printf("⏭️  First incremental run of this folder, looking at every file\n")
+					}
 					scanErr := scanner.ScanDirectory(downloadsPath)
+					if errors.Is(scanErr, errTooManyFiles) {
+						errorColor.Printf("❌ Refusing to continue: %v\n", scanErr)
+						return scanErr
+					}
 					if scanErr != nil {
 						errorColor.Printf("❌ Error scanning directory: %v\n", scanErr)
 						return scanErr
 					}
 
+					// A quick count is all that was asked for
+					if countOnly {
+						scanner.PrintCategoryCounts()
+						return nil
+					}
+
+					if archivedHashes != nil {
+						scanner.MarkArchived(archivedHashes)
+					}
+
 					// Print the scan results
 					scanner.PrintSummary()
+					if archivedHashes != nil {
+						scanner.PrintArchived()
+					}
 
-					// Handle duplicates if requested
-					if c.Bool("remove-duplicates") || c.Bool("interactive-duplicates") || c.Bool("pattern-duplicates") || c.String("move-duplicates") != "" {
-						duplicateHandler := NewDuplicateHandler(scanner, dryRun)
-						
-						if c.Bool("interactive-duplicates") {
-							fmt.Println("\n🔄 Starting interactive duplicate removal...")
-							err := duplicateHandler.RemoveDuplicatesInteractive()
+					// Compare the folder with the last run, and remember it for the next one
+					if c.Bool("compare-last") || c.Bool("record-stats") {
+						snapshot := TakeSnapshot(scanner, runStart)
+						statsPath, err := getStatsPath()
+						if err == nil && c.Bool("compare-last") {
+							var stats *LifetimeStats
+							if stats, err = LoadStats(statsPath); err == nil {
+								if previous, ok := stats.LastSnapshot(downloadsPath); ok {
+									PrintCategoryChanges(CompareSnapshots(previous, snapshot), previous.TakenAt)
+								} else {
+									infoColor.Printf("\n📈 No earlier run of this folder to compare with yet\n")
+								}
+							}
+						}
+						if err == nil && !dryRun && scanner.Since.IsZero() && scanner.TrialLimit == 0 && !scanner.DeadlineReached {
+							err = RecordSnapshot(statsPath, downloadsPath, snapshot)
+						}
+						if err != nil {
+							warningColor.Printf("⚠️  Could not compare with the last run or save this one: %v\n", err)
+						}
+					}
+					if c.Bool("find-name-variants") {
+						PrintNameVariants(scanner.FindNameVariants(c.Bool("ignore-case")))
+					}
+					if nameSize {
+						nameSizeGroups := scanner.FindNameSizeDuplicates()
+						PrintNameSizeDuplicates(nameSizeGroups)
+						if c.Bool("verify-content") {
+							scanner.VerifyNameSizeDuplicates(nameSizeGroups)
+						}
+					}
+					var dirGroups []DuplicateDirectory
+					if c.Bool("find-duplicate-dirs") || c.Bool("remove-duplicate-dirs") {
+						dirGroups = scanner.FindDuplicateDirectories(downloadsPath)
+						PrintDuplicateDirectories(dirGroups)
+					}
+
+					// An audit only reports on duplicates, so stop before anything is changed
+					if audit {
+						auditor := NewDuplicateHandler(scanner, true)
+						auditor.PerDirectory = c.Bool("dedupe-per-directory")
+						auditor.MinGroupSize = c.Int("min-duplicates")
+						auditor.SkipSymlinks = c.Bool("skip-symlink-duplicates")
+						reportBase := c.String("relative-to")
+						if reportBase == "" {
+							reportBase = downloadsPath
+						}
+						reportBase, err := filepath.Abs(reportBase)
+						if err != nil {
+							errorColor.Printf("❌ Invalid --relative-to: %v\n", err)
+							return err
+						}
+						report := auditor.Audit().RelativeTo(reportBase)
+						if c.Bool("json") {
+							return report.WriteJSON(reportOutput)
+						}
+						report.Print()
+						return nil
+					}
+
+					// Clean up messy file names before anything else touches the files
+					if c.Bool("normalize-names") {
+						fmt.Println("\n✏️  Starting file name normalization...")
+						renamer := NewFileOrganizer(scanner, dryRun, downloadsPath)
+						renamer.DestExistsStrategy = destExistsStrategy
+						renamer.IdenticalDestStrategy = identicalDestStrategy
+						renamer.Approver = approver
+						renamer.Deadline = deadline
+						renamer.Plan = plan
+						if identicalDestStrategy == IdenticalDestRemove && c.Bool("quarantine") {
+							quarantine, err := OpenQuarantine(downloadsPath)
 							if err != nil {
-								errorColor.Printf("❌ Error during interactive duplicate removal: %v\n", err)
+								errorColor.Printf("❌ %v\n", err)
 								return err
 							}
-						} else if c.Bool("pattern-duplicates") {
-							fmt.Println("\n🔄 Starting pattern-based duplicate removal...")
-							err := duplicateHandler.RemoveDuplicatesByPattern()
+							renamer.Quarantine = quarantine
+						}
+						err := renamer.NormalizeNames(c.String("name-separator"))
+						if err != nil {
+							errorColor.Printf("❌ Error during file name normalization: %v\n", err)
+							return err
+						}
+					}
+
+					// Track totals for the local lifetime stats
+					var run RunStats
+
+					// Removed duplicates and duplicate folders are shredded or
+					// quarantined alike, sharing one quarantine manifest
+					shredPasses := 0
+					var duplicateQuarantine *Quarantine
+					if dedupe || removeExtracted || c.Bool("remove-duplicate-dirs") {
+						if c.Bool("shred") {
+							if reason := shredIneffective(downloadsPath); reason != "" {
+								warningColor.Printf("⚠️  Not shredding: %s\n", reason)
+								return fmt.Errorf("refusing to shred: %s", reason)
+							}
+							if c.Int("shred-passes") < 1 {
+								errorColor.Printf("❌ --shred-passes must be at least 1\n")
+								return fmt.Errorf("invalid shred-passes: %d", c.Int("shred-passes"))
+							}
+							shredPasses = c.Int("shred-passes")
+						}
+						if c.Bool("quarantine") {
+							quarantine, err := OpenQuarantine(downloadsPath)
 							if err != nil {
-								errorColor.Printf("❌ Error during pattern-based duplicate removal: %v\n", err)
+								errorColor.Printf("❌ %v\n", err)
 								return err
 							}
-						} else if moveFolder := c.String("move-duplicates"); moveFolder != "" {
-							// Validate move folder path
-							if err := validatePath(moveFolder); err != nil {
-								errorColor.Printf("❌ Invalid move folder path: %v\n", err)
-								return err
+							duplicateQuarantine = quarantine
+							infoColor.Printf("📥 Removed duplicates will be kept in %s\n", quarantine.Dir)
+						}
+					}
+
+					// Whole duplicate folders go first, each one only after confirmation
+					if c.Bool("remove-duplicate-dirs") && len(dirGroups) > 0 {
+						fmt.Println("\n🔄 Removing duplicate folders...")
+						dirHandler := NewDuplicateHandler(scanner, dryRun)
+						dirHandler.Throttle = scanner.Throttle
+						dirHandler.Approver = approver
+						dirHandler.ShredPasses = shredPasses
+						dirHandler.Quarantine = duplicateQuarantine
+						dirHandler.Plan = plan
+						dirHandler.Deadline = deadline
+						if err := dirHandler.RemoveDuplicateDirectories(dirGroups); err != nil {
+							errorColor.Printf("❌ Error removing duplicate folders: %v\n", err)
+							return err
+						}
+						run.DuplicatesRemoved += dirHandler.TotalRemoved
+						run.BytesReclaimed += dirHandler.TotalSpaceSaved
+					}
+
+					// Report on and count the duplicates a handler removed, once its
+					// removals have really happened
+					finishDuplicates := func(duplicateHandler *DuplicateHandler) error {
+						if plainRemove {
+							if c.Bool("json") {
+								if err := duplicateHandler.DeletionReport().WriteJSON(reportOutput); err != nil {
+									return err
+								}
+							} else {
+								duplicateHandler.DeletionReport().Print()
 							}
-							fmt.Printf("\n🔄 Moving duplicates to: %s\n", moveFolder)
-							err := duplicateHandler.MoveDuplicatesToFolder(moveFolder)
-							if err != nil {
-								errorColor.Printf("❌ Error moving duplicates: %v\n", err)
+						}
+						if duplicateHandler.ReadOnlySkipped > 0 {
+							warningColor.Printf("🔒 Left %d read-only duplicates in place (use --force-delete-readonly to remove them)\n", duplicateHandler.ReadOnlySkipped)
+						}
+						run.DuplicatesRemoved += duplicateHandler.TotalRemoved
+						run.BytesReclaimed += duplicateHandler.TotalSpaceSaved
+						return nil
+					}
+					var deferredDeletions *DuplicateHandler
+
+					// Handle duplicates if requested
+					if dedupe || removeExtracted {
+						duplicateHandler := NewDuplicateHandler(scanner, dryRun)
+						duplicateHandler.Throttle = scanner.Throttle
+						config.ApplyToDuplicateHandler(duplicateHandler)
+						duplicateHandler.PerDirectory = c.Bool("dedupe-per-directory")
+						duplicateHandler.Approver = approver
+						duplicateHandler.Deadline = deadline
+						duplicateHandler.Plan = plan
+						duplicateHandler.MinGroupSize = c.Int("min-duplicates")
+						duplicateHandler.ForceDeleteReadOnly = c.Bool("force-delete-readonly")
+						duplicateHandler.RestoreNames = c.Bool("restore-names")
+						duplicateHandler.GroupOutput = c.Bool("group-duplicates-output")
+						duplicateHandler.PreserveXattrs = c.Bool("preserve-xattrs")
+						duplicateHandler.SkipSymlinks = c.Bool("skip-symlink-duplicates")
+						if deferDeletions && !dryRun {
+							duplicateHandler.DeferDeletions = true
+							deferredDeletions = duplicateHandler
+							infoColor.Printf("⏳ Deletions will wait until every move has succeeded\n")
+
+							// Any error from here on means not every move succeeded
+							defer func() {
+								if n := duplicateHandler.DiscardDeletions(); n > 0 {
+									warningColor.Printf("⚠️  Left %d files in place that were to be removed, because the run stopped before every move succeeded\n", n)
+								}
+							}()
+						}
+						duplicateHandler.ShredPasses = shredPasses
+						duplicateHandler.Quarantine = duplicateQuarantine
+						
+						if dedupe {
+							if scriptPath != "" {
+								// Pick the copies to keep the way the chosen removal option would
+								var resolver DuplicateResolver
+								if c.Bool("pattern-duplicates") && duplicateHandler.Resolver == nil {
+									resolver = PatternResolver{Weights: duplicateHandler.Weights}
+								}
+								count, err := duplicateHandler.EmitDeletionScript(scriptPath, resolver)
+								if err != nil {
+									errorColor.Printf("❌ %v\n", err)
+									return err
+								}
+								successColor.Printf("📝 Wrote %d delete commands to %s; nothing was deleted\n", count, scriptPath)
+							} else if c.Bool("interactive-duplicates") {
+								fmt.Println("\n🔄 Starting interactive duplicate removal...")
+								err := duplicateHandler.RemoveDuplicatesInteractive()
+								if err != nil {
+									errorColor.Printf("❌ Error during interactive duplicate removal: %v\n", err)
+									return err
+								}
+							} else if c.Bool("pattern-duplicates") {
+								fmt.Println("\n🔄 Starting pattern-based duplicate removal...")
+								err := duplicateHandler.RemoveDuplicatesByPattern()
+								if err != nil {
+									errorColor.Printf("❌ Error during pattern-based duplicate removal: %v\n", err)
+									return err
+								}
+							} else if moveFolder := c.String("move-duplicates"); moveFolder != "" {
+								fmt.Printf("\n🔄 Moving duplicates to: %s\n", moveFolder)
+								err := duplicateHandler.MoveDuplicatesToFolder(moveFolder)
+								if err != nil {
+									errorColor.Printf("❌ Error moving duplicates: %v\n", err)
+									return err
+								}
+							} else {
+								fmt.Println("\n🔄 Starting automatic duplicate removal...")
+								err := duplicateHandler.RemoveDuplicates()
+								if err != nil {
+									errorColor.Printf("❌ Error removing duplicates: %v\n", err)
+									return err
+								}
+							}
+						}
+
+						// Loose files already stored in a zip go after the duplicates,
+						// so a copy that was just removed isn't counted twice
+						if removeExtracted {
+							fmt.Println("\n🔄 Looking for loose files that are also stored in a zip...")
+							if err := duplicateHandler.RemoveExtractedCopies(scanner.FindExtractedCopies()); err != nil {
+								errorColor.Printf("❌ Error removing extracted files: %v\n", err)
 								return err
 							}
-						} else {
-							fmt.Println("\n🔄 Starting automatic duplicate removal...")
-							err := duplicateHandler.RemoveDuplicates()
-							if err != nil {
-								errorColor.Printf("❌ Error removing duplicates: %v\n", err)
+						}
+
+						if deferredDeletions == nil {
+							if err := finishDuplicates(duplicateHandler); err != nil {
 								return err
 							}
 						}
 					}
 
 					// Handle file organization if requested
-					if c.Bool("organize") || c.Bool("organize-by-date") || c.Bool("organize-by-size") || c.Bool("process-zips") {
-						organizer := NewFileOrganizer(scanner, dryRun, downloadsPath)
+					if organize {
+						organizer := NewFileOrganizer(scanner, dryRun, destPath)
+						organizer.Throttle = scanner.Throttle
+						organizer.PreserveXattrs = c.Bool("preserve-xattrs")
+						config.ApplyToOrganizer(organizer)
+						organizer.DateSources = dateSources
+						organizer.DestExistsStrategy = destExistsStrategy
+						organizer.IdenticalDestStrategy = identicalDestStrategy
+						organizer.Approver = approver
+						organizer.Deadline = deadline
+						organizer.Plan = plan
+						for _, hook := range []struct {
+							flag   string
+							target **MoveHook
+						}{{"pre-move-cmd", &organizer.PreMoveHook}, {"post-move-cmd", &organizer.PostMoveHook}} {
+							if c.String(hook.flag) == "" {
+								continue
+							}
+							parsed, err := ParseMoveHook(c.String(hook.flag))
+							if err != nil {
+								errorColor.Printf("❌ Invalid --%s: %v\n", hook.flag, err)
+								return err
+							}
+							*hook.target = parsed
+						}
+						organizer.PackSmallest = c.Bool("pack-tiny")
+						organizer.ShardByHash = c.Bool("shard-by-hash")
+						if c.Int("max-per-folder") < 0 {
+							errorColor.Printf("❌ --max-per-folder can't be negative\n")
+							return fmt.Errorf("invalid max-per-folder: %d", c.Int("max-per-folder"))
+						}
+						organizer.MaxPerFolder = c.Int("max-per-folder")
+						organizer.MaxPackSize = c.Int64("pack-max-size") * 1024 * 1024
+						if c.String("max-bytes") != "" {
+							maxBytes, err := parseByteSize(c.String("max-bytes"))
+							if err != nil || maxBytes == 0 {
+								errorColor.Printf("❌ Invalid --max-bytes %q (use a size like 500MB or 2GB)\n", c.String("max-bytes"))
+								return fmt.Errorf("invalid max-bytes: %s", c.String("max-bytes"))
+							}
+							organizer.MaxBytes = maxBytes
+						}
+						if c.Int("settle-seconds") < 0 {
+							errorColor.Printf("❌ --settle-seconds can't be negative\n")
+							return fmt.Errorf("invalid settle-seconds: %d", c.Int("settle-seconds"))
+						}
+						// A fixture checked out moments ago in CI isn't a download still being written
+						if !assertClean || c.IsSet("settle-seconds") {
+							organizer.SettleTime = time.Duration(c.Int("settle-seconds")) * time.Second
+						}
+						if (organizer.PackSmallest || identicalDestStrategy == IdenticalDestRemove) && c.Bool("quarantine") {
+							quarantine, err := OpenQuarantine(downloadsPath)
+							if err != nil {
+								errorColor.Printf("❌ %v\n", err)
+								return err
+							}
+							organizer.Quarantine = quarantine
+							infoColor.Printf("📥 Packed and removed files will be kept in %s\n", quarantine.Dir)
+						}
+						onlyCategories, err := organizer.parseCategories(c.StringSlice("only-categories"))
+						if err != nil {
+							errorColor.Printf("❌ Invalid --only-categories: %v\n", err)
+							return err
+						}
+						skipCategories, err := organizer.parseCategories(c.StringSlice("skip-categories"))
+						if err != nil {
+							errorColor.Printf("❌ Invalid --skip-categories: %v\n", err)
+							return err
+						}
+						organizer.OnlyCategories = onlyCategories
+						organizer.SkipCategories = skipCategories
+						if previewCategory != "" {
+							if err := organizer.PreviewCategory(previewCategory); err != nil {
+								errorColor.Printf("❌ Invalid --preview-category: %v\n", err)
+								return err
+							}
+							infoColor.Printf("🔍 Previewing only %s\n", previewCategory)
+						}
+						organizer.MaxZipSize = c.Int64("max-zip-size") * 1024 * 1024
+						organizer.MaxZipEntries = c.Int("max-zip-entries")
+						if c.Int("zip-workers") < 1 {
+							errorColor.Printf("❌ --zip-workers must be at least 1\n")
+							return fmt.Errorf("invalid zip-workers: %d", c.Int("zip-workers"))
+						}
+						organizer.ZipWorkers = c.Int("zip-workers")
+						if depth := c.Int("nested-archive-depth"); depth < 0 || depth > maxNestedArchiveDepth {
+							errorColor.Printf("❌ --nested-archive-depth must be between 0 and %d\n", maxNestedArchiveDepth)
+							return fmt.Errorf("invalid nested-archive-depth: %d", depth)
+						}
+						organizer.NestedArchiveDepth = c.Int("nested-archive-depth")
+						organizer.AllowLargeZips = c.Bool("allow-large-zips")
+						if organizer.AllowLargeZips {
+							warningColor.Printf("⚠️  Zip bomb protection is DISABLED - only use --allow-large-zips with zips you trust\n")
+						} else if organizer.MaxZipSize != defaultMaxZipSize || organizer.MaxZipEntries != defaultMaxZipEntries {
+							warningColor.Printf("⚠️  Zip bomb protection is relaxed (max size: %d MB, max entries: %d, 0 means no limit)\n", c.Int64("max-zip-size"), organizer.MaxZipEntries)
+						}
 						
 						if c.Bool("organize-by-date") {
 							fmt.Println("\n📅 Starting date-based organization...")
@@ -231,14 +1064,84 @@ func main() {
 								errorColor.Printf("❌ Error during size-based organization: %v\n", err)
 								return err
 							}
-						} else if c.Bool("process-zips") {
-							fmt.Println("\n📦 Starting zip file processing...")
-							err := organizer.ProcessZipFiles()
+						} else if c.Bool("organize-by-tag") {
+							fmt.Println("\n🏷️  Starting tag-based organization...")
+							err := organizer.OrganizeByTag()
 							if err != nil {
-								errorColor.Printf("❌ Error during zip file processing: %v\n", err)
+								errorColor.Printf("❌ Error during tag-based organization: %v\n", err)
 								return err
 							}
-						} else {
+						} else if c.Bool("group-by-source-app") {
+							fmt.Println("\n🌐 Starting source-based organization...")
+							err := organizer.OrganizeBySource()
+							if err != nil {
+								errorColor.Printf("❌ Error during source-based organization: %v\n", err)
+								return err
+							}
+						} else if mode := c.String("organize-images-by"); mode != "" {
+							fmt.Println("\n🖼️  Starting image organization...")
+							err := organizer.OrganizeImagesBy(mode)
+							if err != nil {
+								errorColor.Printf("❌ Error during image organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("organize-media-by-duration") {
+							fmt.Println("\n⏱️  Starting media organization...")
+							err := organizer.OrganizeByDuration(durationTiers)
+							if err != nil {
+								errorColor.Printf("❌ Error during media organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("organize-by-mime") {
+							fmt.Println("\n🧾 Starting MIME-based organization...")
+							err := organizer.OrganizeByMime(c.Bool("mime-sniff"))
+							if err != nil {
+								errorColor.Printf("❌ Error during MIME-based organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("organize-by-language") {
+							fmt.Println("\n🌍 Starting language-based organization...")
+							err := organizer.OrganizeByLanguage(newKeywordDetector())
+							if err != nil {
+								errorColor.Printf("❌ Error during language-based organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("organize-by-session") {
+							err := organizer.OrganizeBySession(sessionGap, c.Bool("session-misc"))
+							if err != nil {
+								errorColor.Printf("❌ Error during session-based organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("organize-by-access") {
+							if c.Int("active-days") < 1 {
+								errorColor.Printf("❌ --active-days must be at least 1\n")
+								return fmt.Errorf("invalid active-days: %d", c.Int("active-days"))
+							}
+							err := organizer.OrganizeByAccess(time.Duration(c.Int("active-days")) * 24 * time.Hour)
+							if err != nil {
+								errorColor.Printf("❌ Error during access-based organization: %v\n", err)
+								return err
+							}
+						} else if moveMap != nil {
+							err := organizer.OrganizeByMap(moveMap, c.Bool("map-only"))
+							if err != nil {
+								errorColor.Printf("❌ Error during map-based organization: %v\n", err)
+								return err
+							}
+						} else if c.Int("rare-threshold") > 0 {
+							err := organizer.OrganizeByRarity(c.Int("rare-threshold"))
+							if err != nil {
+								errorColor.Printf("❌ Error during rarity-based organization: %v\n", err)
+								return err
+							}
+						} else if c.Bool("process-zips") {
+							fmt.Println("\n📦 Starting zip file processing...")
+							err := organizer.ProcessZipFiles()
+							if err != nil {
+								errorColor.Printf("❌ Error during zip file processing: %v\n", err)
+								return err
+							}
+						} else {
 							fmt.Println("\n📁 Starting file organization by category...")
 							err := organizer.OrganizeFiles()
 							if err != nil {
@@ -246,6 +1149,82 @@ func main() {
 								return err
 							}
 						}
+
+						run.FilesOrganized = organizer.TotalMoved
+						if c.Bool("index") && !dryRun {
+							indexPath, err := getIndexPath()
+							if err == nil {
+								err = RecordMoves(indexPath, organizer)
+							}
+							if err != nil {
+								warningColor.Printf("⚠️  Could not update the file index: %v\n", err)
+							}
+						}
+						if c.Bool("post-verify") && !dryRun {
+							fmt.Println("\n🔬 Re-reading moved files to verify them...")
+							failures, checked := organizer.PostVerify()
+							PrintVerifyFailures(failures, checked)
+							if len(failures) > 0 {
+								return fmt.Errorf("%d moved files failed verification", len(failures))
+							}
+						}
+					}
+
+					// Every move has succeeded, so the held back deletions can go ahead
+					if deferredDeletions != nil {
+						fmt.Println()
+						deferredDeletions.FlushDeletions()
+						if err := finishDuplicates(deferredDeletions); err != nil {
+							return err
+						}
+					}
+
+					approver.PrintSummary()
+
+					if previousPlan != nil {
+						fmt.Println()
+						DiffPlans(previousPlan, plan).Print(os.Stdout)
+					}
+					if planPath := c.String("save-plan"); planPath != "" {
+						if err := plan.Save(planPath); err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						infoColor.Printf("📋 Saved the plan to %s\n", planPath)
+					}
+					if assertClean {
+						if err := plan.AssertEmpty(os.Stdout); err != nil {
+							return err
+						}
+					}
+
+					// Remember where the next incremental run should start
+					// A trial run or one cut short leaves files unseen, so it mustn't move the mark past them
+					if c.Bool("incremental") && !dryRun && scanner.TrialLimit == 0 && !scanner.DeadlineReached {
+						marksPath, err := getHighWaterMarksPath()
+						if err == nil {
+							err = RecordHighWaterMark(marksPath, downloadsPath, runStart)
+						}
+						if err != nil {
+							warningColor.Printf("⚠️  Could not save the high-water mark: %v\n", err)
+						}
+					}
+
+					deadline.Report()
+
+					// Update the local lifetime stats if requested
+					if c.Bool("record-stats") && !dryRun {
+						statsPath, err := getStatsPath()
+						if err == nil {
+							err = RecordRun(statsPath, run)
+						}
+						if err != nil {
+							warningColor.Printf("⚠️  Could not update lifetime stats: %v\n", err)
+						}
+					}
+
+					if c.Bool("notify") {
+						notifyRunComplete(desktopNotifier{}, run, dryRun)
 					}
 
 					successColor.Printf("✨ All done! Your downloads folder is now organized.\n")
@@ -282,31 +1261,772 @@ func main() {
 						Aliases: []string{"m"},
 						Usage:   "Move duplicate files to specified folder instead of deleting",
 					},
+					&cli.BoolFlag{
+						Name:  "restore-names",
+						Usage: "With --move-duplicates, strip copy markers like \"(1)\" from the moved files' names when the plain name is free in the folder",
+					},
+					&cli.StringFlag{
+						Name:  "emit-script",
+						Usage: "Instead of removing duplicates, write a shell script (or a .bat file on Windows) with a delete command for each one, to review and run yourself",
+					},
+					&cli.BoolFlag{
+						Name:  "defer-deletions",
+						Usage: "Hold back every deletion until all moves have succeeded, and skip them if organizing fails",
+					},
+					&cli.BoolFlag{
+						Name:  "remove-extracted",
+						Usage: "Remove loose files whose content is also stored in a zip in the same folder, keeping the zip (this reads the zips' contents, so it can be slow)",
+					},
+					&cli.BoolFlag{
+						Name:  "group-duplicates-output",
+						Usage: "With --move-duplicates, move each group of duplicates into its own numbered subfolder, with a KEPT.txt naming the copy that was kept",
+					},
+					&cli.BoolFlag{
+						Name:  "dedupe-by-name-size",
+						Usage: "Quickly find probable duplicates by name and size alone, without hashing; they are only reported unless --verify-content is given",
+					},
+					&cli.BoolFlag{
+						Name:  "verify-content",
+						Usage: "With --dedupe-by-name-size, hash the probable duplicates and remove the copies whose content matches (or move them with --move-duplicates, etc.)",
+					},
+					&cli.BoolFlag{
+						Name:  "dedupe-per-directory",
+						Usage: "Keep one copy of each duplicate in every directory that has it, only removing copies within the same directory",
+					},
+					&cli.StringFlag{
+						Name:  "remote-manifest",
+						Usage: "Fetch an index written by --index from this URL and leave alone any file whose hash it lists, as already archived elsewhere",
+					},
+					&cli.BoolFlag{
+						Name:  "audit-duplicates",
+						Usage: "Report how much space duplicates waste in each folder, without moving or deleting anything",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the --audit-duplicates report, or the record of what --remove-duplicates removed, as JSON on stdout (other messages go to stderr)",
+					},
+					&cli.StringFlag{
+						Name:  "relative-to",
+						Usage: "Show paths in the --audit-duplicates report relative to this folder (default: the scanned folder); paths outside it stay absolute",
+					},
+					&cli.IntFlag{
+						Name:  "min-duplicates",
+						Value: 2,
+						Usage: "Only act on files with at least this many identical copies; smaller groups are reported but left alone",
+					},
+					&cli.BoolFlag{
+						Name:  "find-name-variants",
+						Usage: "Report files whose names differ only by URL encoding or whitespace, like \"my file.pdf\" and \"my%20file.pdf\", even if their content differs",
+					},
+					&cli.BoolFlag{
+						Name:  "ignore-case",
+						Usage: "With --find-name-variants, also treat names that differ only by case as variants",
+					},
+					&cli.BoolFlag{
+						Name:  "find-partial-duplicates",
+						Usage: "Also report large files that share most of their content, such as partial re-downloads (never removed automatically)",
+					},
+					&cli.Float64Flag{
+						Name:  "partial-threshold",
+						Value: defaultPartialThreshold,
+						Usage: "Fraction of shared content that makes two files partial duplicates",
+					},
+					&cli.BoolFlag{
+						Name:  "compare-archive-contents",
+						Usage: "Treat zip files holding the same files as duplicates, even if they were zipped separately (slower, as every zip is unpacked)",
+					},
+					&cli.BoolFlag{
+						Name:  "quarantine",
+						Usage: "Move removed duplicates, files packed by --pack-tiny and files removed by --identical-dest-strategy remove into a .elf-trash folder inside the scanned folder instead of deleting them",
+					},
+					&cli.BoolFlag{
+						Name:  "empty-quarantine",
+						Usage: "Permanently delete everything in the .elf-trash folder, then exit",
+					},
+					&cli.BoolFlag{
+						Name:  "shred",
+						Usage: "Overwrite removed duplicates before deleting them, for sensitive files (refused on copy-on-write filesystems and SSDs)",
+					},
+					&cli.IntFlag{
+						Name:  "shred-passes",
+						Value: defaultShredPasses,
+						Usage: "Number of times --shred overwrites each file",
+					},
 					&cli.BoolFlag{
 						Name:    "organize",
 						Aliases: []string{"o"},
 						Usage:   "Organize files into category folders (Images, Documents, etc.)",
 					},
+					&cli.StringFlag{
+						Name:  "preview-category",
+						Usage: "Dry-run organizing a single category, like \"Videos\", and show just its planned moves",
+					},
+					&cli.StringSliceFlag{
+						Name:  "only-categories",
+						Usage: "Only organize files in these categories, e.g. Images,Videos",
+					},
+					&cli.StringSliceFlag{
+						Name:  "skip-categories",
+						Usage: "Leave files in these categories where they are, e.g. Documents",
+					},
+					&cli.BoolFlag{
+						Name:  "screenshots",
+						Usage: "Put screenshots in their own Screenshots category instead of Images",
+					},
+					&cli.StringSliceFlag{
+						Name:  "include",
+						Usage: "Only scan files whose names match this pattern, like \"*.pdf\" (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "Don't scan files whose names match this pattern (repeatable). Wins over --include unless --include-wins is set",
+					},
+					&cli.BoolFlag{
+						Name:  "category-from-parent",
+						Usage: "Use the name of the top-level folder a file is in as its category, for files in subfolders, instead of its extension",
+					},
+					&cli.BoolFlag{
+						Name:  "include-wins",
+						Usage: "Scan files that match both --include and --exclude, to carve exceptions out of a broad exclude; other files are scanned as usual",
+					},
+					&cli.StringSliceFlag{
+						Name:  "screenshot-pattern",
+						Usage: "Filename pattern that marks an image as a screenshot, like \"Screenshot*\" (repeatable, implies --screenshots)",
+					},
+					&cli.BoolFlag{
+						Name:  "split-installers",
+						Usage: "Put .dmg and .pkg installers in an Installers category, keeping Disk Images for .iso and .img",
+					},
 					&cli.BoolFlag{
 						Name:    "organize-by-date",
 						Aliases: []string{"od"},
 						Usage:   "Organize files into date-based folders (YYYY-MM format)",
 					},
+					&cli.StringSliceFlag{
+						Name:  "date-source",
+						Usage: "Where --organize-by-date takes dates from, tried in order: exif, birth, downloaded (macOS), mtime (like --date-source=exif,birth,mtime)",
+					},
 					&cli.BoolFlag{
 						Name:    "organize-by-size",
 						Aliases: []string{"os"},
 						Usage:   "Organize files into size-based folders (Tiny, Small, Medium, Large, Huge)",
 					},
+					&cli.BoolFlag{
+						Name:  "organize-by-tag",
+						Usage: "Organize files into folders named after their primary Finder tag (macOS only)",
+					},
+					&cli.BoolFlag{
+						Name:  "group-by-source-app",
+						Usage: "Organize files into folders named after the website they were downloaded from (macOS only)",
+					},
+					&cli.IntFlag{
+						Name:  "max-per-folder",
+						Usage: "Split a category with more files than this into numbered subfolders like Images/001 and Images/002 (0 for no limit)",
+					},
+					&cli.StringFlag{
+						Name:  "max-bytes",
+						Usage: "Stop organizing once this much data has been moved, like 500MB or 2GB; the rest is listed and left for the next run",
+					},
+					&cli.StringFlag{
+						Name:  "max-runtime",
+						Usage: "Stop starting new moves and removals after this long, like 5m or 1h; whatever is under way finishes and the rest is listed and left for the next run",
+					},
+					&cli.IntFlag{
+						Name:  "settle-seconds",
+						Value: 5,
+						Usage: "Leave files modified in the last this many seconds where they are, as they may still be downloading; 0 to move them anyway",
+					},
+					&cli.BoolFlag{
+						Name:  "shard-by-hash",
+						Usage: "Organize by category into <category>/<xx>/ subfolders, where xx is the first two hex characters of the file's hash, to avoid huge folders",
+					},
+					&cli.BoolFlag{
+						Name:  "organize-by-mime",
+						Usage: "Organize files into folders named after their top-level MIME type (image, audio, video, text, application)",
+					},
+					&cli.BoolFlag{
+						Name:  "mime-sniff",
+						Usage: "With --organize-by-mime, look at the content of files whose extension doesn't give a MIME type",
+					},
+					&cli.BoolFlag{
+						Name:  "organize-by-language",
+						Usage: "Sort text documents and source code into Documents/<language> by looking at their content (slower, and a best guess)",
+					},
+					&cli.BoolFlag{
+						Name:  "organize-by-session",
+						Usage: "Experimental: move files downloaded close together in time into numbered session folders",
+					},
+					&cli.StringFlag{
+						Name:  "session-gap",
+						Value: "10m",
+						Usage: "With --organize-by-session, the longest pause between downloads of the same session",
+					},
+					&cli.BoolFlag{
+						Name:  "session-misc",
+						Usage: "With --organize-by-session, move files downloaded on their own into Misc instead of leaving them in place",
+					},
+					&cli.BoolFlag{
+						Name:  "pack-tiny",
+						Usage: "With --organize-by-size, pack the smallest files into dated zip archives (like tiny-2024-03-01.zip) and remove the originals instead of moving them into a folder",
+					},
+					&cli.Int64Flag{
+						Name:  "pack-max-size",
+						Value: defaultMaxPackSize / 1024 / 1024,
+						Usage: "With --pack-tiny, the most file data to put in one archive, in MB; more files start a new archive",
+					},
+					&cli.BoolFlag{
+						Name:  "organize-by-access",
+						Usage: "Move files read recently into Active and the rest into Stale, by their last access time (which many systems don't keep up to date)",
+					},
+					&cli.IntFlag{
+						Name:  "active-days",
+						Value: defaultActiveDays,
+						Usage: "With --organize-by-access, files read within this many days count as active",
+					},
+					&cli.StringFlag{
+						Name:  "dest",
+						Usage: "Organize files into folders under this folder instead of the one being cleaned; if it is inside that folder, it is left out of the scan",
+					},
+					&cli.StringFlag{
+						Name:  "map-file",
+						Usage: "Move the files named in this CSV file (filename,destination rows) into their destination folder, relative to the organized folder; other files are organized by category",
+					},
+					&cli.BoolFlag{
+						Name:  "map-only",
+						Usage: "With --map-file, leave files that aren't in the map where they are",
+					},
+					&cli.IntFlag{
+						Name:  "rare-threshold",
+						Usage: "Organize by category, but move files whose extension appears this many times or fewer into Misc instead of a folder of their own",
+					},
+					&cli.StringFlag{
+						Name:  "organize-images-by",
+						Usage: "Sort images into subfolders of Images by orientation (Portrait/Landscape/Square) or resolution (Small/Medium/Large/Very Large)",
+					},
+					&cli.BoolFlag{
+						Name:  "organize-media-by-duration",
+						Usage: "Sort music and videos into Short, Medium and Long subfolders by how long they play",
+					},
+					&cli.StringFlag{
+						Name:  "duration-tiers",
+						Value: defaultDurationTiers,
+						Usage: "Longest Short and longest Medium file for --organize-media-by-duration",
+					},
+					&cli.StringFlag{
+						Name:  "pre-move-cmd",
+						Usage: "Command to run before each move, like \"clamscan --no-summary {}\"; a non-zero exit leaves the file where it is. {} is the file, {src} and {dst} where it moves from and to",
+					},
+					&cli.StringFlag{
+						Name:  "post-move-cmd",
+						Usage: "Command to run after each move, with the same placeholders as --pre-move-cmd ({} is the file's new path)",
+					},
 					&cli.BoolFlag{
 						Name:    "process-zips",
 						Aliases: []string{"z"},
 						Usage:   "Analyze zip file contents and move them to appropriate category folders",
 					},
+					&cli.BoolFlag{
+						Name:  "normalize-names",
+						Usage: "Clean up messy file names (URL escapes, spaces, query strings, doubled extensions)",
+					},
+					&cli.StringFlag{
+						Name:  "name-separator",
+						Value: "_",
+						Usage: "Separator used in place of spaces by --normalize-names",
+					},
+					&cli.StringFlag{
+						Name:  "dest-exists-strategy",
+						Value: DestExistsSkip,
+						Usage: "What to do when a file already exists at the destination: skip, or merge (rename on conflict, skip identical files)",
+					},
+					&cli.StringFlag{
+						Name:  "identical-dest-strategy",
+						Value: IdenticalDestSkip,
+						Usage: "What to do with a file when its destination already holds the same content: skip, or remove it as a duplicate",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run-interactive",
+						Usage: "Show each planned move or deletion and ask whether to do it: y(es), n(o) or a(ll remaining)",
+					},
+					&cli.StringFlag{
+						Name:  "save-plan",
+						Usage: "With --dry-run, save the planned changes to a JSON file",
+					},
+					&cli.BoolFlag{
+						Name:  "assert-clean",
+						Usage: "Dry run that exits with an error, listing the changes, if anything would be moved, renamed or removed (for CI)",
+					},
+					&cli.BoolFlag{
+						Name:  "preview-json",
+						Usage: "Print the plan of a dry run as JSON before running, then the outcome of each change (only the plan with --dry-run)",
+					},
+					&cli.StringFlag{
+						Name:  "diff-plan",
+						Usage: "With --dry-run, show how the planned changes differ from a plan saved with --save-plan",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "Explain why each file was put in its category",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "Only process files modified at or after this time, like 2024-03-01 or \"2024-03-01 09:30\"",
+					},
+					&cli.BoolFlag{
+						Name:  "incremental",
+						Usage: "Only process files modified since the last --incremental run of this folder, and remember this run for the next one",
+					},
+					&cli.BoolFlag{
+						Name:  "record-stats",
+						Usage: "Add this run's totals to the local lifetime stats file (nothing leaves your machine)",
+					},
+					&cli.BoolFlag{
+						Name:  "compare-last",
+						Usage: "Show how many files each category gained or lost since the last run of this folder",
+					},
+					&cli.BoolFlag{
+						Name:  "post-verify",
+						Usage: "After organizing, re-read every moved file and check it still matches its hash from before the move",
+					},
+					&cli.BoolFlag{
+						Name:  "index",
+						Usage: "Record where each organized file went in a local index, searchable with the find command",
+					},
+					&cli.BoolFlag{
+						Name:  "notify",
+						Usage: "Show a desktop notification with the totals when the run finishes (macOS, and Linux with notify-send)",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-large-zips",
+						Usage: "Disable zip bomb protection for trusted zip files",
+					},
+					&cli.Int64Flag{
+						Name:  "max-zip-size",
+						Value: defaultMaxZipSize / 1024 / 1024,
+						Usage: "Largest zip file to process, in MB (0 for no limit)",
+					},
+					&cli.IntFlag{
+						Name:  "zip-workers",
+						Value: defaultZipWorkers,
+						Usage: "How many zip files --process-zips inspects at the same time",
+					},
+					&cli.IntFlag{
+						Name:  "nested-archive-depth",
+						Usage: "How many levels of zips and tarballs inside zip files --process-zips looks into to pick their folder",
+					},
+					&cli.IntFlag{
+						Name:  "max-zip-entries",
+						Value: defaultMaxZipEntries,
+						Usage: "Most entries a zip file may have to be processed (0 for no limit)",
+					},
+					&cli.StringFlag{
+						Name:  "throttle",
+						Usage: "Read and copy files at most this fast, like 20MB or 500KB/s, to keep the machine responsive during background runs",
+					},
+					&cli.StringFlag{
+						Name:  "hash-block-size",
+						Value: "32KB",
+						Usage: "Read buffer size used when hashing files, like 32KB or 1MB",
+					},
+					&cli.BoolFlag{
+						Name:  "preserve-xattrs",
+						Usage: "Copy extended attributes, such as Finder tags and SELinux labels, and ACLs along with files moved to another drive (Linux and macOS)",
+					},
+					&cli.BoolFlag{
+						Name:  "hash-no-cache",
+						Usage: "Keep huge files (256MB+) out of the OS page cache while hashing them (Linux and macOS)",
+					},
+					&cli.StringFlag{
+						Name:  "ignore-hashes",
+						Usage: "File listing hashes (MD5, SHA-1, SHA-256 or SHA-512, one per line) of files never to move, rename or remove",
+					},
+					&cli.BoolFlag{
+						Name:  "crc-prefilter",
+						Usage: "Find duplicates faster by fully hashing only files that share a size and CRC32 with another file",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-symlink-duplicates",
+						Usage: "Leave duplicates that are symbolic links in place instead of removing them",
+					},
+					&cli.BoolFlag{
+						Name:  "force-delete-readonly",
+						Usage: "Clear the read-only bit on duplicates so they can be removed, instead of skipping them",
+					},
+					&cli.BoolFlag{
+						Name:  "find-duplicate-dirs",
+						Usage: "Report folders whose files are identical throughout",
+					},
+					&cli.BoolFlag{
+						Name:  "remove-duplicate-dirs",
+						Usage: "Remove redundant copies of identical folders, asking before each one",
+					},
+					&cli.BoolFlag{
+						Name:  "count-only",
+						Usage: "Just count files and sizes per category, without hashing, moving or asking anything",
+					},
+					&cli.BoolFlag{
+						Name:  "no-dedupe-scan",
+						Usage: "Skip hashing and duplicate detection entirely (the default when no duplicate options are given)",
+					},
 					&cli.BoolFlag{
 						Name:    "force",
 						Aliases: []string{"f"},
 						Usage:   "Skip confirmation prompt (useful for automated scripts)",
 					},
+					&cli.BoolFlag{
+						Name:  "i-know-what-im-doing",
+						Usage: "Allow cleaning your whole home directory, or a folder with more than --max-scan-files files",
+					},
+					&cli.IntFlag{
+						Name:  "max-scan-files",
+						Value: defaultMaxScanFiles,
+						Usage: "Refuse folders holding more files than this, unless --i-know-what-im-doing is given (0 for no limit)",
+					},
+					&cli.BoolFlag{
+						Name:  "plugins",
+						Usage: "Ask the executables in the plugins folder of your config directory to categorize each file",
+					},
+					&cli.BoolFlag{
+						Name:  "include-in-progress",
+						Usage: "Also organize and dedupe unfinished downloads (.crdownload, .part, .!ut and similar), which are left alone by default",
+					},
+					&cli.IntFlag{
+						Name:  "trial",
+						Usage: "Only scan, and so only act on, the first this many files found, to try options out on a big folder",
+					},
+					&cli.IntFlag{
+						Name:  "max-depth",
+						Value: defaultMaxDepth,
+						Usage: "Skip folders nested more than this many levels below --path (0 for no limit)",
+					},
+					&cli.IntFlag{
+						Name:  "max-path-length",
+						Value: defaultMaxPathLength,
+						Usage: "Skip files whose path, or whose destination, is longer than this many characters (0 for no limit)",
+					},
+				},
+			},
+			{
+				Name:  "flatten",
+				Usage: "Move files out of organized folders back into the main folder",
+				Action: func(c *cli.Context) error {
+					path := c.String("path")
+					if path == "" {
+						var err error
+						path, err = getDefaultDownloadsPath()
+						if err != nil {
+							errorColor.Printf("❌ Oops! Couldn't find your downloads folder: %v\n", err)
+							errorColor.Printf("💡 Please specify a path using --path or -p\n")
+							return err
+						}
+					}
+
+					// Validate the path
+					if err := validatePath(path); err != nil {
+						errorColor.Printf("❌ Invalid path: %v\n", err)
+						return err
+					}
+					if _, err := os.Stat(path); os.IsNotExist(err) {
+						errorColor.Printf("❌ Oh no! The folder doesn't exist: %s\n", path)
+						return fmt.Errorf("folder not found")
+					}
+
+					dryRun := c.Bool("dry-run")
+					if dryRun {
+						warningColor.Printf("⚠️  Dry run mode enabled - no files will be moved\n")
+					}
+
+					config, err := loadUserConfig()
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+
+					organizer := NewFileOrganizer(NewScanner(), dryRun, path)
+					config.ApplyToOrganizer(organizer)
+					if value := c.String("max-runtime"); value != "" {
+						maxRuntime, err := time.ParseDuration(value)
+						if err != nil || maxRuntime <= 0 {
+							errorColor.Printf("❌ Invalid --max-runtime %q: use a duration like 5m or 1h\n", value)
+							return fmt.Errorf("invalid max-runtime: %s", value)
+						}
+						deadline, cancel := NewDeadline(maxRuntime)
+						defer cancel()
+						defer deadline.Report()
+						organizer.Deadline = deadline
+					}
+					if hashListPath := c.String("ignore-hashes"); hashListPath != "" {
+						protected, algorithms, err := LoadHashList(hashListPath)
+						if err != nil {
+							errorColor.Printf("❌ %v\n", err)
+							return err
+						}
+						organizer.Scanner.ProtectedHashes = protected
+						organizer.Scanner.HashAlgorithms = algorithms
+						infoColor.Printf("🛡️  Protecting files with one of %d hashes from %s\n", len(protected), hashListPath)
+					}
+					if category := c.String("unsort-category"); category != "" {
+						err = organizer.UnsortCategory(category, c.Bool("prune"))
+					} else {
+						err = organizer.Flatten(c.Bool("prune"))
+					}
+					if err != nil {
+						errorColor.Printf("❌ Error while flattening: %v\n", err)
+						return err
+					}
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "path",
+						Aliases: []string{"p"},
+						Usage:   "Path to the organized folder",
+					},
+					&cli.BoolFlag{
+						Name:    "dry-run",
+						Aliases: []string{"d"},
+						Usage:   "Show what would be done without actually doing it",
+					},
+					&cli.BoolFlag{
+						Name:  "prune",
+						Usage: "Remove the organized folders once they are empty",
+					},
+					&cli.StringFlag{
+						Name:  "unsort-category",
+						Usage: "Only move files out of this category's folder, like Images, and leave the other organized folders alone",
+					},
+					&cli.StringFlag{
+						Name:  "ignore-hashes",
+						Usage: "File listing hashes (MD5, SHA-1, SHA-256 or SHA-512, one per line) of files never to move out of their folder",
+					},
+					&cli.StringFlag{
+						Name:  "max-runtime",
+						Usage: "Stop starting new moves after this long, like 5m or 1h; whatever is under way finishes and the rest is listed and left for the next run",
+					},
+				},
+			},
+			{
+				Name:  "doctor",
+				Usage: "Check your setup and report anything that would stop a clean-up",
+				Action: func(c *cli.Context) error {
+					path := c.String("path")
+					if path == "" {
+						var err error
+						path, err = getDefaultDownloadsPath()
+						if err != nil {
+							errorColor.Printf("❌ Oops! Couldn't find your downloads folder: %v\n", err)
+							errorColor.Printf("💡 Please specify a path using --path or -p\n")
+							return err
+						}
+					}
+
+					infoColor.Printf("🩺 Checking: %s\n\n", path)
+					return printCheckResults(RunDoctor(path))
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "path",
+						Aliases: []string{"p"},
+						Usage:   "Path to the folder to check",
+					},
+				},
+			},
+			{
+				Name:  "selftest",
+				Usage: "Clean up a made-up folder of files in a temporary directory and check the result, to make sure this build works",
+				Action: func(c *cli.Context) error {
+					infoColor.Printf("🧪 Running the self-test in a temporary folder...\n\n")
+					var results []CheckResult
+					if c.Bool("verbose") {
+						results = RunSelfTest()
+					} else {
+						// Only the checks are of interest, not the progress of each step
+						devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+						if err != nil {
+							return err
+						}
+						stdout, colorOutput := os.Stdout, color.Output
+						os.Stdout, color.Output = devNull, devNull
+						results = RunSelfTest()
+						os.Stdout, color.Output = stdout, colorOutput
+						devNull.Close()
+					}
+					return printCheckResults(results)
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "Show the output of each step as well as the checks",
+					},
+				},
+			},
+			{
+				Name:  "verify-types",
+				Usage: "List files whose content doesn't match their extension, without moving anything",
+				Action: func(c *cli.Context) error {
+					path := c.String("path")
+					if path == "" {
+						var err error
+						path, err = getDefaultDownloadsPath()
+						if err != nil {
+							errorColor.Printf("❌ Oops! Couldn't find your downloads folder: %v\n", err)
+							errorColor.Printf("💡 Please specify a path using --path or -p\n")
+							return err
+						}
+					}
+
+					reportOutput := os.Stdout
+					if c.Bool("json") {
+						// Keep stdout for the JSON report and send progress messages to stderr
+						colorOutput := color.Output
+						os.Stdout, color.Output = os.Stderr, os.Stderr
+						defer func() {
+							os.Stdout, color.Output = reportOutput, colorOutput
+						}()
+					}
+
+					scanner := NewScanner()
+					scanner.SkipHashing = true
+					if err := scanner.ScanDirectory(path); err != nil {
+						errorColor.Printf("❌ Error scanning directory: %v\n", err)
+						return err
+					}
+
+					report := CheckFileTypes(scanner.Files)
+					if c.Bool("json") {
+						return report.WriteJSON(reportOutput)
+					}
+					fmt.Println()
+					report.Print()
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "path",
+						Aliases: []string{"p"},
+						Usage:   "Path to the folder to check",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the mismatches as JSON",
+					},
+				},
+			},
+			{
+				Name:  "init-config",
+				Usage: "Write a commented config file with the built-in category rules, ready to edit",
+				Action: func(c *cli.Context) error {
+					configPath, err := getConfigPath()
+					if err != nil {
+						errorColor.Printf("❌ Couldn't find the config directory: %v\n", err)
+						return err
+					}
+
+					if err := WriteDefaultConfig(configPath, c.Bool("force")); err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+
+					successColor.Printf("📝 Wrote the default config to %s\n", configPath)
+					fmt.Println("   Edit it to change where files go; elf-cli picks it up on the next run")
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Overwrite an existing config file",
+					},
+				},
+			},
+			{
+				Name:  "list-categories",
+				Usage: "Show which folder each category goes to and which extensions belong to it, after applying your config",
+				Action: func(c *cli.Context) error {
+					var config *Config
+					var err error
+					if configPath := c.String("config"); configPath != "" {
+						config, err = LoadConfig(configPath)
+					} else {
+						config, err = loadUserConfig()
+					}
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+
+					rules := config.ResolvedCategories()
+					if c.Bool("json") {
+						return rules.WriteJSON(os.Stdout)
+					}
+					rules.Print()
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Config file to read instead of the one in your config directory",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the categories as JSON",
+					},
+				},
+			},
+			{
+				Name:  "stats",
+				Usage: "Show usage stats recorded locally with --record-stats",
+				Action: func(c *cli.Context) error {
+					statsPath, err := getStatsPath()
+					if err != nil {
+						errorColor.Printf("❌ Couldn't find the config directory: %v\n", err)
+						return err
+					}
+
+					stats, err := LoadStats(statsPath)
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+
+					stats.Print()
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "lifetime",
+						Usage: "Show totals accumulated across all recorded runs (default)",
+					},
+				},
+			},
+			{
+				Name:      "find",
+				Usage:     "Find where organized files went, by name or hash, using the index kept with --index",
+				ArgsUsage: "<name-or-hash>",
+				Action: func(c *cli.Context) error {
+					query := c.Args().First()
+					if query == "" {
+						errorColor.Printf("❌ Tell me what to look for, like: elf-cli find report.pdf\n")
+						return fmt.Errorf("missing name or hash to find")
+					}
+
+					indexPath, err := getIndexPath()
+					if err != nil {
+						errorColor.Printf("❌ Couldn't find the config directory: %v\n", err)
+						return err
+					}
+
+					index, err := LoadIndex(indexPath)
+					if err != nil {
+						errorColor.Printf("❌ %v\n", err)
+						return err
+					}
+					if len(index.Entries) == 0 {
+						fmt.Println("No files indexed yet. Use --index with the clean command to start recording where files go.")
+						return nil
+					}
+
+					PrintIndexEntries(index.Find(query))
+					return nil
 				},
 			},
 			{
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -54,6 +55,36 @@ func TestValidatePath(t *testing.T) {
 	}
 }
 
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+		wantErr  bool
+	}{
+		{"512", 512, false},
+		{"32KB", 32 * 1024, false},
+		{"32k", 32 * 1024, false},
+		{"1MB", 1024 * 1024, false},
+		{"1.5GB", 1536 * 1024 * 1024, false},
+		{" 2 mb ", 2 * 1024 * 1024, false},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"-1KB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			result, err := parseByteSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.size, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetDefaultDownloadsPath(t *testing.T) {
 	path, err := getDefaultDownloadsPath()
 	if err != nil {
@@ -113,4 +144,42 @@ func TestFileInfo(t *testing.T) {
 	if fileInfo.Category != "Documents" {
 		t.Errorf("Expected category 'Documents', got '%s'", fileInfo.Category)
 	}
-} 
\ No newline at end of file
+}
+
+func TestCheckScanRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	downloads := filepath.Join(home, "Downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	if err := checkScanRoot(home); err == nil {
+		t.Error("Expected the home directory to be refused")
+	}
+	if err := checkScanRoot(home + string(filepath.Separator)); err == nil {
+		t.Error("Expected the home directory to be refused with a trailing separator")
+	}
+	if err := checkScanRoot(downloads); err != nil {
+		t.Errorf("Expected a folder inside home to be allowed: %v", err)
+	}
+
+	// The scan itself refuses folders over the file limit
+	scanner := NewScanner()
+	scanner.MaxFiles = 3
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Errorf("Expected a folder at the file limit to be allowed: %v", err)
+	}
+	scanner = NewScanner()
+	scanner.MaxFiles = 2
+	if err := scanner.ScanDirectory(downloads); !errors.Is(err, errTooManyFiles) {
+		t.Errorf("Expected a folder over the file limit to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadMoveMap reads a CSV file with filename,destination rows, as exported
+// from a spreadsheet, into a map of file name to destination folder. A header
+// row naming the columns is allowed. Destinations are relative to the folder
+// being organized and may not point outside it.
+func LoadMoveMap(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read map file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	moveMap := make(map[string]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse map file: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		name := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff")) // Spreadsheets often start with a BOM
+		dest := strings.TrimSpace(record[1])
+		if line == 1 && strings.EqualFold(name, "filename") && strings.EqualFold(dest, "destination") {
+			continue
+		}
+		if name == "" || dest == "" {
+			return nil, fmt.Errorf("map file line %d: both a file name and a destination are needed", line)
+		}
+		dest = filepath.Clean(filepath.FromSlash(dest))
+		if !filepath.IsLocal(dest) {
+			return nil, fmt.Errorf("map file line %d: destination %q must be a folder inside the one being organized", line, record[1])
+		}
+		if previous, ok := moveMap[name]; ok && previous != dest {
+			return nil, fmt.Errorf("map file line %d: %s is already mapped to %s", line, name, previous)
+		}
+		moveMap[name] = dest
+	}
+	return moveMap, nil
+}
+
+// OrganizeByMap moves files named in moveMap into their destination folders
+// under the base path. Other files are organized by category, or left where
+// they are if leaveUnmatched is set.
+func (fo *FileOrganizer) OrganizeByMap(moveMap map[string]string, leaveUnmatched bool) error {
+	fmt.Println("🗺️  Starting map-based organization...")
+	fmt.Println()
+
+	mapGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+
+		folder, ok := moveMap[file.Name]
+		if !ok {
+			if leaveUnmatched {
+				if fo.Scanner.Verbose {
+					fmt.Printf("   🔎 %s: not in the map file, leaving it in place\n", file.Name)
+				}
+				continue
+			}
+			folder = fo.categoryFolder(file.Category)
+		}
+		mapGroups[folder] = append(mapGroups[folder], file)
+	}
+
+	return fo.moveGroups(mapGroups, "🗺️ ", "mapped")
+}
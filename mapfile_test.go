@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrganizeByMap(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"invoice-march.pdf", "contract.docx", "holiday.jpg"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	mapPath := filepath.Join(t.TempDir(), "map.csv")
+	csv := "filename,destination\ninvoice-march.pdf,Finance/2024\n\"contract.docx\", Legal\n"
+	if err := os.WriteFile(mapPath, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write map file: %v", err)
+	}
+
+	moveMap, err := LoadMoveMap(mapPath)
+	if err != nil {
+		t.Fatalf("LoadMoveMap() error = %v", err)
+	}
+	if len(moveMap) != 2 {
+		t.Fatalf("Expected 2 mappings, got %v", moveMap)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByMap(moveMap, false); err != nil {
+		t.Fatalf("OrganizeByMap() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"invoice-march.pdf": filepath.Join("Finance", "2024"),
+		"contract.docx":     "Legal",
+		"holiday.jpg":       "Images", // Not in the map, so organized by category
+	}
+	for name, folder := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder, name)); err != nil {
+			t.Errorf("Expected %s in %s: %v", name, folder, err)
+		}
+	}
+}
+
+func TestOrganizeByMapOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"invoice-march.pdf", "holiday.jpg"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByMap(map[string]string{"invoice-march.pdf": "Finance"}, true); err != nil {
+		t.Fatalf("OrganizeByMap() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Finance", "invoice-march.pdf")); err != nil {
+		t.Errorf("Expected the mapped file in Finance: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "holiday.jpg")); err != nil {
+		t.Errorf("Expected the unmapped file to stay in place: %v", err)
+	}
+}
+
+func TestLoadMoveMapRejectsBadRows(t *testing.T) {
+	tests := map[string]string{
+		"outside the folder": "report.pdf,../elsewhere\n",
+		"absolute path":      "report.pdf,/tmp\n",
+		"missing column":     "report.pdf\n",
+		"conflicting rows":   "report.pdf,A\nreport.pdf,B\n",
+	}
+	for name, csv := range tests {
+		t.Run(name, func(t *testing.T) {
+			mapPath := filepath.Join(t.TempDir(), "map.csv")
+			os.WriteFile(mapPath, []byte(csv), 0644)
+			if _, err := LoadMoveMap(mapPath); err == nil {
+				t.Errorf("Expected an error for %q", csv)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Top-level MIME types that get their own folder; anything else goes to Other
+var mimeFolders = map[string]bool{
+	"image":       true,
+	"audio":       true,
+	"video":       true,
+	"text":        true,
+	"application": true,
+}
+
+// mimeFallbacks covers common types missing from Go's built-in table, so
+// results don't depend on which MIME database the system has installed
+var mimeFallbacks = map[string]string{
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".m4a":  "audio/mp4",
+	".opus": "audio/ogg",
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".avif": "image/avif",
+	".mp4":  "video/mp4",
+	".mkv":  "video/x-matroska",
+	".mov":  "video/quicktime",
+	".avi":  "video/x-msvideo",
+	".webm": "video/webm",
+	".m4v":  "video/x-m4v",
+	".3gp":  "video/3gpp",
+	".txt":  "text/plain",
+	".csv":  "text/csv",
+	".md":   "text/markdown",
+	".zip":  "application/zip",
+	".doc":  "application/msword",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// sniffMimeType guesses a file's MIME type from its first bytes, returning "" if it can't tell
+func sniffMimeType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, err := file.Read(header)
+	if n == 0 || err != nil {
+		return ""
+	}
+	mimeType := http.DetectContentType(header[:n])
+	if mimeType == "application/octet-stream" {
+		return ""
+	}
+	return mimeType
+}
+
+// extensionMimeType returns the MIME type a file extension stands for, or "" if it is unknown
+func extensionMimeType(ext string) string {
+	ext = strings.ToLower(ext)
+	if mimeType := mimeFallbacks[ext]; mimeType != "" {
+		return mimeType
+	}
+	if ext == "" {
+		return ""
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// mimeFolder returns the folder for a file based on the top-level part of its
+// MIME type, such as "image" for image/png. The type comes from the extension,
+// or from the file's content when sniff is set and the extension is unknown.
+func mimeFolder(path, ext string, sniff bool) string {
+	mimeType := extensionMimeType(ext)
+	if mimeType == "" && sniff {
+		mimeType = sniffMimeType(path)
+	}
+
+	topLevel, _, _ := strings.Cut(mimeType, "/")
+	if !mimeFolders[topLevel] {
+		return "Other"
+	}
+	return topLevel
+}
+
+// OrganizeByMime organizes files into folders named after their top-level MIME type
+func (fo *FileOrganizer) OrganizeByMime(sniff bool) error {
+	fmt.Println("🧾 Starting MIME-based organization...")
+	fmt.Println()
+
+	mimeGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		folder := mimeFolder(file.Path, file.Extension, sniff)
+		mimeGroups[folder] = append(mimeGroups[folder], file)
+	}
+
+	return fo.moveGroups(mimeGroups, "🧾", "MIME-based")
+}
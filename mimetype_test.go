@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMimeFolder(t *testing.T) {
+	tests := []struct {
+		name     string
+		ext      string
+		expected string
+	}{
+		{"photo.png", ".png", "image"},
+		{"photo.JPG", ".JPG", "image"},
+		{"song.mp3", ".mp3", "audio"},
+		{"clip.mp4", ".mp4", "video"},
+		{"page.html", ".html", "text"},
+		{"notes.txt", ".txt", "text"},
+		{"manual.pdf", ".pdf", "application"},
+		{"data.json", ".json", "application"},
+		{"mystery.elfdata", ".elfdata", "Other"},
+		{"README", "", "Other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := mimeFolder(tt.name, tt.ext, false); result != tt.expected {
+				t.Errorf("mimeFolder(%s) = %s, want %s", tt.name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMimeFolderSniff(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A PNG saved without an extension
+	pngPath := filepath.Join(tmpDir, "download")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(pngPath, png, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if result := mimeFolder(pngPath, "", false); result != "Other" {
+		t.Errorf("Expected Other without sniffing, got %s", result)
+	}
+	if result := mimeFolder(pngPath, "", true); result != "image" {
+		t.Errorf("Expected image with sniffing, got %s", result)
+	}
+
+	// Binary content that can't be identified stays in Other
+	binPath := filepath.Join(tmpDir, "blob")
+	if err := os.WriteFile(binPath, []byte{0x00, 0x01, 0x02, 0xfe}, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if result := mimeFolder(binPath, "", true); result != "Other" {
+		t.Errorf("Expected Other for unidentified content, got %s", result)
+	}
+}
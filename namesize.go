@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// nameSizeKey identifies files with the same name and size
+type nameSizeKey struct {
+	name string
+	size int64
+}
+
+// FindNameSizeDuplicates groups files anywhere in the scan that have exactly
+// the same name and size. Nothing is read, so this is much faster than hashing
+// on huge folders, but the files are only probable duplicates: their content
+// may still differ.
+func (s *Scanner) FindNameSizeDuplicates() [][]FileInfo {
+	byKey := make(map[nameSizeKey][]FileInfo)
+	for _, file := range s.Files {
+		key := nameSizeKey{name: file.Name, size: file.Size}
+		byKey[key] = append(byKey[key], file)
+	}
+
+	var groups [][]FileInfo
+	for _, files := range byKey {
+		files = collapseHardLinks(files)
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		groups = append(groups, files)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].Path < groups[j][0].Path })
+	return groups
+}
+
+// PrintNameSizeDuplicates prints groups found by FindNameSizeDuplicates
+func PrintNameSizeDuplicates(groups [][]FileInfo) {
+	if len(groups) == 0 {
+		fmt.Println("\n✅ No files share both a name and a size")
+		return
+	}
+
+	fmt.Println("\n📏 Probable duplicates with the same name and size (content not checked):")
+	for _, files := range groups {
+		fmt.Printf("  %s (%.2f MB):\n", files[0].Name, float64(files[0].Size)/1024/1024)
+		for _, file := range files {
+			fmt.Printf("    - %s\n", file.Path)
+		}
+	}
+}
+
+// VerifyNameSizeDuplicates hashes the files in groups found by
+// FindNameSizeDuplicates and makes the ones whose content really matches the
+// scanner's duplicates, replacing any found before, so duplicate removal only
+// acts on verified copies. It returns how many duplicate files were confirmed.
+func (s *Scanner) VerifyNameSizeDuplicates(groups [][]FileInfo) int {
+	fmt.Println("🔍 Checking the content of probable duplicates...")
+
+	s.Duplicates = make(map[string][]FileInfo)
+	added := make(map[string]bool)
+	for _, files := range groups {
+		byHash := make(map[string][]FileInfo)
+		for _, file := range files {
+			if file.Hash == "" {
+				hash, err := s.calculateFileHash(file.Path)
+				if err != nil {
+					fmt.Printf("⚠️  Could not calculate hash for %s: %v\n", file.Path, err)
+					continue
+				}
+				file.Hash = hash
+			}
+			byHash[file.Hash] = append(byHash[file.Hash], file)
+		}
+
+		for hash, same := range byHash {
+			if len(same) < 2 {
+				continue
+			}
+			// Files with different names can share content too, so groups
+			// for the same hash are merged rather than replaced
+			for _, file := range same {
+				if added[file.Path] {
+					continue
+				}
+				added[file.Path] = true
+				s.Duplicates[hash] = append(s.Duplicates[hash], file)
+				s.markDuplicate(file.Path, hash)
+			}
+		}
+	}
+	confirmed := len(added)
+
+	if confirmed > 0 {
+		fmt.Printf("⚠️  Confirmed %d duplicate files\n", confirmed)
+	} else {
+		fmt.Println("✅ None of them have the same content")
+	}
+	return confirmed
+}
+
+// markDuplicate records a file's hash and flags it as a duplicate, as findDuplicates does
+func (s *Scanner) markDuplicate(path, hash string) {
+	for i := range s.Files {
+		if s.Files[i].Path == path {
+			s.Files[i].Hash = hash
+			s.Files[i].IsDuplicate = true
+			return
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindNameSizeDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		filepath.Join("a", "report.pdf"): "same size 1",
+		filepath.Join("b", "report.pdf"): "same size 2", // Same name and size, different content
+		filepath.Join("a", "notes.txt"):  "short",
+		filepath.Join("b", "notes.txt"):  "a good deal longer",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	groups := scanner.FindNameSizeDuplicates()
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group, got %d: %v", len(groups), groups)
+	}
+	if len(groups[0]) != 2 || groups[0][0].Name != "report.pdf" || groups[0][1].Name != "report.pdf" {
+		t.Errorf("Expected the two report.pdf files to be grouped, got %v", groups[0])
+	}
+	for _, file := range groups[0] {
+		if file.Hash != "" {
+			t.Errorf("%s was hashed while grouping by name and size", file.Path)
+		}
+	}
+
+	// Their content differs, so verifying confirms nothing
+	if confirmed := scanner.VerifyNameSizeDuplicates(groups); confirmed != 0 {
+		t.Errorf("Confirmed %d duplicates with different content, want 0", confirmed)
+	}
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected no duplicates after verifying, got %v", scanner.Duplicates)
+	}
+}
+
+func TestVerifyNameSizeDuplicatesRemovesConfirmed(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "movie.mp4"), []byte("identical content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if confirmed := scanner.VerifyNameSizeDuplicates(scanner.FindNameSizeDuplicates()); confirmed != 2 {
+		t.Fatalf("Confirmed %d duplicates, want 2", confirmed)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+	if handler.TotalRemoved != 1 {
+		t.Errorf("Removed %d files, want 1", handler.TotalRemoved)
+	}
+}
+
+func TestVerifyNameSizeDuplicatesMergesGroups(t *testing.T) {
+	tmpDir := t.TempDir()
+	// Two name groups whose files all hold the same content
+	for _, dir := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+		for _, name := range []string{"clip.mp4", "copy.mp4"} {
+			if err := os.WriteFile(filepath.Join(tmpDir, dir, name), []byte("identical content"), 0644); err != nil {
+				t.Fatalf("Failed to create test file %s: %v", name, err)
+			}
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	groups := scanner.FindNameSizeDuplicates()
+	if confirmed := scanner.VerifyNameSizeDuplicates(groups); confirmed != 4 {
+		t.Errorf("Confirmed %d duplicates, want 4", confirmed)
+	}
+	if len(scanner.Duplicates) != 1 {
+		t.Fatalf("Expected one duplicate group, got %v", scanner.Duplicates)
+	}
+	for _, files := range scanner.Duplicates {
+		if len(files) != 4 {
+			t.Errorf("Expected all 4 copies in the group, got %d", len(files))
+		}
+	}
+
+	// Verifying the same groups again doesn't add anyone twice
+	scanner.VerifyNameSizeDuplicates(append(groups, groups[0]))
+	for _, files := range scanner.Duplicates {
+		if len(files) != 4 {
+			t.Errorf("Expected 4 copies after verifying a group twice, got %d", len(files))
+		}
+	}
+}
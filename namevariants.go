@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// nameVariantKey reduces a file name to a form that ignores URL escapes and
+// whitespace differences, and optionally case, so that names like
+// "my file.pdf" and "my%20file.pdf" compare equal
+func nameVariantKey(name string, foldCase bool) string {
+	key := normalizeFileName(name, " ")
+	if foldCase {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// FindNameVariants groups files in the same folder whose names differ only by
+// encoding, whitespace or (with foldCase) case. Their content may differ, so
+// these groups are only reported for review.
+func (s *Scanner) FindNameVariants(foldCase bool) [][]FileInfo {
+	byKey := make(map[string][]FileInfo)
+	for _, file := range s.Files {
+		key := filepath.Join(filepath.Dir(file.Path), nameVariantKey(file.Name, foldCase))
+		byKey[key] = append(byKey[key], file)
+	}
+
+	var groups [][]FileInfo
+	for _, files := range byKey {
+		if len(files) < 2 {
+			continue
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+		groups = append(groups, files)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].Path < groups[j][0].Path })
+	return groups
+}
+
+// PrintNameVariants prints groups found by FindNameVariants
+func PrintNameVariants(groups [][]FileInfo) {
+	if len(groups) == 0 {
+		fmt.Println("\n✅ No file names that differ only by encoding or whitespace")
+		return
+	}
+
+	fmt.Println("\n🔤 Files whose names differ only by encoding or whitespace (review these yourself):")
+	for _, files := range groups {
+		sameContent := files[0].Hash != ""
+		for _, file := range files[1:] {
+			if file.Hash != files[0].Hash {
+				sameContent = false
+			}
+		}
+		if sameContent {
+			fmt.Printf("  In %s (identical content):\n", filepath.Dir(files[0].Path))
+		} else {
+			fmt.Printf("  In %s (different content):\n", filepath.Dir(files[0].Path))
+		}
+		for _, file := range files {
+			fmt.Printf("    - %s (%.2f MB)\n", file.Name, float64(file.Size)/1024/1024)
+		}
+	}
+}
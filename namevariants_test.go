@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNameVariantKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		foldCase bool
+		expected string
+	}{
+		{"my file.pdf", false, "my file.pdf"},
+		{"my%20file.pdf", false, "my file.pdf"},
+		{"my  file .pdf", false, "my file .pdf"},
+		{"My File.PDF", false, "My File.PDF"},
+		{"My File.PDF", true, "my file.pdf"},
+	}
+
+	for _, tt := range tests {
+		if result := nameVariantKey(tt.name, tt.foldCase); result != tt.expected {
+			t.Errorf("nameVariantKey(%q, %v) = %q, want %q", tt.name, tt.foldCase, result, tt.expected)
+		}
+	}
+}
+
+func TestFindNameVariants(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"my file.pdf":   "first version",
+		"my%20file.pdf": "second version",
+		"My  File.pdf":  "third version", // Differs by more than case, so it is its own file everywhere
+		"other.pdf":     "unrelated",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Different content, so hash-based dedupe finds nothing
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected no content duplicates, got %d", len(scanner.Duplicates))
+	}
+
+	groups := scanner.FindNameVariants(false)
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("Expected one group of 2 name variants, got %v", groups)
+	}
+	if groups[0][0].Name != "my file.pdf" || groups[0][1].Name != "my%20file.pdf" {
+		t.Errorf("Expected my file.pdf and my%%20file.pdf, got %s and %s", groups[0][0].Name, groups[0][1].Name)
+	}
+
+	// Folding case pulls in the capitalized name too
+	groups = scanner.FindNameVariants(true)
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Errorf("Expected one group of 3 name variants when ignoring case, got %v", groups)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// normalizeFileName cleans up a messy download filename.
+// It URL-decodes the name, strips trailing query strings, replaces runs of
+// whitespace with sep and collapses doubled extensions like "file.pdf.pdf".
+func normalizeFileName(name, sep string) string {
+	normalized := name
+
+	// Decode URL escapes like %20
+	if decoded, err := url.PathUnescape(normalized); err == nil {
+		normalized = decoded
+	}
+
+	// Strip query string junk like "?dl=1"
+	if i := strings.Index(normalized, "?"); i > 0 {
+		normalized = normalized[:i]
+	}
+
+	// Replace whitespace with the chosen separator
+	normalized = strings.Join(strings.Fields(normalized), sep)
+
+	// Collapse doubled extensions
+	for {
+		ext := filepath.Ext(normalized)
+		if ext == "" || ext == normalized {
+			break
+		}
+		rest := strings.TrimSuffix(normalized, ext)
+		if !strings.EqualFold(filepath.Ext(rest), ext) {
+			break
+		}
+		normalized = rest
+	}
+
+	// Never produce an empty or unusable name
+	if normalized == "" || normalized == "." || normalized == ".." || strings.ContainsAny(normalized, `/\`) {
+		return name
+	}
+	return normalized
+}
+
+// NormalizeNames renames files in place to clean up messy download filenames
+func (fo *FileOrganizer) NormalizeNames(sep string) error {
+	fmt.Println("✏️  Normalizing file names...")
+	fmt.Println()
+
+	totalRenamed := 0
+	totalSkipped := 0
+
+	// Names claimed by earlier renames in this pass
+	planned := make(map[string]bool)
+
+	// Iterate over a copy since renames update the scanner
+	files := make([]FileInfo, len(fo.Scanner.Files))
+	copy(files, fo.Scanner.Files)
+
+	for _, file := range files {
+		newName := normalizeFileName(file.Name, sep)
+		if newName == file.Name {
+			continue
+		}
+
+		dir := filepath.Dir(file.Path)
+		renamed := file
+		renamed.Name = newName
+
+		if planned[filepath.Join(dir, newName)] {
+			warningColor.Printf("⚠️  Another file is already being renamed to %s, skipping %s\n", newName, file.Name)
+			totalSkipped++
+			continue
+		}
+
+		destPath, ok := fo.resolveDestination(renamed, dir)
+		if !ok {
+			totalSkipped++
+			continue
+		}
+		planned[destPath] = true
+
+		if fo.DryRun {
+			fmt.Printf("   ✏️  Would rename: %s -> %s\n", file.Name, filepath.Base(destPath))
+			fo.Plan.Add("rename", file.Path, destPath)
+		} else {
+			if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Rename %s -> %s", file.Name, filepath.Base(destPath))) {
+				totalSkipped++
+				continue
+			}
+			if changedSinceScan(file) {
+				totalSkipped++
+				continue
+			}
+			fmt.Printf("   ✏️  Renaming: %s -> %s\n", file.Name, filepath.Base(destPath))
+			err := fo.moveTracked(file.Path, destPath)
+			fo.Plan.Record("rename", file.Path, destPath, err)
+			if err != nil {
+				if isFatalMoveError(err) {
+					return fo.rollback(err, 0)
+				}
+				warningColor.Printf("   ⚠️  Failed to rename %s: %v\n", file.Name, err)
+				totalSkipped++
+				continue
+			}
+			fo.Scanner.updatePath(file.Path, destPath)
+		}
+		totalRenamed++
+	}
+	fmt.Println()
+
+	if totalRenamed > 0 {
+		successColor.Printf("✅ Renamed %d files!\n", totalRenamed)
+	} else {
+		fmt.Println("✅ All file names are already tidy.")
+	}
+	if totalSkipped > 0 {
+		fmt.Printf("ℹ️  Skipped %d files (conflicts or errors)\n", totalSkipped)
+	}
+
+	return nil
+}
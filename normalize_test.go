@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		sep      string
+		expected string
+	}{
+		{"My%20Report.pdf", "_", "My_Report.pdf"},
+		{"My%20Report.pdf", "-", "My-Report.pdf"},
+		{"photo.jpg?dl=1", "_", "photo.jpg"},
+		{"file.pdf?token=abc&x=1", "_", "file.pdf"},
+		{"file.pdf.pdf", "_", "file.pdf"},
+		{"file.PDF.pdf", "_", "file.PDF"},
+		{"archive.tar.gz", "_", "archive.tar.gz"},
+		{"My%20Notes.txt.txt?v=2", "_", "My_Notes.txt"},
+		{"  lots   of space .txt", "_", "lots_of_space_.txt"},
+		{"already_clean.txt", "_", "already_clean.txt"},
+		{"bad%zzescape.txt", "_", "bad%zzescape.txt"},
+		{"..%2F..%2Fetc", "_", "..%2F..%2Fetc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := normalizeFileName(tt.name, tt.sep)
+			if result != tt.expected {
+				t.Errorf("normalizeFileName(%q, %q) = %q, want %q", tt.name, tt.sep, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeNames(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"My%20Report.pdf": "report",
+		"photo.jpg?dl=1":  "photo",
+		"invoice.pdf.pdf": "invoice",
+		"invoice.pdf":     "other invoice",
+		"clean.txt":       "clean",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Dry run should leave everything alone
+	if err := NewFileOrganizer(scanner, true, tmpDir).NormalizeNames("_"); err != nil {
+		t.Fatalf("NormalizeNames() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "My%20Report.pdf")); err != nil {
+		t.Errorf("File was renamed in dry-run mode: %v", err)
+	}
+
+	if err := NewFileOrganizer(scanner, false, tmpDir).NormalizeNames("_"); err != nil {
+		t.Fatalf("NormalizeNames() error = %v", err)
+	}
+
+	for _, name := range []string{"My_Report.pdf", "photo.jpg", "clean.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s to exist: %v", name, err)
+		}
+	}
+
+	// The doubled extension collides with an existing file, so it is skipped
+	content, err := os.ReadFile(filepath.Join(tmpDir, "invoice.pdf"))
+	if err != nil || string(content) != "other invoice" {
+		t.Errorf("Existing invoice.pdf was overwritten")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "invoice.pdf.pdf")); err != nil {
+		t.Errorf("Conflicting file should have been left alone: %v", err)
+	}
+
+	// The scanner should know about the new names and categories
+	for _, file := range scanner.Files {
+		if file.Name == "photo.jpg" && file.Category != "Images" {
+			t.Errorf("Renamed photo has category %s, want Images", file.Category)
+		}
+	}
+	found := false
+	for _, file := range scanner.Categories["Images"] {
+		if file.Name == "photo.jpg" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Renamed photo missing from Images category")
+	}
+}
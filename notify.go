@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notifier shows a message outside the terminal, like a desktop notification
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// desktopNotifier sends notifications with the platform's own tool
+type desktopNotifier struct{}
+
+// Notify shows a desktop notification, doing nothing where there's no way to
+func (desktopNotifier) Notify(title, message string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return exec.Command("osascript", "-e", script).Run()
+	case "linux", "freebsd", "openbsd", "netbsd":
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return nil // No notification daemon tooling installed
+		}
+		return exec.Command(path, "--app-name=elf-cli", title, message).Run()
+	default:
+		return nil
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Summary describes a run in one line, for notifications
+func (run RunStats) Summary(dryRun bool) string {
+	reclaimed := fmt.Sprintf("%.1f MB", float64(run.BytesReclaimed)/1024/1024)
+	if run.BytesReclaimed >= 1024*1024*1024 {
+		reclaimed = fmt.Sprintf("%.1f GB", float64(run.BytesReclaimed)/1024/1024/1024)
+	}
+	prefix := "FolderElf finished"
+	if dryRun {
+		prefix = "FolderElf dry run finished"
+	}
+	return fmt.Sprintf("%s: moved %d, removed %d, reclaimed %s", prefix, run.FilesOrganized, run.DuplicatesRemoved, reclaimed)
+}
+
+// notifyRunComplete tells the user a run is over, warning if the notification fails
+func notifyRunComplete(notifier Notifier, run RunStats, dryRun bool) {
+	if err := notifier.Notify("elf-cli", run.Summary(dryRun)); err != nil {
+		warningColor.Printf("⚠️  Could not send a desktop notification: %v\n", err)
+	}
+}
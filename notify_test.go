@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// stubNotifier records the notifications it is asked to show
+type stubNotifier struct {
+	titles   []string
+	messages []string
+	err      error
+}
+
+func (sn *stubNotifier) Notify(title, message string) error {
+	sn.titles = append(sn.titles, title)
+	sn.messages = append(sn.messages, message)
+	return sn.err
+}
+
+func TestNotifyRunComplete(t *testing.T) {
+	run := RunStats{FilesOrganized: 120, DuplicatesRemoved: 8, BytesReclaimed: 2469606195}
+
+	notifier := &stubNotifier{}
+	notifyRunComplete(notifier, run, false)
+	if len(notifier.messages) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(notifier.messages))
+	}
+	if want := "FolderElf finished: moved 120, removed 8, reclaimed 2.3 GB"; notifier.messages[0] != want {
+		t.Errorf("Notification = %q, want %q", notifier.messages[0], want)
+	}
+
+	// A failing notifier only produces a warning
+	failing := &stubNotifier{err: errors.New("no display")}
+	notifyRunComplete(failing, RunStats{BytesReclaimed: 5 * 1024 * 1024}, true)
+	if want := "FolderElf dry run finished: moved 0, removed 0, reclaimed 5.0 MB"; len(failing.messages) != 1 || failing.messages[0] != want {
+		t.Errorf("Notifications = %q, want just %q", failing.messages, want)
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	if got, want := appleScriptString(`say "hi" \ bye`), `"say \"hi\" \\ bye"`; got != want {
+		t.Errorf("appleScriptString() = %s, want %s", got, want)
+	}
+}
@@ -1,62 +1,154 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"archive/tar"
 	"archive/zip"
+)
+
+const (
+	defaultMaxZipSize    = 100 * 1024 * 1024 // 100MB max zip size
+	defaultMaxZipEntries = 10000              // Max number of entries in zip
+	maxNestedArchiveDepth = 3                 // Deepest NestedArchiveDepth allowed
+	defaultZipWorkers    = 4                  // Zips inspected at the same time
+)
 
-	"github.com/fatih/color"
+// Strategies for handling files that already exist at the destination
+const (
+	DestExistsSkip  = "skip"  // Leave the file where it is
+	DestExistsMerge = "merge" // Rename on content conflicts, skip identical files
 )
 
+// Policies for files whose destination already holds the same content
 const (
-	maxZipSize    = 100 * 1024 * 1024 // 100MB max zip size
-	maxZipEntries = 10000              // Max number of entries in zip
+	IdenticalDestSkip   = "skip"   // Leave the file where it is
+	IdenticalDestRemove = "remove" // Remove the file as a duplicate of the one already there
 )
 
+// sizeBucket is a size range used by OrganizeBySize; -1 means no limit
+type sizeBucket struct {
+	Name string `json:"name"`
+	Min  int64  `json:"min_bytes"`
+	Max  int64  `json:"max_bytes"`
+}
+
+// Size categories used by OrganizeBySize
+var sizeCategories = []sizeBucket{
+	{"Tiny", 0, 1024 * 1024},         // < 1MB
+	{"Small", 1024 * 1024, 10 * 1024 * 1024},    // 1MB - 10MB
+	{"Medium", 10 * 1024 * 1024, 100 * 1024 * 1024}, // 10MB - 100MB
+	{"Large", 100 * 1024 * 1024, 1024 * 1024 * 1024}, // 100MB - 1GB
+	{"Huge", 1024 * 1024 * 1024, -1}, // > 1GB
+}
+
 // FileOrganizer handles organizing files into categorized folders
 type FileOrganizer struct {
 	Scanner      *Scanner
 	DryRun      bool
 	CategoryMap  map[string]string // Maps category names to folder names
+	SizeBuckets  []sizeBucket      // Size ranges used by OrganizeBySize
+	DateSources  []string          // Where OrganizeByDate takes dates from, in order; modification time if empty
 	BasePath     string           // Base path where organized folders will be created
+	DestExistsStrategy string     // How to handle files that already exist at the destination
+	IdenticalDestStrategy string  // What to do with files whose destination already holds the same content
+	MaxZipSize   int64            // Max zip size in bytes, 0 for no limit
+	MaxZipEntries int             // Max number of entries in a zip, 0 for no limit
+	AllowLargeZips bool           // Disable the zip bomb checks entirely for trusted sources
+	NestedArchiveDepth int        // How many levels of archives inside zips to look into for their category, 0 for none
+	ZipWorkers   int              // How many zips to inspect at once
+	ShardByHash  bool             // Put files in <category>/<first two hex characters of hash>/
+	MaxPerFolder int              // Split a category over numbered subfolders of at most this many files, 0 for no limit
+	TotalMoved   int              // Files moved so far
+	MaxBytes     int64            // Stop moving once this many bytes have moved, 0 for no limit
+	BytesMoved   int64            // Bytes moved so far
+	Unmoved      []FileInfo       // Files left in place because MaxBytes was reached
+	SettleTime   time.Duration    // Leave files modified more recently than this alone, as they may still be written
+	Approver     *Approver        // Asks before each move, nil to move without asking
+	Deadline     *Deadline        // Stops starting new moves once --max-runtime is up, nil for no limit
+	OnlyCategories map[string]bool // Only organize files in these categories, empty for all
+	SkipCategories map[string]bool // Never organize files in these categories
+	Plan         *Plan            // Records what a dry run would do, nil to not record
+	PackSmallest bool             // Pack the smallest size bucket into zip archives instead of a folder
+	MaxPackSize  int64            // Max bytes of file data per packed archive, 0 for the default
+	Quarantine   *Quarantine      // Where packed originals and removed identical files go instead of being deleted, nil to delete
+	PreMoveHook  *MoveHook        // Run before each move; a failure leaves the file in place
+	PostMoveHook *MoveHook        // Run after each move
+	Throttle     *RateLimiter     // Limits how fast files are copied between drives, nil for no limit
+	PreserveXattrs bool           // Copy extended attributes and ACLs along with files moved between drives
+
+	moves        []fileMove                            // Moves made this session, for rolling back on fatal errors
+	moveFile     func(src, dst string) error           // Overrides atomicMove in tests
+	openZip      func(path string) (*zipHandle, error) // Overrides openZip in tests
+	spaceChecker spaceChecker                          // Checks free space before cross-device moves
+}
+
+// fileMove records a single successful move
+type fileMove struct {
+	Src     string
+	Dst     string
+	Sidecar bool // A category sidecar that followed its file, not counted in TotalMoved
 }
 
 // NewFileOrganizer creates a new FileOrganizer instance
 func NewFileOrganizer(scanner *Scanner, dryRun bool, basePath string) *FileOrganizer {
-	// Default category to folder mapping
-	categoryMap := map[string]string{
+	return &FileOrganizer{
+		Scanner:     scanner,
+		DryRun:     dryRun,
+		CategoryMap: defaultCategoryFolders(),
+		SizeBuckets: sizeCategories,
+		BasePath:    basePath,
+		DestExistsStrategy: DestExistsSkip,
+		IdenticalDestStrategy: IdenticalDestSkip,
+		MaxZipSize:  defaultMaxZipSize,
+		MaxZipEntries: defaultMaxZipEntries,
+		ZipWorkers:  defaultZipWorkers,
+		spaceChecker: newSpaceChecker(),
+	}
+}
+
+// defaultCategoryFolders returns the default category to folder mapping
+func defaultCategoryFolders() map[string]string {
+	return map[string]string{
 		"Images":       "Images",
+		"Screenshots":  "Screenshots",
 		"Documents":    "Documents",
 		"Videos":       "Videos",
 		"Music":        "Music",
 		"Applications": "Applications",
 		"Archives":     "Archives",
 		"Disk Images":  "Disk Images",
+		"Installers":   "Installers",
 		"Other":        "Other",
 	}
-
-	return &FileOrganizer{
-		Scanner:     scanner,
-		DryRun:     dryRun,
-		CategoryMap: categoryMap,
-		BasePath:    basePath,
-	}
 }
 
 // checkZipBomb validates zip file to prevent zip bomb attacks
 func (fo *FileOrganizer) checkZipBomb(zipPath string) error {
+	if fo.AllowLargeZips {
+		return nil
+	}
+
 	fileInfo, err := os.Stat(zipPath)
 	if err != nil {
 		return fmt.Errorf("cannot stat zip file: %v", err)
 	}
 
 	// Check file size
-	if fileInfo.Size() > maxZipSize {
-		return fmt.Errorf("zip file too large (%d bytes), max allowed: %d bytes", fileInfo.Size(), maxZipSize)
+	if fo.MaxZipSize > 0 && fileInfo.Size() > fo.MaxZipSize {
+		return fmt.Errorf("zip file too large (%d bytes), max allowed: %d bytes", fileInfo.Size(), fo.MaxZipSize)
 	}
 
 	// Open zip to check number of entries
@@ -66,14 +158,18 @@ func (fo *FileOrganizer) checkZipBomb(zipPath string) error {
 	}
 	defer r.Close()
 
-	// Count entries and check for zip bomb patterns
+	return fo.checkZipEntries(r.File)
+}
+
+// checkZipEntries checks the entries of a zip for zip bomb patterns
+func (fo *FileOrganizer) checkZipEntries(files []*zip.File) error {
 	entryCount := 0
 	totalSize := int64(0)
-	
-	for _, f := range r.File {
+
+	for _, f := range files {
 		entryCount++
-		if entryCount > maxZipEntries {
-			return fmt.Errorf("zip file has too many entries (%d), max allowed: %d", entryCount, maxZipEntries)
+		if fo.MaxZipEntries > 0 && entryCount > fo.MaxZipEntries {
+			return fmt.Errorf("zip file has too many entries (%d), max allowed: %d", entryCount, fo.MaxZipEntries)
 		}
 
 		// Check for suspicious compression ratios
@@ -85,7 +181,7 @@ func (fo *FileOrganizer) checkZipBomb(zipPath string) error {
 		}
 
 		totalSize += int64(f.UncompressedSize64)
-		if totalSize > maxZipSize*10 { // Allow 10x expansion
+		if fo.MaxZipSize > 0 && totalSize > fo.MaxZipSize*10 { // Allow 10x expansion
 			return fmt.Errorf("zip file would expand to too large size (%d bytes)", totalSize)
 		}
 	}
@@ -93,6 +189,65 @@ func (fo *FileOrganizer) checkZipBomb(zipPath string) error {
 	return nil
 }
 
+// categoryFolder returns the folder a category's files go in. Categories taken
+// from folder names keep their folder; other unknown categories go in Other.
+func (fo *FileOrganizer) categoryFolder(category string) string {
+	if folder, ok := fo.CategoryMap[category]; ok {
+		return folder
+	}
+	if fo.Scanner != nil && fo.Scanner.FolderCategories[category] {
+		return category
+	}
+	return "Other"
+}
+
+// categoryEnabled reports whether files in a category should be organized
+func (fo *FileOrganizer) categoryEnabled(category string) bool {
+	if len(fo.OnlyCategories) > 0 && !fo.OnlyCategories[category] {
+		return false
+	}
+	return !fo.SkipCategories[category]
+}
+
+// parseCategories turns category names given on the command line into a set,
+// matching them case-insensitively against the known categories
+func (fo *FileOrganizer) parseCategories(names []string) (map[string]bool, error) {
+	categories := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for category := range fo.CategoryMap {
+			if strings.EqualFold(name, category) {
+				categories[category] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown category %q", name)
+		}
+	}
+	return categories, nil
+}
+
+// PreviewCategory limits the organizer to a dry run of a single category
+func (fo *FileOrganizer) PreviewCategory(name string) error {
+	categories, err := fo.parseCategories([]string{name})
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return fmt.Errorf("no category given")
+	}
+	fo.DryRun = true
+	fo.OnlyCategories = categories
+	fo.SkipCategories = nil
+	return nil
+}
+
 // atomicMove performs an atomic file move operation
 func (fo *FileOrganizer) atomicMove(src, dst string) error {
 	// Try atomic rename first (works on same filesystem)
@@ -105,27 +260,442 @@ func (fo *FileOrganizer) atomicMove(src, dst string) error {
 	return fo.copyAndDelete(src, dst)
 }
 
+// moveTracked moves a file and records the move so it can be rolled back
+func (fo *FileOrganizer) moveTracked(src, dst string) error {
+	move := fo.atomicMove
+	if fo.moveFile != nil {
+		move = fo.moveFile
+	}
+
+	if fo.PreMoveHook != nil {
+		if err := fo.PreMoveHook.Run(src, src, dst); err != nil {
+			return fmt.Errorf("vetoed by --pre-move-cmd: %v", err)
+		}
+	}
+	if err := move(src, dst); err != nil {
+		return err
+	}
+	fo.moves = append(fo.moves, fileMove{Src: src, Dst: dst})
+	if fo.PostMoveHook != nil {
+		if err := fo.PostMoveHook.Run(dst, src, dst); err != nil {
+			warningColor.Printf("   ⚠️  --post-move-cmd failed for %s: %v\n", filepath.Base(dst), err)
+		}
+	}
+
+	// Keep a category sidecar with its file so the override still applies next run
+	if _, err := os.Stat(src + categorySidecarExt); err == nil {
+		if err := move(src+categorySidecarExt, dst+categorySidecarExt); err == nil {
+			fo.moves = append(fo.moves, fileMove{Src: src + categorySidecarExt, Dst: dst + categorySidecarExt, Sidecar: true})
+		}
+	}
+	return nil
+}
+
+// isFatalMoveError reports whether a move error means no further moves can succeed,
+// as opposed to a problem with a single file
+func isFatalMoveError(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EROFS)
+}
+
+// rollback reverses the moves made this session, newest first, after a fatal
+// error. moved is the number of moves the caller made that aren't in
+// TotalMoved yet. A file is left where it was moved to when something new
+// has appeared at its old path, so rolling back never overwrites anything.
+func (fo *FileOrganizer) rollback(cause error, moved int) error {
+	errorColor.Printf("❌ Stopping: %v\n", cause)
+	fo.TotalMoved += moved
+	if len(fo.moves) == 0 {
+		return cause
+	}
+
+	warningColor.Printf("↩️  Rolling back %d moves made this run...\n", len(fo.moves))
+	failed := 0
+	for i := len(fo.moves) - 1; i >= 0; i-- {
+		move := fo.moves[i]
+		if _, err := os.Lstat(move.Src); err == nil {
+			warningColor.Printf("   ⚠️  Leaving %s in place, %s has appeared since it was moved\n", move.Dst, move.Src)
+			failed++
+			continue
+		}
+		if err := fo.atomicMove(move.Dst, move.Src); err != nil {
+			warningColor.Printf("   ⚠️  Failed to move %s back: %v\n", move.Dst, err)
+			failed++
+			continue
+		}
+		if !move.Sidecar && fo.TotalMoved > 0 {
+			fo.TotalMoved--
+		}
+		fmt.Printf("   ↩️  Moved back: %s\n", filepath.Base(move.Src))
+	}
+
+	rolledBack := len(fo.moves) - failed
+	fo.moves = nil
+	if failed > 0 {
+		return fmt.Errorf("%v (rolled back %d moves, %d could not be undone)", cause, rolledBack, failed)
+	}
+	return fmt.Errorf("%v (rolled back %d moves)", cause, rolledBack)
+}
+
+// checkSpace makes sure there is room for the files about to be moved,
+// given as a map of destination folder to files
+func (fo *FileOrganizer) checkSpace(groups map[string][]FileInfo) error {
+	var moves []pendingMove
+	for destDir, files := range groups {
+		for _, file := range files {
+			if file.IsDuplicate || filepath.Dir(file.Path) == destDir {
+				continue
+			}
+			moves = append(moves, pendingMove{Src: file.Path, Dst: filepath.Join(destDir, file.Name), Size: file.Size})
+		}
+	}
+
+	err := fo.spaceChecker.Check(moves)
+	if err != nil && fo.DryRun {
+		warningColor.Printf("⚠️  %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// resolveDestination works out where a file should be moved inside destDir.
+// It returns false when the file should be left where it is.
+func (fo *FileOrganizer) resolveDestination(file FileInfo, destDir string) (string, bool) {
+	destPath := filepath.Join(destDir, file.Name)
+	if fo.Scanner.pathTooLong(destPath) {
+		warningColor.Printf("⚠️  Destination path is too long, leaving %s in place: %s\n", file.Name, destPath)
+		return "", false
+	}
+	if _, err := os.Stat(destPath); os.IsNotExist(err) {
+		return destPath, true
+	}
+
+	// Identical content is already organized, so there is nothing to merge,
+	// and the file is a duplicate of the one already there
+	if fo.DestExistsStrategy == DestExistsMerge || fo.IdenticalDestStrategy == IdenticalDestRemove {
+		if fo.sameContent(file, destPath) {
+			warningColor.Printf("⚠️  Identical file already exists at destination: %s\n", destPath)
+			if fo.IdenticalDestStrategy == IdenticalDestRemove {
+				fo.removeIdentical(file, destPath)
+			}
+			return "", false
+		}
+	}
+
+	if fo.DestExistsStrategy != DestExistsMerge {
+		warningColor.Printf("⚠️  File already exists at destination: %s\n", destPath)
+		return "", false
+	}
+
+	candidate := nextFreeName(destDir, file.Name)
+	if fo.Scanner.pathTooLong(candidate) {
+		warningColor.Printf("⚠️  Destination path is too long, leaving %s in place: %s\n", file.Name, candidate)
+		return "", false
+	}
+	fmt.Printf("   ✏️  %s already exists, using: %s\n", file.Name, filepath.Base(candidate))
+	return candidate, true
+}
+
+// sameContent reports whether the file at destPath has the same content as
+// file. The scan may have skipped hashing, or hashed a zip by what it holds,
+// so the source is hashed here if needed. Only two separate regular files
+// count: a symlink or a hard link to the source isn't a second copy.
+func (fo *FileOrganizer) sameContent(file FileInfo, destPath string) bool {
+	if !separateFiles(file.Path, destPath) {
+		return false
+	}
+	sourceHash := file.Hash
+	if sourceHash == "" || strings.HasPrefix(sourceHash, archiveHashPrefix) {
+		var err error
+		if sourceHash, err = fo.Scanner.calculateFileHash(file.Path); err != nil {
+			return false
+		}
+	}
+	existingHash, err := fo.Scanner.calculateFileHash(destPath)
+	return err == nil && existingHash == sourceHash
+}
+
+// separateFiles reports whether two paths are distinct regular files, neither
+// a symlink nor the same file reached through a hard link
+func separateFiles(a, b string) bool {
+	infoA, err := os.Lstat(a)
+	if err != nil || !infoA.Mode().IsRegular() {
+		return false
+	}
+	infoB, err := os.Lstat(b)
+	if err != nil || !infoB.Mode().IsRegular() {
+		return false
+	}
+	return !os.SameFile(infoA, infoB)
+}
+
+// removeIdentical removes a file whose destination already holds the same
+// content, moving it to the quarantine instead when there is one
+func (fo *FileOrganizer) removeIdentical(file FileInfo, destPath string) {
+	if fo.DryRun {
+		fmt.Printf("   🗑️  Would remove: %s (identical to %s)\n", file.Name, destPath)
+		fo.Plan.Add("remove", file.Path, "")
+		return
+	}
+	if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Remove %s", file.Path)) || changedSinceScan(file) {
+		return
+	}
+	// The destination may have been swapped for a link to the source since it was compared
+	if !separateFiles(file.Path, destPath) {
+		warningColor.Printf("   ⚠️  Keeping %s: %s is no longer a separate copy\n", file.Name, destPath)
+		return
+	}
+
+	var err error
+	if fo.Quarantine != nil {
+		err = fo.Quarantine.Add(file)
+	} else {
+		err = os.Remove(file.Path)
+	}
+	fo.Plan.Record("remove", file.Path, "", err)
+	if err != nil {
+		errorColor.Printf("   ❌ Failed to remove %s: %v\n", file.Name, err)
+		return
+	}
+	fmt.Printf("   🗑️  Removed: %s (identical to %s)\n", file.Name, destPath)
+}
+
+// nextFreeName finds a free path for name inside destDir, using the same "(n)" suffix browsers use
+func nextFreeName(destDir, name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := filepath.Join(destDir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// nextUnclaimedName is nextFreeName that also passes over the paths in
+// claimed, the ones a dry run has already handed out without creating them
+func nextUnclaimedName(destDir, name string, claimed map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := filepath.Join(destDir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+		if _, err := os.Stat(candidate); os.IsNotExist(err) && !claimed[candidate] {
+			return candidate
+		}
+	}
+}
+
+// folderPath returns the path of a destination folder under the base path. A
+// folder that already exists with different case, like "images" from an older
+// run, is reused: on case-insensitive file systems "Images" can't be created
+// next to it, and on others a second folder isn't wanted.
+func (fo *FileOrganizer) folderPath(folderName string) string {
+	path := fo.BasePath
+	for _, part := range strings.Split(filepath.Clean(folderName), string(filepath.Separator)) {
+		path = filepath.Join(path, existingFolderName(path, part))
+	}
+	return path
+}
+
+// existingFolderName returns the name of the folder in dir that matches name
+// ignoring case, preferring an exact match, or name itself if there is none
+func existingFolderName(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return name
+	}
+	match := name
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.EqualFold(entry.Name(), name) {
+			continue
+		}
+		if entry.Name() == name {
+			return name
+		}
+		if match == name {
+			match = entry.Name()
+		}
+	}
+	return match
+}
+
+// hashShard returns the shard folder for a file hash, its first two hex
+// characters, or "" if the file wasn't hashed
+func hashShard(hash string) string {
+	hash = strings.TrimPrefix(hash, archiveHashPrefix)
+	if len(hash) < 2 {
+		return ""
+	}
+	return strings.ToLower(hash[:2])
+}
+
+// withinByteLimit reports whether file can be moved without going over MaxBytes.
+// Once the limit is hit every later file is left for the next run, so a run
+// never skips a large file only to move smaller ones after it
+func (fo *FileOrganizer) withinByteLimit(file FileInfo) bool {
+	if fo.MaxBytes <= 0 {
+		return true
+	}
+	if len(fo.Unmoved) == 0 && fo.BytesMoved+file.Size <= fo.MaxBytes {
+		return true
+	}
+	fo.Unmoved = append(fo.Unmoved, file)
+	return false
+}
+
+// reportUnmoved lists the files left in place because MaxBytes was reached
+func (fo *FileOrganizer) reportUnmoved() {
+	if len(fo.Unmoved) == 0 {
+		return
+	}
+
+	var remaining int64
+	for _, file := range fo.Unmoved {
+		remaining += file.Size
+	}
+	warningColor.Printf("⏸️  Reached --max-bytes after %.2f MB, leaving %d files (%.2f MB) for the next run:\n",
+		float64(fo.BytesMoved)/1024/1024, len(fo.Unmoved), float64(remaining)/1024/1024)
+	for _, file := range fo.Unmoved {
+		fmt.Printf("   ⏭️  %s\n", file.Path)
+	}
+}
+
+// settled reports whether file was last modified more than SettleTime ago.
+// A download without a temporary extension may still be being written, and
+// moving it then fails or leaves a partial copy, so it is left for the next run.
+func (fo *FileOrganizer) settled(file FileInfo) bool {
+	if fo.SettleTime <= 0 {
+		return true
+	}
+	age := time.Since(file.LastModified)
+	if age >= fo.SettleTime || age <= -fo.SettleTime {
+		return true
+	}
+	fmt.Printf("   ⏳ %s was modified in the last %s and may still be written, leaving it for the next run\n", file.Name, fo.SettleTime)
+	return false
+}
+
+// folderChunks splits a category's files over numbered subfolders of
+// categoryPath holding at most MaxPerFolder files each, returning the
+// subfolder for each file path. Files already in a numbered subfolder stay
+// there, and the rest are taken in name order to fill the last subfolder
+// before a new one is started, so the limit holds across runs. It returns nil
+// when there are no numbered subfolders yet and the files fit in one folder.
+func (fo *FileOrganizer) folderChunks(files []FileInfo, categoryPath string) map[string]string {
+	if fo.MaxPerFolder <= 0 {
+		return nil
+	}
+
+	counts := chunkCounts(categoryPath)
+	chunks := make(map[string]string, len(files))
+	var pending []FileInfo
+	for _, file := range files {
+		if file.IsDuplicate {
+			continue
+		}
+		if dir := filepath.Dir(file.Path); filepath.Dir(dir) == categoryPath && isChunkName(filepath.Base(dir)) {
+			chunks[file.Path] = filepath.Base(dir)
+			continue
+		}
+		pending = append(pending, file)
+	}
+	if len(counts) == 0 && len(pending) <= fo.MaxPerFolder {
+		return nil
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		a, b := strings.ToLower(pending[i].Name), strings.ToLower(pending[j].Name)
+		if a != b {
+			return a < b
+		}
+		return pending[i].Path < pending[j].Path
+	})
+	last := 1
+	for name := range counts {
+		if n, _ := strconv.Atoi(name); n > last {
+			last = n
+		}
+	}
+	for _, file := range pending {
+		for counts[fmt.Sprintf("%03d", last)] >= fo.MaxPerFolder {
+			last++
+		}
+		chunk := fmt.Sprintf("%03d", last)
+		chunks[file.Path] = chunk
+		counts[chunk]++
+	}
+	return chunks
+}
+
+// isChunkName reports whether a folder name is one folderChunks numbers, like "001"
+func isChunkName(name string) bool {
+	if len(name) < 3 {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// chunkCounts returns how many files each numbered subfolder of categoryPath
+// already holds, from earlier runs
+func chunkCounts(categoryPath string) map[string]int {
+	counts := make(map[string]int)
+	entries, err := os.ReadDir(categoryPath)
+	if err != nil {
+		return counts
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !isChunkName(entry.Name()) {
+			continue
+		}
+		inside, err := os.ReadDir(filepath.Join(categoryPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		counts[entry.Name()] = 0
+		for _, file := range inside {
+			if !file.IsDir() && !skippedFile(file.Name()) {
+				counts[entry.Name()]++
+			}
+		}
+	}
+	return counts
+}
+
 // OrganizeFiles organizes all files into their respective category folders
 func (fo *FileOrganizer) OrganizeFiles() error {
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("📁 Starting file organization...")
 	fmt.Println()
 
 	totalMoved := 0
 	totalSkipped := 0
 
+	// Make sure cross-device moves won't run out of space halfway
+	spaceGroups := make(map[string][]FileInfo)
+	for category, files := range fo.Scanner.Categories {
+		if !fo.categoryEnabled(category) {
+			continue
+		}
+		folderName := fo.categoryFolder(category)
+		categoryPath := fo.folderPath(folderName)
+		spaceGroups[categoryPath] = append(spaceGroups[categoryPath], files...)
+	}
+	if err := fo.checkSpace(spaceGroups); err != nil {
+		return err
+	}
+
 	// Process each category
 	for category, files := range fo.Scanner.Categories {
-		folderName, exists := fo.CategoryMap[category]
-		if !exists {
-			folderName = "Other"
+		if !fo.categoryEnabled(category) {
+			continue
 		}
+		folderName := fo.categoryFolder(category)
 
 		// Create category folder if it doesn't exist
-		categoryPath := filepath.Join(fo.BasePath, folderName)
+		categoryPath := fo.folderPath(folderName)
 		if !fo.DryRun {
 			err := os.MkdirAll(categoryPath, 0755)
 			if err != nil {
@@ -149,6 +719,7 @@ func (fo *FileOrganizer) OrganizeFiles() error {
 		}
 
 		infoColor.Printf("📂 Processing %s (%d files)...\n", category, len(files))
+		chunks := fo.folderChunks(files, categoryPath)
 
 		// Move each file to its category folder
 		for _, file := range files {
@@ -157,53 +728,83 @@ func (fo *FileOrganizer) OrganizeFiles() error {
 				continue
 			}
 
+			// Spread files over subfolders named after the start of their hash
+			destDir, destName := categoryPath, folderName
+			if shard := hashShard(file.Hash); fo.ShardByHash && shard != "" {
+				destDir, destName = filepath.Join(categoryPath, shard), filepath.Join(folderName, shard)
+			} else if chunk := chunks[file.Path]; chunk != "" {
+				destDir, destName = filepath.Join(categoryPath, chunk), filepath.Join(folderName, chunk)
+			}
+
 			// Skip files that are already in the correct folder
-			if filepath.Dir(file.Path) == categoryPath {
+			if filepath.Dir(file.Path) == destDir {
 				totalSkipped++
 				continue
 			}
 
-			destPath := filepath.Join(categoryPath, file.Name)
+			if destDir != categoryPath && !fo.DryRun {
+				if err := os.MkdirAll(destDir, 0755); err != nil {
+					warningColor.Printf("   ⚠️  Failed to create folder %s: %v\n", destName, err)
+					totalSkipped++
+					continue
+				}
+			}
 
-			// Check if destination file already exists
-			if _, err := os.Stat(destPath); err == nil {
-				warningColor.Printf("⚠️  File already exists at destination: %s\n", destPath)
+			if !fo.settled(file) || !fo.withinByteLimit(file) {
+				continue
+			}
+
+			destPath, ok := fo.resolveDestination(file, destDir)
+			if !ok {
 				totalSkipped++
 				continue
 			}
 
 			if fo.DryRun {
-				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, folderName)
+				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, destName)
+				fo.Plan.Add("move", file.Path, destPath)
 			} else {
+				if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Move %s -> %s", file.Name, destName)) {
+					totalSkipped++
+					continue
+				}
+				if changedSinceScan(file) {
+					totalSkipped++
+					continue
+				}
 				fmt.Printf("   📁 Moving: %s\n", file.Name)
-				err := fo.atomicMove(file.Path, destPath)
+				err := fo.moveTracked(file.Path, destPath)
+				fo.Plan.Record("move", file.Path, destPath, err)
 				if err != nil {
+					if isFatalMoveError(err) {
+						return fo.rollback(err, totalMoved)
+					}
 					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", file.Name, err)
 					totalSkipped++
 					continue
 				}
 			}
 			totalMoved++
+			fo.BytesMoved += file.Size
 		}
 		fmt.Println()
 	}
 
+	fo.TotalMoved += totalMoved
+
 	if totalMoved > 0 {
 		successColor.Printf("✅ Moved %d files to organized folders!\n", totalMoved)
 	}
 	if totalSkipped > 0 {
 		fmt.Printf("ℹ️  Skipped %d files (already in place or conflicts)\n", totalSkipped)
 	}
+	fo.reportUnmoved()
 
 	return nil
 }
 
 // OrganizeByDate organizes files into date-based folders (YYYY-MM format)
 func (fo *FileOrganizer) OrganizeByDate() error {
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("📅 Starting date-based organization...")
 	fmt.Println()
 
@@ -213,15 +814,28 @@ func (fo *FileOrganizer) OrganizeByDate() error {
 	// Group files by date
 	dateGroups := make(map[string][]FileInfo)
 	for _, file := range fo.Scanner.Files {
-		if file.IsDuplicate {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
 			continue
 		}
 
-		// Get year-month from modification date
-		dateKey := file.LastModified.Format("2006-01")
+		// Get year-month from the first date source that has a date
+		date, source := fo.fileDate(file)
+		dateKey := date.Format("2006-01")
+		if fo.Scanner.Verbose {
+			fmt.Printf("   🔎 %s: %s date -> %s\n", file.Name, source, dateKey)
+		}
 		dateGroups[dateKey] = append(dateGroups[dateKey], file)
 	}
 
+	// Make sure cross-device moves won't run out of space halfway
+	spaceGroups := make(map[string][]FileInfo)
+	for dateKey, files := range dateGroups {
+		spaceGroups[filepath.Join(fo.BasePath, dateKey)] = files
+	}
+	if err := fo.checkSpace(spaceGroups); err != nil {
+		return err
+	}
+
 	// Process each date group
 	for dateKey, files := range dateGroups {
 		// Create date folder
@@ -244,96 +858,126 @@ func (fo *FileOrganizer) OrganizeByDate() error {
 				continue
 			}
 
-			destPath := filepath.Join(datePath, file.Name)
+			if !fo.settled(file) || !fo.withinByteLimit(file) {
+				continue
+			}
 
-			// Check if destination file already exists
-			if _, err := os.Stat(destPath); err == nil {
-				warningColor.Printf("⚠️  File already exists at destination: %s\n", destPath)
+			destPath, ok := fo.resolveDestination(file, datePath)
+			if !ok {
 				totalSkipped++
 				continue
 			}
 
 			if fo.DryRun {
 				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, dateKey)
+				fo.Plan.Add("move", file.Path, destPath)
 			} else {
+				if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Move %s -> %s", file.Name, dateKey)) {
+					totalSkipped++
+					continue
+				}
+				if changedSinceScan(file) {
+					totalSkipped++
+					continue
+				}
 				fmt.Printf("   📁 Moving: %s\n", file.Name)
-				err := fo.atomicMove(file.Path, destPath)
+				err := fo.moveTracked(file.Path, destPath)
+				fo.Plan.Record("move", file.Path, destPath, err)
 				if err != nil {
+					if isFatalMoveError(err) {
+						return fo.rollback(err, totalMoved)
+					}
 					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", file.Name, err)
 					totalSkipped++
 					continue
 				}
 			}
 			totalMoved++
+			fo.BytesMoved += file.Size
 		}
 		fmt.Println()
 	}
 
+	fo.TotalMoved += totalMoved
+
 	if totalMoved > 0 {
 		successColor.Printf("✅ Moved %d files to date-based folders!\n", totalMoved)
 	}
 	if totalSkipped > 0 {
 		fmt.Printf("ℹ️  Skipped %d files (already in place or conflicts)\n", totalSkipped)
 	}
+	fo.reportUnmoved()
 
 	return nil
 }
 
 // OrganizeBySize organizes files into size-based folders
 func (fo *FileOrganizer) OrganizeBySize() error {
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("📏 Starting size-based organization...")
 	fmt.Println()
 
-	// Define size categories
-	sizeCategories := []struct {
-		name  string
-		min   int64
-		max   int64
-	}{
-		{"Tiny", 0, 1024 * 1024},         // < 1MB
-		{"Small", 1024 * 1024, 10 * 1024 * 1024},    // 1MB - 10MB
-		{"Medium", 10 * 1024 * 1024, 100 * 1024 * 1024}, // 10MB - 100MB
-		{"Large", 100 * 1024 * 1024, 1024 * 1024 * 1024}, // 100MB - 1GB
-		{"Huge", 1024 * 1024 * 1024, -1}, // > 1GB
-	}
-
 	totalMoved := 0
 	totalSkipped := 0
 
-	// Process each size category
-	for _, sizeCat := range sizeCategories {
-		var filesToMove []FileInfo
-		
+	// Group files by size category
+	sizeGroups := make(map[string][]FileInfo)
+	for _, sizeCat := range fo.SizeBuckets {
 		for _, file := range fo.Scanner.Files {
-			if file.IsDuplicate {
+			if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
 				continue
 			}
 
-			if (sizeCat.min == -1 || file.Size >= sizeCat.min) && 
-			   (sizeCat.max == -1 || file.Size < sizeCat.max) {
-				filesToMove = append(filesToMove, file)
+			if (sizeCat.Min == -1 || file.Size >= sizeCat.Min) && 
+			   (sizeCat.Max == -1 || file.Size < sizeCat.Max) {
+				sizeGroups[sizeCat.Name] = append(sizeGroups[sizeCat.Name], file)
+			}
+		}
+	}
+
+	// Pack the smallest files into archives rather than moving them one by one
+	if fo.PackSmallest && len(fo.SizeBuckets) > 0 {
+		bucket := fo.SizeBuckets[0].Name
+		var toPack []FileInfo
+		for _, file := range sizeGroups[bucket] {
+			if !isPackArchive(bucket, file.Name) && fo.settled(file) {
+				toPack = append(toPack, file)
+			}
+		}
+		delete(sizeGroups, bucket)
+		if len(toPack) > 0 {
+			if err := fo.PackFiles(bucket, toPack, fo.MaxPackSize); err != nil {
+				return err
 			}
 		}
+	}
 
+	// Make sure cross-device moves won't run out of space halfway
+	spaceGroups := make(map[string][]FileInfo)
+	for name, files := range sizeGroups {
+		spaceGroups[fo.folderPath(name)] = files
+	}
+	if err := fo.checkSpace(spaceGroups); err != nil {
+		return err
+	}
+
+	// Process each size category
+	for _, sizeCat := range fo.SizeBuckets {
+		filesToMove := sizeGroups[sizeCat.Name]
 		if len(filesToMove) == 0 {
 			continue
 		}
 
 		// Create size folder
-		sizePath := filepath.Join(fo.BasePath, sizeCat.name)
+		sizePath := fo.folderPath(sizeCat.Name)
 		if !fo.DryRun {
 			err := os.MkdirAll(sizePath, 0755)
 			if err != nil {
-				warningColor.Printf("⚠️  Failed to create folder %s: %v\n", sizeCat.name, err)
+				warningColor.Printf("⚠️  Failed to create folder %s: %v\n", sizeCat.Name, err)
 				continue
 			}
 		}
 
-		infoColor.Printf("📏 Processing %s files (%d files)...\n", sizeCat.name, len(filesToMove))
+		infoColor.Printf("📏 Processing %s files (%d files)...\n", sizeCat.Name, len(filesToMove))
 
 		// Move each file to its size folder
 		for _, file := range filesToMove {
@@ -343,47 +987,204 @@ func (fo *FileOrganizer) OrganizeBySize() error {
 				continue
 			}
 
-			destPath := filepath.Join(sizePath, file.Name)
+			if !fo.settled(file) || !fo.withinByteLimit(file) {
+				continue
+			}
 
-			// Check if destination file already exists
-			if _, err := os.Stat(destPath); err == nil {
-				warningColor.Printf("⚠️  File already exists at destination: %s\n", destPath)
+			destPath, ok := fo.resolveDestination(file, sizePath)
+			if !ok {
 				totalSkipped++
 				continue
 			}
 
 			if fo.DryRun {
-				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, sizeCat.name)
+				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, sizeCat.Name)
+				fo.Plan.Add("move", file.Path, destPath)
 			} else {
+				if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Move %s -> %s", file.Name, sizeCat.Name)) {
+					totalSkipped++
+					continue
+				}
+				if changedSinceScan(file) {
+					totalSkipped++
+					continue
+				}
 				fmt.Printf("   📁 Moving: %s\n", file.Name)
-				err := fo.atomicMove(file.Path, destPath)
+				err := fo.moveTracked(file.Path, destPath)
+				fo.Plan.Record("move", file.Path, destPath, err)
 				if err != nil {
+					if isFatalMoveError(err) {
+						return fo.rollback(err, totalMoved)
+					}
 					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", file.Name, err)
 					totalSkipped++
 					continue
 				}
 			}
 			totalMoved++
+			fo.BytesMoved += file.Size
 		}
 		fmt.Println()
 	}
 
+	fo.TotalMoved += totalMoved
+
 	if totalMoved > 0 {
 		successColor.Printf("✅ Moved %d files to size-based folders!\n", totalMoved)
 	}
 	if totalSkipped > 0 {
 		fmt.Printf("ℹ️  Skipped %d files (already in place or conflicts)\n", totalSkipped)
 	}
+	fo.reportUnmoved()
+
+	return nil
+}
+
+// OrganizeByTag organizes files into folders named after their primary macOS Finder tag
+func (fo *FileOrganizer) OrganizeByTag() error {
+	if !xattrSupported {
+		return fmt.Errorf("organizing by Finder tag is only supported on macOS")
+	}
+
+	fmt.Println("🏷️  Starting tag-based organization...")
+	fmt.Println()
+
+	// Group files by their primary tag
+	tagGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+
+		tags, err := readFinderTags(file.Path)
+		if err != nil {
+			warningColor.Printf("⚠️  Could not read tags for %s: %v\n", file.Name, err)
+		}
+		tagKey := primaryTagFolder(tags)
+		tagGroups[tagKey] = append(tagGroups[tagKey], file)
+	}
+
+	return fo.moveGroups(tagGroups, "🏷️ ", "tag-based")
+}
+
+// OrganizeBySource organizes files into folders named after the website they were downloaded from
+func (fo *FileOrganizer) OrganizeBySource() error {
+	if !xattrSupported {
+		return fmt.Errorf("organizing by download source is only supported on macOS")
+	}
+
+	fmt.Println("🌐 Starting source-based organization...")
+	fmt.Println()
+
+	// Group files by the domain they came from
+	sourceGroups := make(map[string][]FileInfo)
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+
+		urls, err := readWhereFroms(file.Path)
+		if err != nil {
+			warningColor.Printf("⚠️  Could not read download source for %s: %v\n", file.Name, err)
+		}
+		sourceKey := sourceDomainFolder(urls)
+		sourceGroups[sourceKey] = append(sourceGroups[sourceKey], file)
+	}
+
+	return fo.moveGroups(sourceGroups, "🌐", "source-based")
+}
+
+// moveGroups moves files into folders under the base path, given as a map of folder name to files
+func (fo *FileOrganizer) moveGroups(groups map[string][]FileInfo, icon, kind string) error {
+	totalMoved := 0
+	totalSkipped := 0
+
+	// Make sure cross-device moves won't run out of space halfway
+	spaceGroups := make(map[string][]FileInfo)
+	for folderName, files := range groups {
+		spaceGroups[fo.folderPath(folderName)] = files
+	}
+	if err := fo.checkSpace(spaceGroups); err != nil {
+		return err
+	}
+
+	// Process each group
+	for folderName, files := range groups {
+		// Create the group's folder
+		folderPath := fo.folderPath(folderName)
+		if !fo.DryRun {
+			err := os.MkdirAll(folderPath, 0755)
+			if err != nil {
+				warningColor.Printf("⚠️  Failed to create folder %s: %v\n", folderName, err)
+				continue
+			}
+		}
+
+		infoColor.Printf("%s Processing %s (%d files)...\n", icon, folderName, len(files))
+
+		// Move each file to its group's folder
+		for _, file := range files {
+			// Skip files that are already in the correct folder
+			if filepath.Dir(file.Path) == folderPath {
+				totalSkipped++
+				continue
+			}
+
+			if !fo.settled(file) || !fo.withinByteLimit(file) {
+				continue
+			}
+
+			destPath, ok := fo.resolveDestination(file, folderPath)
+			if !ok {
+				totalSkipped++
+				continue
+			}
+
+			if fo.DryRun {
+				fmt.Printf("   📁 Would move: %s -> %s\n", file.Name, folderName)
+				fo.Plan.Add("move", file.Path, destPath)
+			} else {
+				if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Move %s -> %s", file.Name, folderName)) {
+					totalSkipped++
+					continue
+				}
+				if changedSinceScan(file) {
+					totalSkipped++
+					continue
+				}
+				fmt.Printf("   📁 Moving: %s\n", file.Name)
+				err := fo.moveTracked(file.Path, destPath)
+				fo.Plan.Record("move", file.Path, destPath, err)
+				if err != nil {
+					if isFatalMoveError(err) {
+						return fo.rollback(err, totalMoved)
+					}
+					warningColor.Printf("   ⚠️  Failed to move %s: %v\n", file.Name, err)
+					totalSkipped++
+					continue
+				}
+			}
+			totalMoved++
+			fo.BytesMoved += file.Size
+		}
+		fmt.Println()
+	}
+
+	fo.TotalMoved += totalMoved
+
+	if totalMoved > 0 {
+		successColor.Printf("✅ Moved %d files to %s folders!\n", totalMoved, kind)
+	}
+	if totalSkipped > 0 {
+		fmt.Printf("ℹ️  Skipped %d files (already in place or conflicts)\n", totalSkipped)
+	}
+	fo.reportUnmoved()
 
 	return nil
 }
 
 // ProcessZipFiles processes zip files and organizes their contents
 func (fo *FileOrganizer) ProcessZipFiles() error {
-	successColor := color.New(color.FgGreen, color.Bold)
-	warningColor := color.New(color.FgYellow)
-	infoColor := color.New(color.FgCyan)
-
 	fmt.Println("📦 Starting zip file processing...")
 	fmt.Println()
 
@@ -397,31 +1198,22 @@ func (fo *FileOrganizer) ProcessZipFiles() error {
 		return nil
 	}
 
-	for _, zipFile := range zipFiles {
-		if zipFile.IsDuplicate {
+	// Inspect the zips in parallel, then move them one at a time in order
+	analyses := fo.analyzeZipFiles(zipFiles)
+
+	for i, zipFile := range zipFiles {
+		if zipFile.IsDuplicate || !fo.settled(zipFile) {
 			continue
 		}
 
 		infoColor.Printf("📦 Processing zip file: %s\n", zipFile.Name)
 
-		// Check for zip bomb before processing
-		if err := fo.checkZipBomb(zipFile.Path); err != nil {
-			warningColor.Printf("⚠️  Skipping suspicious zip file %s: %v\n", zipFile.Name, err)
-			totalSkipped++
-			continue
-		}
-
-		// Open the zip file
-		r, err := zip.OpenReader(zipFile.Path)
+		category, err := analyses[i].category, analyses[i].err
 		if err != nil {
-			warningColor.Printf("⚠️  Failed to open zip file %s: %v\n", zipFile.Name, err)
+			warningColor.Printf("⚠️  Skipping zip file %s: %v\n", zipFile.Name, err)
 			totalSkipped++
 			continue
 		}
-		defer r.Close()
-
-		// Analyze zip contents to determine the best category
-		category := fo.analyzeZipContents(&r.Reader)
 		infoColor.Printf("   📂 Zip appears to contain: %s\n", category)
 
 		// Create category folder if it doesn't exist
@@ -430,7 +1222,7 @@ func (fo *FileOrganizer) ProcessZipFiles() error {
 			folderName = "Other"
 		}
 
-		categoryPath := filepath.Join(fo.BasePath, folderName)
+		categoryPath := fo.folderPath(folderName)
 		if !fo.DryRun {
 			err := os.MkdirAll(categoryPath, 0755)
 			if err != nil {
@@ -445,10 +1237,23 @@ func (fo *FileOrganizer) ProcessZipFiles() error {
 
 		if fo.DryRun {
 			fmt.Printf("   📁 Would move: %s -> %s\n", zipFile.Name, folderName)
+			fo.Plan.Add("move", zipFile.Path, destPath)
 		} else {
+			if !fo.Deadline.Allow(zipFile.Path) || !fo.Approver.Approve(fmt.Sprintf("Move %s -> %s", zipFile.Name, folderName)) {
+				totalSkipped++
+				continue
+			}
+			if changedSinceScan(zipFile) {
+				totalSkipped++
+				continue
+			}
 			fmt.Printf("   📁 Moving: %s\n", zipFile.Name)
-			err := fo.atomicMove(zipFile.Path, destPath)
+			err := fo.moveTracked(zipFile.Path, destPath)
+			fo.Plan.Record("move", zipFile.Path, destPath, err)
 			if err != nil {
+				if isFatalMoveError(err) {
+					return fo.rollback(err, totalProcessed)
+				}
 				warningColor.Printf("   ⚠️  Failed to move %s: %v\n", zipFile.Name, err)
 				totalSkipped++
 				continue
@@ -458,6 +1263,8 @@ func (fo *FileOrganizer) ProcessZipFiles() error {
 		fmt.Println()
 	}
 
+	fo.TotalMoved += totalProcessed
+
 	if totalProcessed > 0 {
 		successColor.Printf("✅ Processed %d zip files!\n", totalProcessed)
 	}
@@ -468,8 +1275,227 @@ func (fo *FileOrganizer) ProcessZipFiles() error {
 	return nil
 }
 
-// analyzeZipContents analyzes the contents of a zip file to determine its category
-func (fo *FileOrganizer) analyzeZipContents(r *zip.Reader) string {
+// zipAnalysis is the outcome of inspecting one zip file
+type zipAnalysis struct {
+	category string
+	err      error
+}
+
+// analyzeZipFiles inspects zip files with up to ZipWorkers at a time,
+// returning the results in the same order. Duplicates are left out.
+func (fo *FileOrganizer) analyzeZipFiles(zipFiles []FileInfo) []zipAnalysis {
+	workers := fo.ZipWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	analyses := make([]zipAnalysis, len(zipFiles))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, zipFile := range zipFiles {
+		if zipFile.IsDuplicate {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			category, err := fo.analyzeZipFile(path)
+			analyses[i] = zipAnalysis{category: category, err: err}
+		}(i, zipFile.Path)
+	}
+	wg.Wait()
+	return analyses
+}
+
+// analyzeZipFile checks a zip for signs of a zip bomb and works out which
+// category its contents belong to, closing it before returning
+func (fo *FileOrganizer) analyzeZipFile(path string) (string, error) {
+	// RAR archives can't be opened, but their headers list what's inside
+	if strings.ToLower(filepath.Ext(path)) == ".rar" {
+		maxEntries := fo.MaxZipEntries
+		if fo.AllowLargeZips {
+			maxEntries = 0
+		}
+		names, err := listRarEntries(path, maxEntries)
+		if err != nil {
+			return "", fmt.Errorf("cannot read RAR file list: %v", err)
+		}
+		return dominantArchiveCategory(names), nil
+	}
+
+	if err := fo.checkZipBomb(path); err != nil {
+		return "", fmt.Errorf("looks suspicious: %v", err)
+	}
+
+	open := openZip
+	if fo.openZip != nil {
+		open = fo.openZip
+	}
+	r, err := open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open zip file: %v", err)
+	}
+	defer r.Close()
+
+	return fo.analyzeZipContents(r.Reader)
+}
+
+// zipHandle is an open zip file
+type zipHandle struct {
+	*zip.Reader
+	io.Closer
+}
+
+// openZip opens a zip file for reading
+func openZip(path string) (*zipHandle, error) {
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &zipHandle{Reader: &rc.Reader, Closer: rc}, nil
+}
+
+// analyzeZipContents analyzes the contents of a zip file to determine its
+// category, counting the files in archives inside it down to NestedArchiveDepth
+// levels. A nested archive that fails the zip bomb checks fails the whole zip.
+func (fo *FileOrganizer) analyzeZipContents(r *zip.Reader) (string, error) {
+	names, err := fo.zipEntryNames(r, fo.NestedArchiveDepth)
+	if err != nil {
+		return "", err
+	}
+	return dominantArchiveCategory(names), nil
+}
+
+// zipEntryNames lists the files in a zip, with any zips and tarballs in it
+// replaced by the files they contain while depth is above 0
+func (fo *FileOrganizer) zipEntryNames(r *zip.Reader, depth int) ([]string, error) {
+	var names []string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if depth > 0 && isNestedArchive(f.Name) {
+			nested, err := fo.nestedArchiveNames(f, depth-1)
+			if err != nil {
+				return nil, fmt.Errorf("nested archive %s: %v", f.Name, err)
+			}
+			names = append(names, nested...)
+			continue
+		}
+		names = append(names, f.Name)
+	}
+	return names, nil
+}
+
+// isNestedArchive reports whether a zip entry is an archive analyzeZipContents can look into
+func isNestedArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedArchiveNames reads an archive stored in a zip into memory and lists
+// the files in it, applying the same zip bomb checks as to the outer zip. An
+// archive that can't be read is listed under its own name.
+func (fo *FileOrganizer) nestedArchiveNames(f *zip.File, depth int) ([]string, error) {
+	limit := fo.MaxZipSize
+	if fo.AllowLargeZips {
+		limit = 0
+	}
+	if limit > 0 && f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("too large (%d bytes), max allowed: %d bytes", f.UncompressedSize64, limit)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return []string{f.Name}, nil
+	}
+	defer rc.Close()
+
+	// The size in the header can be wrong, so stop reading past the limit as well
+	var reader io.Reader = rc
+	if limit > 0 {
+		reader = io.LimitReader(rc, limit+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return []string{f.Name}, nil
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return nil, fmt.Errorf("too large, max allowed: %d bytes", limit)
+	}
+
+	lower := strings.ToLower(f.Name)
+	if strings.HasSuffix(lower, ".zip") {
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return []string{f.Name}, nil
+		}
+		if !fo.AllowLargeZips {
+			if err := fo.checkZipEntries(r.File); err != nil {
+				return nil, err
+			}
+		}
+		return fo.zipEntryNames(r, depth)
+	}
+
+	var tarData io.Reader = bytes.NewReader(data)
+	if !strings.HasSuffix(lower, ".tar") {
+		gz, err := gzip.NewReader(tarData)
+		if err != nil {
+			return []string{f.Name}, nil
+		}
+		defer gz.Close()
+		tarData = gz
+	}
+	return fo.tarEntryNames(tarData, f.Name)
+}
+
+// tarEntryNames lists the files in a tarball, stopping at the same entry and
+// expansion limits as checkZipEntries. Archives inside it aren't looked into.
+func (fo *FileOrganizer) tarEntryNames(r io.Reader, name string) ([]string, error) {
+	var names []string
+	totalSize := int64(0)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if len(names) == 0 {
+				return []string{name}, nil
+			}
+			break
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		names = append(names, header.Name)
+
+		if fo.AllowLargeZips {
+			continue
+		}
+		if fo.MaxZipEntries > 0 && len(names) > fo.MaxZipEntries {
+			return nil, fmt.Errorf("tarball has too many entries (%d), max allowed: %d", len(names), fo.MaxZipEntries)
+		}
+		totalSize += header.Size
+		if fo.MaxZipSize > 0 && totalSize > fo.MaxZipSize*10 {
+			return nil, fmt.Errorf("tarball would expand to too large size (%d bytes)", totalSize)
+		}
+	}
+	return names, nil
+}
+
+// dominantArchiveCategory works out which category most of the files in an
+// archive belong to, from their names
+func dominantArchiveCategory(names []string) string {
 	imageCount := 0
 	documentCount := 0
 	videoCount := 0
@@ -478,21 +1504,17 @@ func (fo *FileOrganizer) analyzeZipContents(r *zip.Reader) string {
 	fontCount := 0
 	codeCount := 0
 
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-
-		ext := strings.ToLower(filepath.Ext(f.Name))
+	for _, name := range names {
+		ext := strings.ToLower(filepath.Ext(name))
 		
 		switch ext {
-		case ".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".svg", ".webp":
+		case ".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".svg", ".webp", ".avif", ".heic", ".heif", ".jxl":
 			imageCount++
 		case ".pdf", ".doc", ".docx", ".txt", ".rtf", ".odt", ".xls", ".xlsx", ".ppt", ".pptx":
 			documentCount++
-		case ".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm":
+		case ".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".3gp", ".3g2", ".hevc", ".av1":
 			videoCount++
-		case ".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma":
+		case ".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".opus", ".m4a", ".aiff":
 			audioCount++
 		case ".exe", ".msi", ".dmg", ".pkg", ".app", ".deb", ".rpm":
 			applicationCount++
@@ -553,17 +1575,27 @@ func (fo *FileOrganizer) copyAndDelete(src, dst string) error {
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
 
-	// Copy file content
-	_, err = io.Copy(dstFile, srcFile)
+	// Copy file content and sync to ensure data is written. A partial copy,
+	// say from a full disk, is removed so it isn't mistaken for a conflicting
+	// file on the next run.
+	_, err = io.Copy(dstFile, fo.Throttle.Reader(srcFile))
+	if err == nil {
+		err = dstFile.Sync()
+	}
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		os.Remove(dst)
 		return err
 	}
 
-	// Sync to ensure data is written
-	if err := dstFile.Sync(); err != nil {
-		return err
+	// A rename keeps extended attributes, but a copy has to carry them over
+	if fo.PreserveXattrs {
+		if err := copyXattrs(src, dst); err != nil {
+			warningColor.Printf("   ⚠️  %s: %v\n", filepath.Base(dst), err)
+		}
 	}
 
 	// Delete source file
@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openFilesUnder lists the files under dir this process has open
+func openFilesUnder(t *testing.T, dir string) []string {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skipf("Cannot list open files: %v", err)
+	}
+	var open []string
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+		if err == nil && strings.HasPrefix(target, dir+string(filepath.Separator)) {
+			open = append(open, target)
+		}
+	}
+	return open
+}
+
+func TestProcessZipFilesClosesEachZip(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	for i := 0; i < 6; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("photos-%d.zip", i))
+		if err := createTestZip(path, map[string]string{"a.jpg": "image", "b.png": "image"}); err != nil {
+			t.Fatalf("Failed to create test zip: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.ZipWorkers = 3
+
+	moves := 0
+	organizer.moveFile = func(src, dst string) error {
+		// Every zip should be closed again by the time anything is moved
+		if open := openFilesUnder(t, tmpDir); len(open) > 0 {
+			t.Errorf("Zips still open while moving %s: %v", filepath.Base(src), open)
+		}
+		moves++
+		return os.Rename(src, dst)
+	}
+	if err := organizer.ProcessZipFiles(); err != nil {
+		t.Fatalf("ProcessZipFiles() error = %v", err)
+	}
+
+	if moves != 6 {
+		t.Errorf("Expected 6 zips moved, got %d", moves)
+	}
+	for i := 0; i < 6; i++ {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Images", fmt.Sprintf("photos-%d.zip", i))); err != nil {
+			t.Errorf("Expected photos-%d.zip in Images: %v", i, err)
+		}
+	}
+}
@@ -3,9 +3,14 @@ package main
 import (
 	"archive/zip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestFileOrganizer(t *testing.T) {
@@ -153,6 +158,320 @@ func TestOrganizeBySize(t *testing.T) {
 	}
 }
 
+func TestOrganizeFilesMergeRerun(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// First run organizes the initial files
+	initial := map[string]string{
+		"report.pdf": "first report",
+		"photo.jpg":  "first photo",
+	}
+	for name, content := range initial {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if err := NewFileOrganizer(scanner, false, tmpDir).OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// New downloads reuse the same names with different content
+	later := map[string]string{
+		"report.pdf": "second report",
+		"photo.jpg":  "second photo",
+		"song.mp3":   "new song",
+	}
+	for name, content := range later {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner = NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.DestExistsStrategy = DestExistsMerge
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// No loose files should remain at the root
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			t.Errorf("Loose file left at root after merge: %s", entry.Name())
+		}
+	}
+
+	// The conflicting file should have been renamed, not overwritten
+	content, err := os.ReadFile(filepath.Join(tmpDir, "Documents", "report (1).pdf"))
+	if err != nil {
+		t.Fatalf("Expected renamed file in Documents: %v", err)
+	}
+	if string(content) != "second report" {
+		t.Errorf("Renamed file has wrong content: %s", string(content))
+	}
+	content, err = os.ReadFile(filepath.Join(tmpDir, "Documents", "report.pdf"))
+	if err != nil {
+		t.Fatalf("Original file missing from Documents: %v", err)
+	}
+	if string(content) != "first report" {
+		t.Errorf("Original file was overwritten: %s", string(content))
+	}
+}
+
+func TestResolveDestinationIdentical(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "Documents")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "notes.txt"), []byte("same"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	hash, err := scanner.calculateFileHash(filepath.Join(destDir, "notes.txt"))
+	if err != nil {
+		t.Fatalf("calculateFileHash() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, true, tmpDir)
+	organizer.DestExistsStrategy = DestExistsMerge
+
+	file := FileInfo{Path: filepath.Join(tmpDir, "notes.txt"), Name: "notes.txt", Hash: hash}
+	if err := os.WriteFile(file.Path, []byte("same"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, ok := organizer.resolveDestination(file, destDir); ok {
+		t.Error("Expected identical file to be skipped")
+	}
+
+	// When the scan skipped hashing, the source is hashed on demand
+	scanner.SkipHashing = true
+	file.Hash = ""
+	if _, ok := organizer.resolveDestination(file, destDir); ok {
+		t.Error("Expected identical unhashed file to be skipped")
+	}
+}
+
+func TestOrganizeFilesIdenticalDestRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "Documents")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, path := range []string{filepath.Join(destDir, "report.pdf"), filepath.Join(tmpDir, "report.pdf")} {
+		if err := os.WriteFile(path, []byte("quarterly numbers"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to scan directory: %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.DestExistsStrategy = DestExistsMerge
+	organizer.IdenticalDestStrategy = IdenticalDestRemove
+	organizer.Plan = &Plan{}
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// The source is removed as a duplicate instead of becoming a "(1)" copy
+	if _, err := os.Stat(filepath.Join(destDir, "report (1).pdf")); !os.IsNotExist(err) {
+		t.Error("Expected no (1) copy of an identical file")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "report.pdf")); !os.IsNotExist(err) {
+		t.Error("Expected the identical source file to be removed")
+	}
+	if content, err := os.ReadFile(filepath.Join(destDir, "report.pdf")); err != nil || string(content) != "quarterly numbers" {
+		t.Errorf("Expected the existing file to be kept, got %q (%v)", content, err)
+	}
+	if len(organizer.Plan.Results) != 1 || organizer.Plan.Results[0].Action != "remove" || organizer.Plan.Results[0].Status != "done" {
+		t.Errorf("Expected one successful removal recorded, got %+v", organizer.Plan.Results)
+	}
+}
+
+func TestSameContentNeedsSeparateFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "report.pdf")
+	if err := os.WriteFile(source, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	organizer := NewFileOrganizer(NewScanner(), false, tmpDir)
+	file := FileInfo{Path: source, Name: "report.pdf"}
+
+	copyPath := filepath.Join(tmpDir, "copy.pdf")
+	if err := os.WriteFile(copyPath, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if !organizer.sameContent(file, copyPath) {
+		t.Error("Expected a separate copy to count as the same content")
+	}
+
+	// A hard link is the same file, so removing the source as a duplicate would lose nothing but a name
+	hardLink := filepath.Join(tmpDir, "hardlink.pdf")
+	if err := os.Link(source, hardLink); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+	if organizer.sameContent(file, hardLink) {
+		t.Error("Expected a hard link to the source not to count as a copy")
+	}
+
+	// Removing the source would leave a symlink pointing nowhere
+	symlink := filepath.Join(tmpDir, "symlink.pdf")
+	if err := os.Symlink(source, symlink); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if organizer.sameContent(file, symlink) {
+		t.Error("Expected a symlink to the source not to count as a copy")
+	}
+}
+
+func TestOrganizeFilesIdenticalDestRemoveKeepsSameFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "Documents")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	source := filepath.Join(tmpDir, "report.pdf")
+	if err := os.WriteFile(source, []byte("quarterly numbers"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to scan directory: %v", err)
+	}
+	// The destination becomes a symlink to the source after the scan
+	if err := os.Symlink(source, filepath.Join(destDir, "report.pdf")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.DestExistsStrategy = DestExistsMerge
+	organizer.IdenticalDestStrategy = IdenticalDestRemove
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// The source is the only copy, so it is moved next to the link rather than removed
+	if content, err := os.ReadFile(filepath.Join(destDir, "report (1).pdf")); err != nil || string(content) != "quarterly numbers" {
+		t.Errorf("Expected the source to be kept as report (1).pdf, got %q (%v)", content, err)
+	}
+}
+
+func TestOrganizeFilesRollbackOnFatalError(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	names := []string{"image.jpg", "document.pdf", "video.mp4", "music.mp3"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Let two moves succeed, then fail as if the disk filled up
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	calls := 0
+	organizer.moveFile = func(src, dst string) error {
+		calls++
+		if calls > 2 {
+			return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.ENOSPC}
+		}
+		return organizer.atomicMove(src, dst)
+	}
+
+	if err := organizer.OrganizeFiles(); err == nil {
+		t.Fatal("Expected OrganizeFiles() to fail on a fatal move error")
+	}
+	if calls != 3 {
+		t.Errorf("Expected organizing to stop after the fatal error, got %d move attempts", calls)
+	}
+
+	// Every file should be back where it started
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("File %s was not moved back to the root: %v", name, err)
+		}
+	}
+}
+
+func TestOrganizeFilesRollbackKeepsNewFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"image.jpg", "document.pdf", "video.mp4"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// A new file turns up where the first one was moved from before the disk fills up
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	var first fileMove
+	calls := 0
+	organizer.moveFile = func(src, dst string) error {
+		calls++
+		if calls == 1 {
+			first = fileMove{Src: src, Dst: dst}
+		}
+		if calls > 2 {
+			if err := os.WriteFile(first.Src, []byte("newer download"), 0644); err != nil {
+				t.Fatalf("Failed to create new file: %v", err)
+			}
+			return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.ENOSPC}
+		}
+		return organizer.atomicMove(src, dst)
+	}
+
+	if err := organizer.OrganizeFiles(); err == nil {
+		t.Fatal("Expected OrganizeFiles() to fail on a fatal move error")
+	}
+
+	if content, err := os.ReadFile(first.Src); err != nil || string(content) != "newer download" {
+		t.Errorf("Expected the new file to be left alone, got %q (%v)", content, err)
+	}
+	if _, err := os.Stat(first.Dst); err != nil {
+		t.Errorf("Expected the first file to stay where it was moved: %v", err)
+	}
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected the one move that wasn't undone to be counted, got %d", organizer.TotalMoved)
+	}
+}
+
+func TestIsFatalMoveError(t *testing.T) {
+	fatal := &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOSPC}
+	if !isFatalMoveError(fatal) {
+		t.Error("Expected out of space to be fatal")
+	}
+
+	perFile := &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOENT}
+	if isFatalMoveError(perFile) {
+		t.Error("Expected missing file not to be fatal")
+	}
+}
+
 func TestCheckZipBomb(t *testing.T) {
 	organizer := NewFileOrganizer(nil, true, "")
 
@@ -187,6 +506,64 @@ func TestCheckZipBomb(t *testing.T) {
 	}
 }
 
+func TestCheckZipBombRaisedLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	largeZip := filepath.Join(tmpDir, "backup.zip")
+	if err := createLargeTestZip(largeZip); err != nil {
+		t.Fatalf("Failed to create large zip: %v", err)
+	}
+
+	// Blocked at the default limits
+	organizer := NewFileOrganizer(nil, true, "")
+	if err := organizer.checkZipBomb(largeZip); err == nil {
+		t.Error("Expected error for large zip file at default limits")
+	}
+
+	// Allowed when the entry limit is raised
+	organizer.MaxZipEntries = 30000
+	if err := organizer.checkZipBomb(largeZip); err != nil {
+		t.Errorf("checkZipBomb() error with raised limit: %v", err)
+	}
+
+	// Allowed when the checks are disabled
+	organizer = NewFileOrganizer(nil, true, "")
+	organizer.AllowLargeZips = true
+	if err := organizer.checkZipBomb(largeZip); err != nil {
+		t.Errorf("checkZipBomb() error with checks disabled: %v", err)
+	}
+}
+
+func TestProcessZipFilesRaisedLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	largeZip := filepath.Join(tmpDir, "backup.zip")
+	if err := createLargeTestZip(largeZip); err != nil {
+		t.Fatalf("Failed to create large zip: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// At the default limits the zip is skipped
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.ProcessZipFiles(); err != nil {
+		t.Fatalf("ProcessZipFiles() error = %v", err)
+	}
+	if _, err := os.Stat(largeZip); err != nil {
+		t.Fatalf("Zip file was moved at default limits: %v", err)
+	}
+
+	// With a raised limit it is moved to the category of its contents
+	organizer.MaxZipEntries = 30000
+	if err := organizer.ProcessZipFiles(); err != nil {
+		t.Fatalf("ProcessZipFiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "backup.zip")); err != nil {
+		t.Errorf("Zip file was not processed with raised limit: %v", err)
+	}
+}
+
 func TestAnalyzeZipContents(t *testing.T) {
 	organizer := NewFileOrganizer(nil, true, "")
 
@@ -212,12 +589,61 @@ func TestAnalyzeZipContents(t *testing.T) {
 	defer r.Close()
 
 	// Test zip content analysis
-	category := organizer.analyzeZipContents(&r.Reader)
+	category, err := organizer.analyzeZipContents(&r.Reader)
+	if err != nil {
+		t.Fatalf("analyzeZipContents() error = %v", err)
+	}
 	if category != "Images" {
 		t.Errorf("Expected category 'Images', got '%s'", category)
 	}
 }
 
+func TestAnalyzeZipContentsNested(t *testing.T) {
+	tmpDir := t.TempDir()
+	innerZip := filepath.Join(tmpDir, "inner.zip")
+	if err := createTestZip(innerZip, map[string]string{
+		"photo1.jpg": "fake image data",
+		"photo2.jpg": "fake image data",
+		"photo3.png": "fake image data",
+	}); err != nil {
+		t.Fatalf("Failed to create test zip: %v", err)
+	}
+	inner, err := os.ReadFile(innerZip)
+	if err != nil {
+		t.Fatalf("Failed to read test zip: %v", err)
+	}
+	outerZip := filepath.Join(tmpDir, "outer.zip")
+	if err := createTestZip(outerZip, map[string]string{
+		"photos.zip": string(inner),
+		"notes.txt":  "fake text data",
+	}); err != nil {
+		t.Fatalf("Failed to create test zip: %v", err)
+	}
+
+	organizer := NewFileOrganizer(nil, true, "")
+	r, err := zip.OpenReader(outerZip)
+	if err != nil {
+		t.Fatalf("Failed to open test zip: %v", err)
+	}
+	defer r.Close()
+
+	// Only the top level counts by default
+	if category, err := organizer.analyzeZipContents(&r.Reader); err != nil || category != "Documents" {
+		t.Errorf("Expected 'Documents' without looking into nested archives, got %q (err %v)", category, err)
+	}
+
+	organizer.NestedArchiveDepth = 1
+	if category, err := organizer.analyzeZipContents(&r.Reader); err != nil || category != "Images" {
+		t.Errorf("Expected 'Images' from the nested zip, got %q (err %v)", category, err)
+	}
+
+	// The zip bomb checks apply to the nested zip too
+	organizer.MaxZipSize = int64(len(inner)) - 1
+	if _, err := organizer.analyzeZipContents(&r.Reader); err == nil {
+		t.Error("Expected a nested zip over the size limit to be rejected")
+	}
+}
+
 func TestOrganizerAtomicMove(t *testing.T) {
 	organizer := NewFileOrganizer(nil, true, "")
 
@@ -289,6 +715,28 @@ func TestOrganizerCopyAndDelete(t *testing.T) {
 	}
 }
 
+func TestOrganizerCopyAndDeleteFailure(t *testing.T) {
+	organizer := NewFileOrganizer(nil, false, "")
+	tmpDir := t.TempDir()
+
+	// Reading a folder fails partway, like a copy that runs out of space
+	src := filepath.Join(tmpDir, "not-a-file")
+	if err := os.Mkdir(src, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	dst := filepath.Join(tmpDir, "destination.txt")
+	if err := organizer.copyAndDelete(src, dst); err == nil {
+		t.Fatal("Expected copyAndDelete() to fail")
+	}
+
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("Expected the partial destination to be removed")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("Expected the source to be left in place: %v", err)
+	}
+}
+
 // Helper functions for creating test zip files
 func createTestZip(zipPath string, files map[string]string) error {
 	zipFile, err := os.Create(zipPath)
@@ -338,4 +786,487 @@ func createLargeTestZip(zipPath string) error {
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
+
+func TestOrganizeFilesSkipsChangedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"stable.pdf", "growing.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("initial content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// The file is still being written after the scan
+	growing := filepath.Join(tmpDir, "growing.pdf")
+	if err := os.WriteFile(growing, []byte("initial content, and then some more"), 0644); err != nil {
+		t.Fatalf("Failed to modify test file: %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if _, err := os.Stat(growing); err != nil {
+		t.Errorf("Expected changed file to be left in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "stable.pdf")); err != nil {
+		t.Errorf("Expected unchanged file to be moved: %v", err)
+	}
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected 1 file moved, got %d", organizer.TotalMoved)
+	}
+}
+
+func TestOrganizeFilesCategoryFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		only  []string
+		skip  []string
+		moved []string
+		kept  []string
+	}{
+		{"only", []string{"images", "Videos"}, nil, []string{"Images/photo.jpg", "Videos/clip.mp4"}, []string{"report.pdf", "song.mp3"}},
+		{"skip", nil, []string{"Documents"}, []string{"Images/photo.jpg", "Videos/clip.mp4", "Music/song.mp3"}, []string{"report.pdf"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, name := range []string{"photo.jpg", "clip.mp4", "report.pdf", "song.mp3"} {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+					t.Fatalf("Failed to create test file %s: %v", name, err)
+				}
+			}
+
+			scanner := NewScanner()
+			if err := scanner.ScanDirectory(tmpDir); err != nil {
+				t.Fatalf("ScanDirectory() error = %v", err)
+			}
+
+			organizer := NewFileOrganizer(scanner, false, tmpDir)
+			var err error
+			if organizer.OnlyCategories, err = organizer.parseCategories(tt.only); err != nil {
+				t.Fatalf("parseCategories() error = %v", err)
+			}
+			if organizer.SkipCategories, err = organizer.parseCategories(tt.skip); err != nil {
+				t.Fatalf("parseCategories() error = %v", err)
+			}
+			if err := organizer.OrganizeFiles(); err != nil {
+				t.Fatalf("OrganizeFiles() error = %v", err)
+			}
+
+			for _, path := range tt.moved {
+				if _, err := os.Stat(filepath.Join(tmpDir, path)); err != nil {
+					t.Errorf("Expected %s to be organized: %v", path, err)
+				}
+			}
+			for _, name := range tt.kept {
+				if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+					t.Errorf("Expected %s to stay at the root: %v", name, err)
+				}
+			}
+			// Disabled categories don't even get a folder
+			if _, err := os.Stat(filepath.Join(tmpDir, "Documents")); !os.IsNotExist(err) {
+				t.Error("Expected no Documents folder to be created")
+			}
+		})
+	}
+
+	if _, err := NewFileOrganizer(NewScanner(), true, t.TempDir()).parseCategories([]string{"Spreadsheets"}); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-output
+}
+
+func TestPreviewCategory(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"movie.mp4", "clip.mkv", "photo.jpg", "report.pdf", "song.mp3"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.PreviewCategory("videos"); err != nil {
+		t.Fatalf("PreviewCategory() error = %v", err)
+	}
+	output := captureStdout(t, func() {
+		if err := organizer.OrganizeFiles(); err != nil {
+			t.Errorf("OrganizeFiles() error = %v", err)
+		}
+	})
+
+	for _, name := range []string{"movie.mp4", "clip.mkv"} {
+		if !strings.Contains(output, "Would move: "+name) {
+			t.Errorf("Expected a planned move for %s in:\n%s", name, output)
+		}
+	}
+	for _, name := range []string{"photo.jpg", "report.pdf", "song.mp3"} {
+		if strings.Contains(output, name) {
+			t.Errorf("Expected nothing about %s in the preview:\n%s", name, output)
+		}
+	}
+
+	// It's a dry run, so nothing moved
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s to stay put in a preview: %v", name, err)
+		}
+	}
+
+	if err := organizer.PreviewCategory("Movies"); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+}
+
+// countingCloser records when a zip is closed
+type countingCloser struct {
+	io.Closer
+	onClose func()
+}
+
+func (cc countingCloser) Close() error {
+	cc.onClose()
+	return cc.Closer.Close()
+}
+
+func TestProcessZipFilesHandlesDontAccumulate(t *testing.T) {
+	tmpDir := t.TempDir()
+	const zipCount = 40
+	for i := 0; i < zipCount; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("docs-%02d.zip", i))
+		if err := createTestZip(path, map[string]string{"report.pdf": "pdf", "notes.txt": "text"}); err != nil {
+			t.Fatalf("Failed to create test zip: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, true, tmpDir)
+	organizer.ZipWorkers = 3
+
+	var mu sync.Mutex
+	opened, closed, open, mostOpen := 0, 0, 0, 0
+	organizer.openZip = func(path string) (*zipHandle, error) {
+		handle, err := openZip(path)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		opened++
+		open++
+		if open > mostOpen {
+			mostOpen = open
+		}
+		mu.Unlock()
+		handle.Closer = countingCloser{Closer: handle.Closer, onClose: func() {
+			mu.Lock()
+			closed++
+			open--
+			mu.Unlock()
+		}}
+		return handle, nil
+	}
+
+	if err := organizer.ProcessZipFiles(); err != nil {
+		t.Fatalf("ProcessZipFiles() error = %v", err)
+	}
+
+	if opened != zipCount || closed != zipCount {
+		t.Errorf("Expected %d zips opened and closed, got %d opened and %d closed", zipCount, opened, closed)
+	}
+	if mostOpen > organizer.ZipWorkers {
+		t.Errorf("Expected at most %d zips open at once, got %d", organizer.ZipWorkers, mostOpen)
+	}
+}
+
+func TestOrganizeFilesShardByHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.pdf", "b.pdf", "c.jpg", "d.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	files := append([]FileInfo(nil), scanner.Files...)
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.ShardByHash = true
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	for _, file := range files {
+		want := filepath.Join(tmpDir, organizer.CategoryMap[file.Category], file.Hash[:2], file.Name)
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("Expected %s in shard %s: %v", file.Name, file.Hash[:2], err)
+		}
+	}
+}
+
+func TestOrganizeFilesMaxBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"a.pdf", "b.pdf", "c.pdf", "d.pdf"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// Room for two files, the third would go over the cap
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.MaxBytes = 250
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if organizer.TotalMoved != 2 || organizer.BytesMoved != 200 {
+		t.Errorf("Expected 2 files and 200 bytes moved, got %d files and %d bytes", organizer.TotalMoved, organizer.BytesMoved)
+	}
+	if len(organizer.Unmoved) != 2 {
+		t.Fatalf("Expected 2 files reported as unmoved, got %d", len(organizer.Unmoved))
+	}
+	for _, file := range organizer.Unmoved {
+		if _, err := os.Stat(file.Path); err != nil {
+			t.Errorf("Unmoved file %s is no longer in place: %v", file.Name, err)
+		}
+	}
+	moved, err := os.ReadDir(filepath.Join(tmpDir, "Documents"))
+	if err != nil {
+		t.Fatalf("Failed to read Documents: %v", err)
+	}
+	if len(moved) != 2 {
+		t.Errorf("Expected 2 files in Documents, got %d", len(moved))
+	}
+}
+
+func TestOrganizeFilesSettleTime(t *testing.T) {
+	tmpDir := t.TempDir()
+	fresh := filepath.Join(tmpDir, "fresh.pdf")
+	old := filepath.Join(tmpDir, "old.pdf")
+	for _, path := range []string{fresh, old} {
+		if err := os.WriteFile(path, []byte("content of "+filepath.Base(path)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	oldTime := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(old, oldTime, oldTime); err != nil {
+		t.Fatalf("Failed to set file times: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// fresh.pdf was written moments ago and may still be downloading
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.SettleTime = 30 * time.Second
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("Expected the recently modified file to stay in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "old.pdf")); err != nil {
+		t.Errorf("Expected the settled file to be moved: %v", err)
+	}
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected 1 file moved, got %d", organizer.TotalMoved)
+	}
+}
+
+func TestOrganizeFilesSkipsLongDestinations(t *testing.T) {
+	tmpDir := t.TempDir()
+	name := strings.Repeat("r", 40) + ".pdf"
+	src := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// The file itself fits, but not once it is inside Documents/
+	scanner.MaxPathLength = len(src) + 5
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if organizer.TotalMoved != 0 {
+		t.Errorf("Expected nothing to be moved, got %d moves", organizer.TotalMoved)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("Expected %s to be left in place: %v", name, err)
+	}
+}
+
+func TestOrganizeFilesMaxPerFolder(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"e.pdf", "c.pdf", "a.pdf", "d.pdf", "b.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	organize := func() *FileOrganizer {
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(tmpDir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+		organizer := NewFileOrganizer(scanner, false, tmpDir)
+		organizer.MaxPerFolder = 2
+		if err := organizer.OrganizeFiles(); err != nil {
+			t.Fatalf("OrganizeFiles() error = %v", err)
+		}
+		return organizer
+	}
+
+	organize()
+	for _, path := range []string{"001/a.pdf", "001/b.pdf", "002/c.pdf", "002/d.pdf", "003/e.pdf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Documents", filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected Documents/%s: %v", path, err)
+		}
+	}
+
+	// Running again finds everything already in place
+	if again := organize(); again.TotalMoved != 0 {
+		t.Errorf("Expected a second run to move nothing, got %d moves", again.TotalMoved)
+	}
+
+	// New files fill the last partial subfolder before starting another
+	for _, name := range []string{"h.pdf", "f.pdf", "g.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	organize()
+	for _, path := range []string{"001/a.pdf", "002/c.pdf", "003/e.pdf", "003/f.pdf", "004/g.pdf", "004/h.pdf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Documents", filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected Documents/%s: %v", path, err)
+		}
+	}
+}
+
+func TestHashShard(t *testing.T) {
+	tests := map[string]string{
+		"d41d8cd98f00b204e9800998ecf8427e":     "d4",
+		"zip:9E107D9D372BB6826BD81D3542A419D6": "9e",
+		"":                                     "",
+	}
+	for hash, want := range tests {
+		if got := hashShard(hash); got != want {
+			t.Errorf("hashShard(%q) = %q, want %q", hash, got, want)
+		}
+	}
+}
+
+func TestOrganizeFilesReusesFolderDifferingInCase(t *testing.T) {
+	tmpDir := t.TempDir()
+	// An earlier run, or the user, made the category folder in lowercase
+	existing := filepath.Join(tmpDir, "images")
+	if err := os.MkdirAll(existing, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "photo.jpg"), []byte("fake image data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(existing, "photo.jpg")); err != nil {
+		t.Errorf("Expected photo.jpg in the existing images folder: %v", err)
+	}
+	entries, _ := os.ReadDir(tmpDir)
+	for _, entry := range entries {
+		if entry.Name() == "Images" {
+			t.Error("A second Images folder was created next to images")
+		}
+	}
+}
+
+func TestOrganizeFilesAgainScansOrganizedFolders(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"Images/old.jpg": "organized before",
+		"copy.jpg":       "organized before",
+		"new.pdf":        "new download",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	// The copy of a file organized on an earlier run is still found
+	if len(scanner.Duplicates) != 1 {
+		t.Errorf("Expected the copy of Images/old.jpg to be found, got %v", scanner.Duplicates)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	// Files already in their folder aren't moved again
+	for _, move := range organizer.moves {
+		if move.Src == filepath.Join(tmpDir, "Images", "old.jpg") {
+			t.Errorf("Expected Images/old.jpg to stay in place, moved to %s", move.Dst)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "new.pdf")); err != nil {
+		t.Errorf("Expected new.pdf to be organized: %v", err)
+	}
+}
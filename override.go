@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	categoryOverrideAttr = "user.elf.category" // Extended attribute pinning a file to a category
+	categorySidecarExt   = ".elfcat"           // Companion file holding a category, next to the file it pins
+)
+
+// readCategoryOverride returns the category a file has been pinned to and where
+// that came from, or "" if it isn't pinned. The extended attribute wins over a
+// sidecar file, and platforms without extended attributes only use sidecars.
+func readCategoryOverride(path string) (string, string) {
+	if data, err := readXattr(path, categoryOverrideAttr); err == nil && len(data) > 0 {
+		if category := strings.TrimSpace(string(data)); category != "" {
+			return category, "xattr " + categoryOverrideAttr
+		}
+	}
+
+	if data, err := os.ReadFile(path + categorySidecarExt); err == nil {
+		if category := strings.TrimSpace(string(data)); category != "" {
+			return category, categorySidecarExt + " file"
+		}
+	}
+	return "", ""
+}
+
+// knownCategory matches a category name case-insensitively against the
+// categories the scanner can produce, returning the canonical spelling
+func (s *Scanner) knownCategory(name string) (string, bool) {
+	extensionCategories := s.ExtensionCategories
+	if extensionCategories == nil {
+		extensionCategories = defaultExtensionCategories
+	}
+
+	for category := range defaultCategoryFolders() {
+		if strings.EqualFold(category, name) {
+			return category, true
+		}
+	}
+	for _, category := range extensionCategories {
+		if strings.EqualFold(category, name) {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// categoryOverride returns the category a file is pinned to, if any, with the reason
+func (s *Scanner) categoryOverride(path string) (string, string, bool) {
+	name, source := readCategoryOverride(path)
+	if name == "" {
+		return "", "", false
+	}
+
+	category, ok := s.knownCategory(name)
+	if !ok {
+		fmt.Printf("⚠️  Ignoring unknown category %q pinned to %s by %s\n", name, path, source)
+		return "", "", false
+	}
+	return category, fmt.Sprintf("pinned by %s -> %s", source, category), true
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCategoryOverrideXattr(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "installer.exe")
+	if err := os.WriteFile(path, []byte("not really an installer"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := unix.Setxattr(path, categoryOverrideAttr, []byte("Archives"), 0); err != nil {
+		t.Skipf("Extended attributes not supported here: %v", err)
+	}
+
+	// The attribute wins over a sidecar
+	if err := os.WriteFile(path+categorySidecarExt, []byte("Music"), 0644); err != nil {
+		t.Fatalf("Failed to create sidecar: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 1 || scanner.Files[0].Category != "Archives" {
+		t.Errorf("Expected installer.exe to be pinned to Archives, got %+v", scanner.Files)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCategoryOverrideSidecar(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"song.mp3":         "music",
+		"song.mp3.elfcat":  "documents\n",
+		"photo.jpg":        "image",
+		"photo.jpg.elfcat": "Nonsense",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	if len(scanner.Files) != 2 {
+		t.Fatalf("Expected sidecar files to be left out of the scan, got %d files", len(scanner.Files))
+	}
+	for _, file := range scanner.Files {
+		expected := map[string]string{"song.mp3": "Documents", "photo.jpg": "Images"}[file.Name]
+		if file.Category != expected {
+			t.Errorf("%s categorized as %s, want %s", file.Name, file.Category, expected)
+		}
+	}
+
+	// The sidecar travels with its file
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	for _, path := range []string{"Documents/song.mp3", "Documents/song.mp3.elfcat"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected %s: %v", path, err)
+		}
+	}
+}
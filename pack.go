@@ -0,0 +1,208 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultMaxPackSize is the largest amount of file data packed into one archive
+const defaultMaxPackSize = 100 * 1024 * 1024
+
+// packBatches splits files into batches whose total size stays within maxSize.
+// A file bigger than maxSize gets a batch of its own.
+func packBatches(files []FileInfo, maxSize int64) [][]FileInfo {
+	var batches [][]FileInfo
+	var batchSize int64
+	for _, file := range files {
+		if len(batches) == 0 || (maxSize > 0 && batchSize+file.Size > maxSize && batchSize > 0) {
+			batches = append(batches, nil)
+			batchSize = 0
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], file)
+		batchSize += file.Size
+	}
+	return batches
+}
+
+// packArchiveName names the nth archive for a size bucket packed on the given
+// day, like tiny-2024-03-01.zip, with -2, -3 and so on for the archives after the first
+func packArchiveName(bucket string, day time.Time, n int) string {
+	name := strings.ToLower(bucket) + "-" + day.Format("2006-01-02")
+	if n > 1 {
+		name += fmt.Sprintf("-%d", n)
+	}
+	return name + ".zip"
+}
+
+// isPackArchive reports whether name is an archive made by packing the bucket,
+// so it isn't packed again on a later run
+func isPackArchive(bucket, name string) bool {
+	matched, _ := filepath.Match(strings.ToLower(bucket)+"-????-??-??*.zip", name)
+	return matched
+}
+
+// PackFiles packs files into dated zip archives in the base path named after
+// bucket, splitting them so no archive holds more than maxSize bytes of file
+// data, then removes the originals. Originals go to the quarantine instead if
+// there is one. Nothing is removed from an archive that couldn't be written.
+func (fo *FileOrganizer) PackFiles(bucket string, files []FileInfo, maxSize int64) error {
+	if maxSize <= 0 {
+		maxSize = defaultMaxPackSize
+	}
+
+	infoColor.Printf("🗜️  Packing %s files (%d files)...\n", bucket, len(files))
+
+	totalPacked := 0
+	totalSkipped := 0
+	today := time.Now()
+	for i, batch := range packBatches(files, maxSize) {
+		archivePath := filepath.Join(fo.BasePath, packArchiveName(bucket, today, i+1))
+		if _, err := os.Lstat(archivePath); err == nil {
+			archivePath = nextFreeName(fo.BasePath, filepath.Base(archivePath))
+		}
+		archiveName := filepath.Base(archivePath)
+
+		if fo.DryRun {
+			for _, file := range batch {
+				fmt.Printf("   🗜️  Would pack: %s -> %s\n", file.Name, archiveName)
+				fo.Plan.Add("pack", file.Path, archivePath)
+			}
+			totalPacked += len(batch)
+			continue
+		}
+
+		var approved []FileInfo
+		for _, file := range batch {
+			if !fo.Deadline.Allow(file.Path) || !fo.Approver.Approve(fmt.Sprintf("Pack %s into %s", file.Name, archiveName)) || changedSinceScan(file) {
+				totalSkipped++
+				continue
+			}
+			approved = append(approved, file)
+		}
+		if len(approved) == 0 {
+			continue
+		}
+
+		fmt.Printf("   🗜️  Writing %s (%d files)\n", archiveName, len(approved))
+		if err := fo.writeArchive(archivePath, approved); err != nil {
+			warningColor.Printf("   ⚠️  Failed to write %s, leaving its files in place: %v\n", archiveName, err)
+			for _, file := range approved {
+				fo.Plan.Record("pack", file.Path, archivePath, err)
+			}
+			totalSkipped += len(approved)
+			continue
+		}
+
+		for _, file := range approved {
+			var err error
+			if fo.Quarantine != nil {
+				err = fo.Quarantine.Add(file)
+			} else {
+				err = os.Remove(file.Path)
+			}
+			if err != nil {
+				warningColor.Printf("   ⚠️  Packed %s but failed to remove it: %v\n", file.Name, err)
+				err = fmt.Errorf("packed but not removed: %v", err)
+			}
+			fo.Plan.Record("pack", file.Path, archivePath, err)
+		}
+		totalPacked += len(approved)
+	}
+	fmt.Println()
+
+	fo.TotalMoved += totalPacked
+
+	if totalPacked > 0 {
+		if fo.DryRun {
+			successColor.Printf("✅ Would pack %d files into archives!\n", totalPacked)
+		} else {
+			successColor.Printf("✅ Packed %d files into archives!\n", totalPacked)
+		}
+	}
+	if totalSkipped > 0 {
+		fmt.Printf("ℹ️  Skipped %d files (changed, not approved or failed)\n", totalSkipped)
+	}
+	return nil
+}
+
+// writeArchive writes files into a new zip at archivePath, named by their path
+// relative to the base path. Files outside it, as with --dest, are named by
+// their file name, with a (n) suffix when two share one, so no entry is lost.
+// A partly written archive is removed on failure.
+func (fo *FileOrganizer) writeArchive(archivePath string, files []FileInfo) (err error) {
+	out, err := os.OpenFile(archivePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(archivePath)
+		}
+	}()
+
+	archive := zip.NewWriter(out)
+	used := make(map[string]bool)
+	for _, file := range files {
+		if err := addToArchive(archive, archiveEntryName(fo.BasePath, file.Path, used), file.Path); err != nil {
+			return fmt.Errorf("cannot add %s: %v", file.Name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	// The originals are about to go, so make sure the archive really is on disk
+	return out.Sync()
+}
+
+// archiveEntryName returns the name a file is stored under in an archive,
+// its slash-separated path relative to basePath or else its file name, made
+// unique among the names already used
+func archiveEntryName(basePath, path string, used map[string]bool) string {
+	name, err := filepath.Rel(basePath, path)
+	if err != nil || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		name = filepath.Base(path)
+	}
+	name = filepath.ToSlash(name)
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; used[strings.ToLower(name)]; i++ {
+		name = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// addToArchive compresses a single file into the archive under the given name
+func addToArchive(archive *zip.Writer, name, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(writer, src)
+	return err
+}
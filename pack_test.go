@@ -0,0 +1,155 @@
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPackTinyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	tiny := map[string]string{
+		"a.txt":  strings.Repeat("a", 400),
+		"b.json": strings.Repeat("b", 400),
+		"c.csv":  strings.Repeat("c", 400),
+	}
+	for name, content := range tiny {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+	bigger := filepath.Join(tmpDir, "bigger.bin")
+	if err := os.WriteFile(bigger, make([]byte, 2*1024*1024), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.PackSmallest = true
+	organizer.MaxPackSize = 1000 // Room for two of the tiny files per archive
+	if err := organizer.OrganizeBySize(); err != nil {
+		t.Fatalf("OrganizeBySize() error = %v", err)
+	}
+
+	for name := range tiny {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed after packing", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Small", "bigger.bin")); err != nil {
+		t.Errorf("Expected bigger files to be moved as usual: %v", err)
+	}
+
+	today := time.Now()
+	packed := make(map[string]string)
+	for n := 1; n <= 2; n++ {
+		archivePath := filepath.Join(tmpDir, packArchiveName("Tiny", today, n))
+		reader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			t.Fatalf("Expected archive %s: %v", filepath.Base(archivePath), err)
+		}
+		for _, entry := range reader.File {
+			rc, err := entry.Open()
+			if err != nil {
+				t.Fatalf("Failed to open %s in archive: %v", entry.Name, err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			packed[entry.Name] = string(data)
+		}
+		reader.Close()
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, packArchiveName("Tiny", today, 3))); !os.IsNotExist(err) {
+		t.Error("Expected the files to fit in two archives")
+	}
+
+	var names []string
+	for name, content := range packed {
+		names = append(names, name)
+		if content != tiny[name] {
+			t.Errorf("Archive holds wrong content for %s", name)
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a.txt,b.json,c.csv" {
+		t.Errorf("Archives hold %v, want a.txt, b.json and c.csv", names)
+	}
+}
+
+func TestPackTinyFilesDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	note := filepath.Join(tmpDir, "note.txt")
+	if err := os.WriteFile(note, []byte("remember"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, true, tmpDir)
+	organizer.PackSmallest = true
+	organizer.Plan = &Plan{}
+	if err := organizer.OrganizeBySize(); err != nil {
+		t.Fatalf("OrganizeBySize() error = %v", err)
+	}
+
+	if _, err := os.Stat(note); err != nil {
+		t.Errorf("Expected the file to stay put in a dry run: %v", err)
+	}
+	if len(organizer.Plan.Operations) != 1 || organizer.Plan.Operations[0].Action != "pack" {
+		t.Errorf("Expected one planned pack, got %+v", organizer.Plan.Operations)
+	}
+}
+
+func TestPackFilesNameClash(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"a/notes.txt": "notes from a",
+		"b/notes.txt": "notes from b",
+	})
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	// With --dest the files aren't under the base path, so only their names are used
+	organizer := NewFileOrganizer(scanner, false, destDir)
+	if err := organizer.PackFiles("Tiny", scanner.Files, 0); err != nil {
+		t.Fatalf("PackFiles() error = %v", err)
+	}
+
+	reader, err := zip.OpenReader(filepath.Join(destDir, packArchiveName("Tiny", time.Now(), 1)))
+	if err != nil {
+		t.Fatalf("Expected an archive: %v", err)
+	}
+	defer reader.Close()
+	var contents []string
+	names := make(map[string]bool)
+	for _, entry := range reader.File {
+		names[entry.Name] = true
+		rc, err := entry.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s in archive: %v", entry.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents = append(contents, string(data))
+	}
+	sort.Strings(contents)
+	if !names["notes.txt"] || !names["notes (1).txt"] {
+		t.Errorf("Expected notes.txt and notes (1).txt in the archive, got %v", names)
+	}
+	if len(contents) != 2 || contents[0] != "notes from a" || contents[1] != "notes from b" {
+		t.Errorf("Expected both files' content in the archive, got %q", contents)
+	}
+}
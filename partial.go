@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Content-defined chunking parameters. Chunk boundaries depend on the bytes
+// around them rather than their offset, so files that share long runs of
+// content share chunks even when the runs start at different positions.
+const (
+	minChunkSize   = 2 * 1024
+	maxChunkSize   = 64 * 1024
+	chunkMask      = uint64(0x1FFF) << 51 // 13 bits, for roughly 8KB chunks
+	partialMinSize = 64 * 1024            // Smaller files don't have enough chunks to compare
+)
+
+// defaultPartialThreshold is the fraction of shared content that makes two files partial duplicates
+const defaultPartialThreshold = 0.5
+
+// gearTable holds the random values the rolling hash mixes in for each byte
+var gearTable = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x9E3779B97F4A7C15)
+	for i := range table {
+		// splitmix64, so the table is the same on every run
+		state += 0x9E3779B97F4A7C15
+		z := state
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// PartialDuplicate is a pair of different files that share much of their content
+type PartialDuplicate struct {
+	A           FileInfo
+	B           FileInfo
+	SharedBytes int64   // Bytes of content found in both files
+	Fraction    float64 // SharedBytes as a fraction of the smaller file
+}
+
+// chunkFile splits a file into content-defined chunks and returns the size of each distinct chunk by its hash
+func chunkFile(path string) (map[[32]byte]int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	chunks := make(map[[32]byte]int64)
+	reader := bufio.NewReaderSize(file, maxChunkSize)
+	chunk := make([]byte, 0, maxChunkSize)
+	var rolling uint64
+
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		chunk = append(chunk, b)
+		rolling = (rolling << 1) + gearTable[b]
+		if (len(chunk) >= minChunkSize && rolling&chunkMask == 0) || len(chunk) == maxChunkSize {
+			chunks[sha256.Sum256(chunk)] = int64(len(chunk))
+			chunk = chunk[:0]
+			rolling = 0
+		}
+	}
+	if len(chunk) > 0 {
+		chunks[sha256.Sum256(chunk)] = int64(len(chunk))
+	}
+	return chunks, nil
+}
+
+// findPartialDuplicates reports pairs of files that aren't identical but share
+// at least threshold of the smaller file's content. They are only reported,
+// never removed, since the differences may matter.
+func (s *Scanner) findPartialDuplicates(threshold float64) {
+	fmt.Println("🧩 Checking for partial duplicates...")
+
+	var candidates []FileInfo
+	var chunkSets []map[[32]byte]int64
+	owners := make(map[[32]byte][]int) // Chunk hash to the candidates that contain it
+
+	for _, file := range s.Files {
+		if file.Size < partialMinSize || file.Hash == "" {
+			continue
+		}
+		chunks, err := chunkFile(file.Path)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Could not read %s: %v\n", file.Path, err)
+			continue
+		}
+		index := len(candidates)
+		candidates = append(candidates, file)
+		chunkSets = append(chunkSets, chunks)
+		for hash := range chunks {
+			owners[hash] = append(owners[hash], index)
+		}
+	}
+
+	// Add up the shared bytes for every pair of files with a chunk in common
+	shared := make(map[[2]int]int64)
+	for hash, files := range owners {
+		for i := 0; i < len(files); i++ {
+			for j := i + 1; j < len(files); j++ {
+				shared[[2]int{files[i], files[j]}] += chunkSets[files[i]][hash]
+			}
+		}
+	}
+
+	s.PartialDuplicates = nil
+	for pair, bytes := range shared {
+		a, b := candidates[pair[0]], candidates[pair[1]]
+		if a.Hash == b.Hash {
+			continue // Exact duplicates are handled already
+		}
+		smaller := a.Size
+		if b.Size < smaller {
+			smaller = b.Size
+		}
+		fraction := float64(bytes) / float64(smaller)
+		if fraction > 1 {
+			fraction = 1
+		}
+		if fraction >= threshold {
+			s.PartialDuplicates = append(s.PartialDuplicates, PartialDuplicate{A: a, B: b, SharedBytes: bytes, Fraction: fraction})
+		}
+	}
+
+	sort.Slice(s.PartialDuplicates, func(i, j int) bool {
+		return s.PartialDuplicates[i].Fraction > s.PartialDuplicates[j].Fraction
+	})
+
+	if len(s.PartialDuplicates) > 0 {
+		fmt.Printf("🧩 Found %d pairs of partial duplicates\n", len(s.PartialDuplicates))
+	}
+}
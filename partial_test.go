@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindPartialDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	random := rand.New(rand.NewSource(1))
+
+	randomBytes := func(n int) []byte {
+		data := make([]byte, n)
+		random.Read(data)
+		return data
+	}
+
+	// Two downloads sharing a large prefix but with different tails
+	prefix := randomBytes(1024 * 1024)
+	complete := append(append([]byte{}, prefix...), randomBytes(128*1024)...)
+	partial := append(append([]byte{}, prefix...), randomBytes(32*1024)...)
+	unrelated := randomBytes(1024 * 1024)
+
+	for name, data := range map[string][]byte{
+		"movie.mkv":          complete,
+		"movie (1).mkv":      partial,
+		"something else.mkv": unrelated,
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.PartialThreshold = defaultPartialThreshold
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected no exact duplicates, got %d", len(scanner.Duplicates))
+	}
+	if len(scanner.PartialDuplicates) != 1 {
+		t.Fatalf("Expected 1 pair of partial duplicates, got %d", len(scanner.PartialDuplicates))
+	}
+
+	pair := scanner.PartialDuplicates[0]
+	names := map[string]bool{pair.A.Name: true, pair.B.Name: true}
+	if !names["movie.mkv"] || !names["movie (1).mkv"] {
+		t.Errorf("Expected movie.mkv and movie (1).mkv to be paired, got %s and %s", pair.A.Name, pair.B.Name)
+	}
+	if pair.Fraction < 0.9 {
+		t.Errorf("Expected most of the smaller file to be shared, got %.2f", pair.Fraction)
+	}
+
+	// Partial duplicates are only reported, so nothing is marked for removal
+	for _, file := range scanner.Files {
+		if file.IsDuplicate {
+			t.Errorf("Expected %s not to be marked as a duplicate", file.Name)
+		}
+	}
+}
+
+func TestFindPartialDuplicatesDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	data := make([]byte, 256*1024)
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.bin"), data, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.bin"), append(data, 1), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.PartialDuplicates) != 0 {
+		t.Errorf("Expected no partial duplicates without a threshold, got %d", len(scanner.PartialDuplicates))
+	}
+}
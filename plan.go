@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// PlannedOperation is a single change a dry run would make
+type PlannedOperation struct {
+	Action      string `json:"action"` // move, rename, pack or remove
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// OperationResult is the outcome of an operation carried out by a real run
+type OperationResult struct {
+	PlannedOperation
+	Status string `json:"status"` // done or failed
+	Error  string `json:"error,omitempty"`
+}
+
+// Plan records the changes a dry run would make, so it can be saved and
+// compared with a later run. In a real run it also records how each change
+// went. A nil Plan records nothing.
+type Plan struct {
+	Operations []PlannedOperation `json:"operations"`
+	Results    []OperationResult  `json:"results,omitempty"`
+}
+
+// Add records an operation
+func (p *Plan) Add(action, source, destination string) {
+	if p == nil {
+		return
+	}
+	p.Operations = append(p.Operations, PlannedOperation{Action: action, Source: source, Destination: destination})
+}
+
+// Record records an operation a real run carried out, and its outcome
+func (p *Plan) Record(action, source, destination string, err error) {
+	if p == nil {
+		return
+	}
+	p.Add(action, source, destination)
+	result := OperationResult{PlannedOperation: p.Operations[len(p.Operations)-1], Status: "done"}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+	}
+	p.Results = append(p.Results, result)
+}
+
+// AssertEmpty checks that the plan has nothing to do, for --assert-clean. If
+// it has, it lists the operations to w and returns an error.
+func (p *Plan) AssertEmpty(w io.Writer) error {
+	if len(p.Operations) == 0 {
+		successColor.Fprintf(w, "✅ Already clean: nothing would be moved, renamed or removed\n")
+		return nil
+	}
+
+	errorColor.Fprintf(w, "❌ Not clean: %d changes would be made\n", len(p.Operations))
+	for _, op := range p.Operations {
+		fmt.Fprintf(w, "  - %s\n", op.describe())
+	}
+	return fmt.Errorf("folder is not clean: %d changes would be made", len(p.Operations))
+}
+
+// LoadPlan reads a plan saved with --save-plan
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plan file: %v", err)
+	}
+	plan := &Plan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("cannot parse plan file: %v", err)
+	}
+	return plan, nil
+}
+
+// Save writes the plan as JSON
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("cannot write plan file: %v", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	out := *p
+	if out.Operations == nil {
+		out.Operations = []PlannedOperation{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// PlanChange is an operation on the same file that differs between two plans
+type PlanChange struct {
+	Old PlannedOperation
+	New PlannedOperation
+}
+
+// PlanDiff lists the differences between two plans. An operation on a file
+// that is in both plans, but with a different action or destination, counts
+// as changed.
+type PlanDiff struct {
+	Added   []PlannedOperation
+	Removed []PlannedOperation
+	Changed []PlanChange
+}
+
+// Empty reports whether the two plans were the same
+func (d PlanDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffPlans compares a previous plan with the current one. A file can have
+// more than one operation, like a rename and a move, so operations are first
+// matched on every field, and only what is left over on each side is paired
+// up by source file.
+func DiffPlans(previous, current *Plan) PlanDiff {
+	unmatched := make(map[PlannedOperation]int)
+	for _, op := range previous.Operations {
+		unmatched[op]++
+	}
+	var added []PlannedOperation
+	for _, op := range current.Operations {
+		if unmatched[op] > 0 {
+			unmatched[op]--
+			continue
+		}
+		added = append(added, op)
+	}
+	removed := make(map[string][]PlannedOperation)
+	for _, op := range previous.Operations {
+		if unmatched[op] > 0 {
+			unmatched[op]--
+			removed[op.Source] = append(removed[op.Source], op)
+		}
+	}
+
+	var diff PlanDiff
+	for _, op := range added {
+		if old := removed[op.Source]; len(old) > 0 {
+			diff.Changed = append(diff.Changed, PlanChange{Old: old[0], New: op})
+			removed[op.Source] = old[1:]
+		} else {
+			diff.Added = append(diff.Added, op)
+		}
+	}
+	for _, ops := range removed {
+		diff.Removed = append(diff.Removed, ops...)
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].describe() < diff.Added[j].describe() })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].describe() < diff.Removed[j].describe() })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].New.describe() < diff.Changed[j].New.describe() })
+	return diff
+}
+
+// describe formats an operation for the diff output
+func (op PlannedOperation) describe() string {
+	if op.Destination == "" {
+		return fmt.Sprintf("%s %s", op.Action, op.Source)
+	}
+	return fmt.Sprintf("%s %s -> %s", op.Action, op.Source, op.Destination)
+}
+
+// Print writes the diff in a readable form
+func (d PlanDiff) Print(w io.Writer) {
+	if d.Empty() {
+		fmt.Fprintln(w, "✅ The plan is the same as last time")
+		return
+	}
+
+	fmt.Fprintf(w, "📋 Plan changes: %d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed))
+	for _, op := range d.Added {
+		fmt.Fprintf(w, "  + %s\n", op.describe())
+	}
+	for _, op := range d.Removed {
+		fmt.Fprintf(w, "  - %s\n", op.describe())
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(w, "  ~ %s\n", change.Old.describe())
+		fmt.Fprintf(w, "    now %s\n", change.New.describe())
+	}
+}
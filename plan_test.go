@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffPlans(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"photo.jpg", "report.pdf", "song.mp3"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// First plan with the default settings, saved to disk
+	first := NewFileOrganizer(scanner, true, tmpDir)
+	first.Plan = &Plan{}
+	if err := first.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	if err := first.Plan.Save(planPath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	previous, err := LoadPlan(planPath)
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+	if len(previous.Operations) != 3 {
+		t.Fatalf("Expected 3 planned operations, got %d", len(previous.Operations))
+	}
+
+	// Second plan after tweaking the settings
+	second := NewFileOrganizer(scanner, true, tmpDir)
+	second.Plan = &Plan{}
+	second.CategoryMap["Documents"] = "Paperwork"
+	second.SkipCategories = map[string]bool{"Music": true}
+	if err := second.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	diff := DiffPlans(previous, second.Plan)
+	if len(diff.Added) != 0 || len(diff.Removed) != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("Expected 0 added, 1 removed and 1 changed, got %+v", diff)
+	}
+
+	var out bytes.Buffer
+	diff.Print(&out)
+	report := filepath.Join(tmpDir, "report.pdf")
+	for _, want := range []string{
+		"~ move " + report + " -> " + filepath.Join(tmpDir, "Documents", "report.pdf"),
+		"now move " + report + " -> " + filepath.Join(tmpDir, "Paperwork", "report.pdf"),
+		"- move " + filepath.Join(tmpDir, "song.mp3"),
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected diff output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "photo.jpg") {
+		t.Errorf("Expected the unchanged operation to be left out, got:\n%s", out.String())
+	}
+
+	// Nothing was actually moved
+	if _, err := os.Stat(report); err != nil {
+		t.Errorf("Expected dry runs to leave files in place: %v", err)
+	}
+}
+
+func TestDiffPlansSameSource(t *testing.T) {
+	previous := &Plan{Operations: []PlannedOperation{
+		{Action: "remove", Source: "/d/a.pdf"},
+		{Action: "move", Source: "/d/a.pdf", Destination: "/d/Documents/a.pdf"},
+		{Action: "move", Source: "/d/b.jpg", Destination: "/d/Images/b.jpg"},
+	}}
+	current := &Plan{Operations: []PlannedOperation{
+		{Action: "move", Source: "/d/a.pdf", Destination: "/d/Documents/a.pdf"},
+		{Action: "remove", Source: "/d/b.jpg"},
+		{Action: "rename", Source: "/d/c d.txt", Destination: "/d/c_d.txt"},
+	}}
+
+	// Every operation on a file counts, not just the last one
+	diff := DiffPlans(previous, current)
+	if len(diff.Removed) != 1 || diff.Removed[0] != previous.Operations[0] {
+		t.Errorf("Expected the dropped removal to be reported, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Old != previous.Operations[2] || diff.Changed[0].New != current.Operations[1] {
+		t.Errorf("Expected the move turned removal to be reported as changed, got %+v", diff.Changed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != current.Operations[2] {
+		t.Errorf("Expected the new rename to be reported, got %+v", diff.Added)
+	}
+}
+
+func TestPlanRecordsResults(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"report.pdf":     "quarterly numbers",
+		"report (1).pdf": "quarterly numbers",
+		"photo.jpg":      "pixels",
+		"song.mp3":       "music",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// A real run removing a duplicate and organizing, where one move fails
+	plan := &Plan{}
+	dh := NewDuplicateHandler(scanner, false)
+	dh.Plan = plan
+	if err := dh.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.Plan = plan
+	organizer.SkipCategories = map[string]bool{"Documents": true}
+	organizer.moveFile = func(src, dst string) error {
+		if filepath.Base(src) == "song.mp3" {
+			return fmt.Errorf("device not ready")
+		}
+		return organizer.atomicMove(src, dst)
+	}
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := plan.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var written Plan
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatalf("Failed to parse plan JSON: %v\n%s", err, out.String())
+	}
+
+	if len(written.Operations) != 3 {
+		t.Fatalf("Expected 3 operations, got %+v", written.Operations)
+	}
+	results := make(map[string]OperationResult)
+	for _, result := range written.Results {
+		results[filepath.Base(result.Source)] = result
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for each operation, got %+v", written.Results)
+	}
+
+	removed := results["report (1).pdf"]
+	if removed.Action != "remove" || removed.Status != "done" {
+		t.Errorf("Expected the duplicate to be removed, got %+v", removed)
+	}
+	moved := results["photo.jpg"]
+	if moved.Action != "move" || moved.Status != "done" || moved.Destination != filepath.Join(tmpDir, "Images", "photo.jpg") {
+		t.Errorf("Expected photo.jpg to be moved to Images, got %+v", moved)
+	}
+	failed := results["song.mp3"]
+	if failed.Status != "failed" || failed.Error != "device not ready" {
+		t.Errorf("Expected the song's move to be reported as failed, got %+v", failed)
+	}
+}
+
+func TestPlanAssertEmpty(t *testing.T) {
+	planFor := func(dir string) *Plan {
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(dir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+		organizer := NewFileOrganizer(scanner, true, dir)
+		organizer.Plan = &Plan{}
+		if err := organizer.OrganizeFiles(); err != nil {
+			t.Fatalf("OrganizeFiles() error = %v", err)
+		}
+		return organizer.Plan
+	}
+
+	// Already organized, so nothing would change
+	clean := t.TempDir()
+	for _, path := range []string{"Documents/report.pdf", "Images/photo.jpg"} {
+		full := filepath.Join(clean, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(full, []byte("content of "+path), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", path, err)
+		}
+	}
+	var out bytes.Buffer
+	if err := planFor(clean).AssertEmpty(&out); err != nil {
+		t.Errorf("Expected an organized folder to pass, got %v:\n%s", err, out.String())
+	}
+
+	// A stray file would be moved
+	dirty := t.TempDir()
+	stray := filepath.Join(dirty, "report.pdf")
+	if err := os.WriteFile(stray, []byte("stray"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	out.Reset()
+	if err := planFor(dirty).AssertEmpty(&out); err == nil {
+		t.Error("Expected a folder with a stray file to fail")
+	}
+	if want := "move " + stray + " -> " + filepath.Join(dirty, "Documents", "report.pdf"); !strings.Contains(out.String(), want) {
+		t.Errorf("Expected the output to list %q, got:\n%s", want, out.String())
+	}
+	if _, err := os.Stat(stray); err != nil {
+		t.Errorf("Expected the check to leave files in place: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// pluginTimeout is how long a plugin gets to answer for one file
+const pluginTimeout = 10 * time.Second
+
+// PluginActionSkip is the action a plugin returns to leave a file out of the run
+const PluginActionSkip = "skip"
+
+// PluginRequest describes a scanned file to a plugin. elf-cli starts the plugin
+// once per file and writes the request to its stdin as a single line of JSON.
+type PluginRequest struct {
+	Path         string    `json:"path"`
+	Name         string    `json:"name"`
+	Extension    string    `json:"extension"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	Category     string    `json:"category"` // What the built-in rules decided
+}
+
+// PluginResponse is a plugin's answer, a single line of JSON on its stdout.
+// Category puts the file in another category and Action "skip" leaves it out
+// of the run; a plugin with no opinion prints {} or nothing at all.
+type PluginResponse struct {
+	Category string `json:"category,omitempty"`
+	Action   string `json:"action,omitempty"`
+}
+
+// Plugin is an executable in the plugins folder
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// PluginRegistry holds the plugins asked about each file, in name order
+type PluginRegistry struct {
+	Plugins []Plugin
+}
+
+// getPluginsDir returns the folder plugins are discovered in
+func getPluginsDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "plugins"), nil
+}
+
+// LoadPlugins finds the executables in dir. A missing folder just means no plugins.
+func LoadPlugins(dir string) (*PluginRegistry, error) {
+	registry := &PluginRegistry{}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plugins folder: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(info) {
+			continue
+		}
+		registry.Plugins = append(registry.Plugins, Plugin{Name: entry.Name(), Path: filepath.Join(dir, entry.Name())})
+	}
+	return registry, nil
+}
+
+// isExecutable reports whether a file can be run as a plugin
+func isExecutable(info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// Ask runs the plugin for one file and returns its answer
+func (p Plugin) Ask(request PluginRequest) (PluginResponse, error) {
+	var response PluginResponse
+	input, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return response, fmt.Errorf("no answer within %v", pluginTimeout)
+		}
+		return response, err
+	}
+
+	line, _ := bufio.NewReader(&stdout).ReadString('\n')
+	if strings.TrimSpace(line) == "" {
+		return response, nil
+	}
+	if err := json.Unmarshal([]byte(line), &response); err != nil {
+		return response, fmt.Errorf("invalid answer %q: %v", strings.TrimSpace(line), err)
+	}
+	if response.Action != "" && response.Action != PluginActionSkip {
+		return PluginResponse{}, fmt.Errorf("unknown action %q", response.Action)
+	}
+	return response, nil
+}
+
+// Categorize asks each plugin about a file in turn and returns the first
+// answer that says something, along with the plugin that gave it. Plugins
+// that fail are warned about and passed over.
+func (r *PluginRegistry) Categorize(request PluginRequest) (PluginResponse, string, bool) {
+	for _, plugin := range r.Plugins {
+		response, err := plugin.Ask(request)
+		if err != nil {
+			fmt.Printf("⚠️  Plugin %s failed for %s: %v\n", plugin.Name, request.Name, err)
+			continue
+		}
+		if response.Category != "" || response.Action != "" {
+			return response, plugin.Name, true
+		}
+	}
+	return PluginResponse{}, "", false
+}
+
+// pluginCategory asks the plugins about a file, returning the category they
+// chose with the reason, and whether the file should be left out of the scan
+func (s *Scanner) pluginCategory(path string, info os.FileInfo, ext, category string) (string, string, bool, bool) {
+	if s.Plugins == nil || len(s.Plugins.Plugins) == 0 {
+		return "", "", false, false
+	}
+
+	response, plugin, ok := s.Plugins.Categorize(PluginRequest{
+		Path:         path,
+		Name:         info.Name(),
+		Extension:    ext,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+		Category:     category,
+	})
+	if !ok {
+		return "", "", false, false
+	}
+	if response.Action == PluginActionSkip {
+		return "", fmt.Sprintf("skipped by plugin %s", plugin), false, true
+	}
+
+	known, found := s.knownCategory(response.Category)
+	if !found {
+		fmt.Printf("⚠️  Ignoring unknown category %q for %s from plugin %s\n", response.Category, path, plugin)
+		return "", "", false, false
+	}
+	return known, fmt.Sprintf("plugin %s -> %s", plugin, known), true, false
+}
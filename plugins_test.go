@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPluginCategorizes(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("No sh to run the stub plugin with")
+	}
+
+	tmpDir := t.TempDir()
+	pluginsDir := filepath.Join(tmpDir, "plugins")
+	downloads := filepath.Join(tmpDir, "Downloads")
+	for _, dir := range []string{pluginsDir, downloads} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	// The stub files everything under Documents, except .log files, which it skips
+	stub := "#!/bin/sh\nread request\ncase \"$request\" in *'\"extension\":\".log\"'*) echo '{\"action\":\"skip\"}';; *) echo '{\"category\":\"documents\"}';; esac\n"
+	if err := os.WriteFile(filepath.Join(pluginsDir, "classify"), []byte(stub), 0755); err != nil {
+		t.Fatalf("Failed to create stub plugin: %v", err)
+	}
+	// Not executable, so not a plugin
+	if err := os.WriteFile(filepath.Join(pluginsDir, "README.txt"), []byte("notes"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	for _, name := range []string{"photo.jpg", "debug.log"} {
+		if err := os.WriteFile(filepath.Join(downloads, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	registry, err := LoadPlugins(pluginsDir)
+	if err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+	if len(registry.Plugins) != 1 || registry.Plugins[0].Name != "classify" {
+		t.Fatalf("Expected only the classify plugin, got %+v", registry.Plugins)
+	}
+
+	scanner := NewScanner()
+	scanner.Plugins = registry
+	if err := scanner.ScanDirectory(downloads); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 1 {
+		t.Fatalf("Expected the plugin to skip debug.log, got %d files", len(scanner.Files))
+	}
+	if file := scanner.Files[0]; file.Name != "photo.jpg" || file.Category != "Documents" {
+		t.Errorf("Expected photo.jpg in Documents, got %s in %s", file.Name, file.Category)
+	}
+}
+
+func TestLoadPluginsMissingFolder(t *testing.T) {
+	registry, err := LoadPlugins(filepath.Join(t.TempDir(), "plugins"))
+	if err != nil {
+		t.Fatalf("LoadPlugins() error = %v", err)
+	}
+	if len(registry.Plugins) != 0 {
+		t.Errorf("Expected no plugins, got %+v", registry.Plugins)
+	}
+}
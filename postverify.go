@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VerifyFailure is a moved file whose content couldn't be confirmed at its new location
+type VerifyFailure struct {
+	Path         string // Where the file is now
+	OriginalPath string // Where it was before the move
+	Err          error
+}
+
+// PostVerify re-reads every file moved this session at its new location and
+// compares it with the hashes taken while scanning, to catch silent corruption
+// by the move or copy. It hashes the same way the scan did, so the throttle
+// and any extra checksums apply too. It returns the files that didn't match or
+// couldn't be read, and how many were checked. Files the scan didn't hash are
+// left out, and checking stops when the deadline runs out.
+func (fo *FileOrganizer) PostVerify() ([]VerifyFailure, int) {
+	scanned := make(map[string]FileInfo, len(fo.Scanner.Files))
+	for _, file := range fo.Scanner.Files {
+		if file.Hash != "" {
+			scanned[file.Path] = file
+		}
+	}
+
+	var failures []VerifyFailure
+	checked := 0
+	for _, move := range fo.moves {
+		file, ok := scanned[move.Src]
+		if !ok {
+			continue
+		}
+		err := fo.verifyMove(file, move.Dst)
+		if err != nil && fo.Scanner.Deadline.Expired() {
+			break
+		}
+		checked++
+		if err != nil {
+			failures = append(failures, VerifyFailure{Path: move.Dst, OriginalPath: move.Src, Err: err})
+		}
+	}
+	return failures, checked
+}
+
+// verifyMove re-hashes a scanned file at the place it was moved to and
+// returns an error if it can't be read or no longer matches
+func (fo *FileOrganizer) verifyMove(file FileInfo, dst string) error {
+	// Extra checksums include the MD5, so one read covers plain files
+	if len(file.Hashes) > 0 {
+		algos := make([]string, 0, len(file.Hashes))
+		for algo := range file.Hashes {
+			algos = append(algos, algo)
+		}
+		sort.Strings(algos)
+		actual, err := fo.Scanner.calculateFileHashes(dst, algos)
+		if err != nil {
+			return fmt.Errorf("cannot re-read: %v", err)
+		}
+		for _, algo := range algos {
+			if actual[algo] != file.Hashes[algo] {
+				return fmt.Errorf("content differs from before the move (%s)", algo)
+			}
+		}
+		if !strings.HasPrefix(file.Hash, archiveHashPrefix) {
+			return nil
+		}
+	}
+
+	// Zips compared by content were hashed by content, and contentHash checks them the same way
+	moved := file
+	moved.Path = dst
+	actual, err := fo.Scanner.contentHash(moved)
+	if err != nil {
+		return fmt.Errorf("cannot re-read: %v", err)
+	}
+	if actual != file.Hash {
+		return fmt.Errorf("content differs from before the move")
+	}
+	return nil
+}
+
+// PrintVerifyFailures reports the outcome of PostVerify
+func PrintVerifyFailures(failures []VerifyFailure, checked int) {
+	if len(failures) == 0 {
+		successColor.Printf("🔬 Verified %d moved files, all match their content from before the move\n", checked)
+		return
+	}
+
+	errorColor.Printf("🔬 %d of %d moved files failed verification:\n", len(failures), checked)
+	for _, failure := range failures {
+		fmt.Printf("  - %s (moved from %s): %v\n", failure.Path, failure.OriginalPath, failure.Err)
+	}
+}
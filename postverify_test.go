@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostVerifyFlagsCorruptedMove(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"good.pdf", "bad.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	// A copier that silently flips a byte of bad.pdf on the way
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	organizer.moveFile = func(src, dst string) error {
+		if err := organizer.copyAndDelete(src, dst); err != nil {
+			return err
+		}
+		if filepath.Base(dst) == "bad.pdf" {
+			data, _ := os.ReadFile(dst)
+			data[0] ^= 0xff
+			return os.WriteFile(dst, data, 0644)
+		}
+		return nil
+	}
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	failures, checked := organizer.PostVerify()
+	if checked != 2 {
+		t.Errorf("Checked %d files, want 2", checked)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("Expected 1 verification failure, got %v", failures)
+	}
+	if want := filepath.Join(tmpDir, "Documents", "bad.pdf"); failures[0].Path != want {
+		t.Errorf("Failure path = %s, want %s", failures[0].Path, want)
+	}
+	if failures[0].OriginalPath != filepath.Join(tmpDir, "bad.pdf") {
+		t.Errorf("Failure original path = %s, want the path before the move", failures[0].OriginalPath)
+	}
+}
+
+func TestPostVerifyChecksExtraAlgorithms(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "report.pdf"), []byte("report content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	scanner.HashAlgorithms = []string{"sha256"}
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	// A SHA-256 that doesn't match stands in for corruption only it would catch
+	scanner.Files[0].Hashes["sha256"] = "0000"
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	failures, checked := organizer.PostVerify()
+	if checked != 1 || len(failures) != 1 {
+		t.Fatalf("Expected 1 of 1 files to fail verification, got %d of %d: %v", len(failures), checked, failures)
+	}
+	if !strings.Contains(failures[0].Err.Error(), "sha256") {
+		t.Errorf("Expected the failure to name sha256, got %v", failures[0].Err)
+	}
+}
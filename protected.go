@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// hashLengths maps the length of a hex digest to the algorithm that makes it
+var hashLengths = map[int]string{32: "md5", 40: "sha1", 64: "sha256", 128: "sha512"}
+
+// LoadHashList reads the hashes in a --ignore-hashes file, one per line.
+// Blank lines and lines starting with # are skipped, and anything after the
+// hash is ignored, so the output of md5sum or sha256sum works as is. It also
+// returns the algorithms needed to compare files with the hashes.
+func LoadHashList(path string) (map[string]bool, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read hash list: %v", err)
+	}
+	defer file.Close()
+
+	hashes := make(map[string]bool)
+	algorithms := make(map[string]bool)
+	lineScanner := bufio.NewScanner(file)
+	for line := 1; lineScanner.Scan(); line++ {
+		fields := strings.Fields(lineScanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		hash := strings.ToLower(fields[0])
+		algorithm, ok := hashLengths[len(hash)]
+		if !ok || strings.Trim(hash, "0123456789abcdef") != "" {
+			return nil, nil, fmt.Errorf("%s:%d: %q is not an MD5, SHA-1, SHA-256 or SHA-512 hash", path, line, fields[0])
+		}
+		hashes[hash] = true
+		algorithms[algorithm] = true
+	}
+	if err := lineScanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("cannot read hash list: %v", err)
+	}
+
+	// MD5 is always calculated
+	var extra []string
+	for algorithm := range algorithms {
+		if algorithm != "md5" {
+			extra = append(extra, algorithm)
+		}
+	}
+	sort.Strings(extra)
+	return hashes, extra, nil
+}
+
+// isProtected reports whether any of a file's hashes is in ProtectedHashes
+func (s *Scanner) isProtected(file FileInfo) bool {
+	if s.ProtectedHashes[strings.ToLower(file.Hash)] {
+		return true
+	}
+	for _, hash := range file.Hashes {
+		if s.ProtectedHashes[strings.ToLower(hash)] {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedFile reports whether the file at path has a protected hash, for
+// operations like flattening that work without a scan. A file that can't be
+// read counts as protected, so it is left alone.
+func (s *Scanner) protectedFile(path string) bool {
+	if len(s.ProtectedHashes) == 0 {
+		return false
+	}
+	hashes, err := s.calculateFileHashes(path, append([]string{"md5"}, s.HashAlgorithms...))
+	if err != nil {
+		return true
+	}
+	return s.isProtected(FileInfo{Hash: hashes["md5"], Hashes: hashes})
+}
+
+// protectFiles drops the files whose hash is protected from the scan, before
+// duplicates are looked for, so nothing later moves, renames or removes them
+func (s *Scanner) protectFiles() {
+	var protected []string
+	for _, file := range s.Files {
+		if s.isProtected(file) {
+			s.Protected = append(s.Protected, file)
+			protected = append(protected, file.Path)
+		}
+	}
+	for _, path := range protected {
+		s.forgetPath(path)
+	}
+}
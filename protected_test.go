@@ -0,0 +1,131 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIgnoreHashes(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"README.md":     "the standard readme",
+		"README (1).md": "the standard readme",
+		"a.txt":         "duplicate content",
+		"b.txt":         "duplicate content",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	// Protect the readme by its SHA-256, the way sha256sum writes it
+	hashList := filepath.Join(t.TempDir(), "protected.txt")
+	list := "# Copies kept on purpose\n9c1a0c2b5b2ba7ba2b5a2ab0d0d6b3a8e7d4f0b6e1e9a8c3f2d1b0a9e8d7c6b5  notes.txt\n"
+	if checksums, err := NewScanner().calculateFileHashes(filepath.Join(tmpDir, "README.md"), []string{"sha256"}); err != nil {
+		t.Fatalf("calculateFileHashes() error = %v", err)
+	} else {
+		list += checksums["sha256"] + "  README.md\n"
+	}
+	if err := os.WriteFile(hashList, []byte(list), 0644); err != nil {
+		t.Fatalf("Failed to create hash list: %v", err)
+	}
+	protected, algorithms, err := LoadHashList(hashList)
+	if err != nil {
+		t.Fatalf("LoadHashList() error = %v", err)
+	}
+	if len(protected) != 2 || len(algorithms) != 1 || algorithms[0] != "sha256" {
+		t.Fatalf("Expected 2 SHA-256 hashes, got %v (%v)", protected, algorithms)
+	}
+
+	scanner := NewScanner()
+	scanner.ProtectedHashes = protected
+	scanner.HashAlgorithms = algorithms
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Protected) != 2 {
+		t.Errorf("Expected both readmes to be protected, got %d files", len(scanner.Protected))
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	// The protected copies are neither deduplicated nor organized
+	for _, name := range []string{"README.md", "README (1).md"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected protected %s to be left in place: %v", name, err)
+		}
+	}
+	// Other duplicates are handled as usual
+	if handler.TotalRemoved != 1 {
+		t.Errorf("Expected 1 duplicate removed, got %d", handler.TotalRemoved)
+	}
+	if organizer.TotalMoved != 1 {
+		t.Errorf("Expected 1 file organized, got %d", organizer.TotalMoved)
+	}
+}
+
+func TestLoadHashListInvalid(t *testing.T) {
+	hashList := filepath.Join(t.TempDir(), "protected.txt")
+	if err := os.WriteFile(hashList, []byte("README.md\n"), 0644); err != nil {
+		t.Fatalf("Failed to create hash list: %v", err)
+	}
+	if _, _, err := LoadHashList(hashList); err == nil {
+		t.Error("Expected an error for a line that isn't a hash")
+	}
+}
+
+func TestIgnoreHashesComparingArchiveContents(t *testing.T) {
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "bundle.zip")
+	writeZipWith(t, zipPath, []string{"notes.txt"}, map[string]string{"notes.txt": "keep these"}, time.Now(), zip.Deflate)
+
+	// The list holds the zip's own MD5, not its content fingerprint
+	md5, err := NewScanner().calculateFileHash(zipPath)
+	if err != nil {
+		t.Fatalf("calculateFileHash() error = %v", err)
+	}
+
+	scanner := NewScanner()
+	scanner.CompareArchiveContents = true
+	scanner.ProtectedHashes = map[string]bool{md5: true}
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Protected) != 1 {
+		t.Errorf("Expected the zip to be protected by its MD5, got %d protected files", len(scanner.Protected))
+	}
+}
+
+func TestIgnoreHashesFlatten(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"Documents/README.md":  "the standard readme",
+		"Documents/report.pdf": "quarterly numbers",
+	})
+	md5, err := NewScanner().calculateFileHash(filepath.Join(tmpDir, "Documents", "README.md"))
+	if err != nil {
+		t.Fatalf("calculateFileHash() error = %v", err)
+	}
+
+	flattener := NewFileOrganizer(NewScanner(), false, tmpDir)
+	flattener.Scanner.ProtectedHashes = map[string]bool{md5: true}
+	if err := flattener.Flatten(false); err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Documents", "README.md")); err != nil {
+		t.Errorf("Expected the protected README.md to be left in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "report.pdf")); err != nil {
+		t.Errorf("Expected report.pdf to be flattened: %v", err)
+	}
+}
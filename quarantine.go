@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	quarantineFolder   = ".elf-trash"    // Hidden, so scans skip it
+	quarantineManifest = "manifest.json" // Records where each quarantined file came from
+	quarantineFiles    = "files"         // Holds the files, so none can clash with the manifest
+)
+
+// QuarantineEntry records a single quarantined file
+type QuarantineEntry struct {
+	OriginalPath    string    `json:"original_path"`
+	QuarantinedPath string    `json:"quarantined_path"`
+	Size            int64     `json:"size"`
+	Hash            string    `json:"hash"`
+	QuarantinedAt   time.Time `json:"quarantined_at"`
+}
+
+// Quarantine holds removed duplicates in a folder inside the scan root
+// instead of deleting them, so they can be restored until it is emptied
+type Quarantine struct {
+	Dir     string
+	Entries []QuarantineEntry
+}
+
+// OpenQuarantine opens the quarantine inside root, loading its manifest if there is one
+func OpenQuarantine(root string) (*Quarantine, error) {
+	q := &Quarantine{Dir: filepath.Join(root, quarantineFolder)}
+
+	data, err := os.ReadFile(filepath.Join(q.Dir, quarantineManifest))
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read quarantine manifest: %v", err)
+	}
+	if err := json.Unmarshal(data, &q.Entries); err != nil {
+		return nil, fmt.Errorf("cannot parse quarantine manifest: %v", err)
+	}
+	return q, nil
+}
+
+// Add moves a file into the quarantine and records it in the manifest
+func (q *Quarantine) Add(file FileInfo) error {
+	filesDir := filepath.Join(q.Dir, quarantineFiles)
+	if err := os.MkdirAll(filesDir, 0755); err != nil {
+		return fmt.Errorf("cannot create quarantine folder: %v", err)
+	}
+
+	destPath := filepath.Join(filesDir, file.Name)
+	if _, err := os.Lstat(destPath); err == nil {
+		destPath = nextFreeName(filesDir, file.Name)
+	}
+	if err := os.Rename(file.Path, destPath); err != nil {
+		return err
+	}
+
+	q.Entries = append(q.Entries, QuarantineEntry{
+		OriginalPath:    file.Path,
+		QuarantinedPath: destPath,
+		Size:            file.Size,
+		Hash:            file.Hash,
+		QuarantinedAt:   time.Now(),
+	})
+	return q.save()
+}
+
+// save writes the manifest, going through a temporary file so a crash can't leave it half-written
+func (q *Quarantine) save() error {
+	if err := writeJSONAtomic(filepath.Join(q.Dir, quarantineManifest), q.Entries); err != nil {
+		return fmt.Errorf("cannot write quarantine manifest: %v", err)
+	}
+	return nil
+}
+
+// TotalSize returns the bytes held in the quarantine
+func (q *Quarantine) TotalSize() int64 {
+	total := int64(0)
+	for _, entry := range q.Entries {
+		total += entry.Size
+	}
+	return total
+}
+
+// Empty permanently deletes everything in the quarantine
+func (q *Quarantine) Empty() error {
+	if err := os.RemoveAll(q.Dir); err != nil {
+		return fmt.Errorf("cannot empty quarantine: %v", err)
+	}
+	q.Entries = nil
+	return nil
+}
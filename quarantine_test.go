@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuarantineDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"invoice.pdf", "invoice (1).pdf", "invoice (2).pdf"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("invoice content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	quarantine, err := OpenQuarantine(tmpDir)
+	if err != nil {
+		t.Fatalf("OpenQuarantine() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	handler.Quarantine = quarantine
+	if err := handler.RemoveDuplicatesByPattern(); err != nil {
+		t.Fatalf("RemoveDuplicatesByPattern() error = %v", err)
+	}
+
+	// The original stays, the copies are quarantined rather than deleted
+	if _, err := os.Stat(filepath.Join(tmpDir, "invoice.pdf")); err != nil {
+		t.Errorf("Expected invoice.pdf to be kept: %v", err)
+	}
+	for _, name := range names[1:] {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed from the folder", name)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, quarantineFolder, quarantineFiles, name)); err != nil {
+			t.Errorf("Expected %s in the quarantine: %v", name, err)
+		}
+	}
+
+	// The manifest records where each file came from
+	reopened, err := OpenQuarantine(tmpDir)
+	if err != nil {
+		t.Fatalf("OpenQuarantine() error = %v", err)
+	}
+	if len(reopened.Entries) != 2 {
+		t.Fatalf("Expected 2 manifest entries, got %d", len(reopened.Entries))
+	}
+	for _, entry := range reopened.Entries {
+		if filepath.Dir(entry.OriginalPath) != tmpDir || entry.Hash == "" {
+			t.Errorf("Unexpected manifest entry: %+v", entry)
+		}
+	}
+	if reopened.TotalSize() != 2*int64(len("invoice content")) {
+		t.Errorf("Expected %d bytes quarantined, got %d", 2*len("invoice content"), reopened.TotalSize())
+	}
+
+	// A rescan doesn't see the quarantined copies
+	rescan := NewScanner()
+	if err := rescan.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(rescan.Files) != 1 {
+		t.Errorf("Expected 1 file after quarantining, got %d", len(rescan.Files))
+	}
+
+	// Emptying deletes them for good
+	if err := reopened.Empty(); err != nil {
+		t.Fatalf("Empty() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, quarantineFolder)); !os.IsNotExist(err) {
+		t.Error("Expected the quarantine folder to be gone")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "invoice.pdf")); err != nil {
+		t.Errorf("Expected invoice.pdf to survive emptying the quarantine: %v", err)
+	}
+}
+
+func TestQuarantineNameCollision(t *testing.T) {
+	tmpDir := t.TempDir()
+	quarantine, err := OpenQuarantine(tmpDir)
+	if err != nil {
+		t.Fatalf("OpenQuarantine() error = %v", err)
+	}
+
+	// Two different folders each give up a file with the same name
+	for _, dir := range []string{"a", "b"} {
+		path := filepath.Join(tmpDir, dir, "notes.txt")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(dir), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := quarantine.Add(FileInfo{Path: path, Name: "notes.txt", Size: 1}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	for _, name := range []string{"notes.txt", "notes (1).txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, quarantineFolder, quarantineFiles, name)); err != nil {
+			t.Errorf("Expected %s in the quarantine: %v", name, err)
+		}
+	}
+}
+
+func TestQuarantineManifestName(t *testing.T) {
+	tmpDir := t.TempDir()
+	quarantine, err := OpenQuarantine(tmpDir)
+	if err != nil {
+		t.Fatalf("OpenQuarantine() error = %v", err)
+	}
+
+	// Files named like the manifest or its temporary file aren't overwritten by it
+	for _, name := range []string{quarantineManifest, quarantineManifest + ".tmp"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("user data"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := quarantine.Add(FileInfo{Path: path, Name: name, Size: 9}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	for _, entry := range quarantine.Entries {
+		data, err := os.ReadFile(entry.QuarantinedPath)
+		if err != nil || string(data) != "user data" {
+			t.Errorf("Expected %s to hold the quarantined file, got %q (%v)", entry.QuarantinedPath, data, err)
+		}
+	}
+	if _, err := OpenQuarantine(tmpDir); err != nil {
+		t.Errorf("Expected the manifest to still be readable: %v", err)
+	}
+}
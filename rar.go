@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// RAR signatures. Only the headers are read, to list entry names; nothing is extracted.
+var (
+	rar4Signature = []byte("Rar!\x1a\x07\x00")
+	rar5Signature = []byte("Rar!\x1a\x07\x01\x00")
+)
+
+// errRarEncryptedHeaders is returned for archives whose file list is encrypted
+var errRarEncryptedHeaders = errors.New("the file list is encrypted")
+
+// RAR 4.x block types and flags
+const (
+	rar4BlockArchive    = 0x73
+	rar4BlockFile       = 0x74
+	rar4BlockEnd        = 0x7b
+	rar4ArchivePassword = 0x0080 // Archive header flag: headers are encrypted
+	rar4LongBlock       = 0x8000 // Block flag: ADD_SIZE follows the header size
+	rar4FileLarge       = 0x0100 // File flag: 64-bit sizes follow
+	rar4FileUnicode     = 0x0200 // File flag: name holds an encoded Unicode copy after a NUL
+	rar4FileDirectory   = 0x00e0 // File flag bits that together mark a directory
+)
+
+// RAR 5.0 header types and flags
+const (
+	rar5HeaderFile       = 2
+	rar5HeaderEncryption = 4
+	rar5HeaderEnd        = 5
+	rar5HasExtra         = 0x0001 // Header flag: extra area size follows
+	rar5HasData          = 0x0002 // Header flag: data size follows
+	rar5FileDirectory    = 0x0001 // File flag: entry is a directory
+	rar5FileHasTime      = 0x0002 // File flag: modification time follows
+	rar5FileHasCRC       = 0x0004 // File flag: data CRC32 follows
+)
+
+// listRarEntries reads the headers of a RAR archive and returns the names of
+// the files in it, leaving out directories. It stops with an error after
+// maxEntries entries, 0 for no limit.
+func listRarEntries(path string, maxEntries int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	signature, err := r.Peek(len(rar5Signature))
+	if err != nil && len(signature) < len(rar4Signature) {
+		return nil, fmt.Errorf("not a RAR archive")
+	}
+	switch {
+	case bytes.HasPrefix(signature, rar5Signature):
+		r.Discard(len(rar5Signature))
+		return listRar5Entries(r, maxEntries)
+	case bytes.HasPrefix(signature, rar4Signature):
+		r.Discard(len(rar4Signature))
+		return listRar4Entries(r, maxEntries)
+	}
+	return nil, fmt.Errorf("not a RAR archive")
+}
+
+// listRar4Entries lists the file names in a RAR 4.x archive, after its signature
+func listRar4Entries(r *bufio.Reader, maxEntries int) ([]string, error) {
+	var names []string
+	entries := 0
+	for {
+		var head [7]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			if err == io.EOF {
+				return names, nil
+			}
+			return nil, fmt.Errorf("truncated RAR header: %v", err)
+		}
+		blockType := head[2]
+		flags := binary.LittleEndian.Uint16(head[3:5])
+		headSize := int(binary.LittleEndian.Uint16(head[5:7]))
+		if headSize < len(head) {
+			return nil, fmt.Errorf("corrupt RAR header")
+		}
+
+		body := make([]byte, headSize-len(head))
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, fmt.Errorf("truncated RAR header: %v", err)
+		}
+
+		var dataSize uint64
+		if blockType == rar4BlockFile || flags&rar4LongBlock != 0 {
+			if len(body) < 4 {
+				return nil, fmt.Errorf("corrupt RAR header")
+			}
+			dataSize = uint64(binary.LittleEndian.Uint32(body[0:4]))
+		}
+
+		switch blockType {
+		case rar4BlockArchive:
+			if flags&rar4ArchivePassword != 0 {
+				return nil, errRarEncryptedHeaders
+			}
+		case rar4BlockFile:
+			// PACK_SIZE UNP_SIZE HOST_OS FILE_CRC FTIME UNP_VER METHOD NAME_SIZE ATTR
+			if len(body) < 25 {
+				return nil, fmt.Errorf("corrupt RAR file header")
+			}
+			nameSize := int(binary.LittleEndian.Uint16(body[19:21]))
+			nameStart := 25
+			if flags&rar4FileLarge != 0 {
+				if len(body) < 33 {
+					return nil, fmt.Errorf("corrupt RAR file header")
+				}
+				dataSize |= uint64(binary.LittleEndian.Uint32(body[25:29])) << 32
+				nameStart = 33
+			}
+			if len(body) < nameStart+nameSize {
+				return nil, fmt.Errorf("corrupt RAR file header")
+			}
+			name := body[nameStart : nameStart+nameSize]
+			if flags&rar4FileUnicode != 0 {
+				if i := bytes.IndexByte(name, 0); i >= 0 {
+					name = name[:i]
+				}
+			}
+
+			entries++
+			if maxEntries > 0 && entries > maxEntries {
+				return nil, fmt.Errorf("RAR archive has too many entries, max allowed: %d", maxEntries)
+			}
+			if flags&rar4FileDirectory != rar4FileDirectory {
+				names = append(names, strings.ReplaceAll(string(name), "\\", "/"))
+			}
+		case rar4BlockEnd:
+			return names, nil
+		}
+
+		if err := skipBytes(r, dataSize); err != nil {
+			return nil, fmt.Errorf("truncated RAR archive: %v", err)
+		}
+	}
+}
+
+// listRar5Entries lists the file names in a RAR 5.0 archive, after its signature
+func listRar5Entries(r *bufio.Reader, maxEntries int) ([]string, error) {
+	var names []string
+	entries := 0
+	for {
+		var crc [4]byte
+		if _, err := io.ReadFull(r, crc[:]); err != nil {
+			if err == io.EOF {
+				return names, nil
+			}
+			return nil, fmt.Errorf("truncated RAR header: %v", err)
+		}
+		headSize, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("truncated RAR header: %v", err)
+		}
+		if headSize == 0 || headSize > 2*1024*1024 {
+			return nil, fmt.Errorf("corrupt RAR header")
+		}
+		header := make([]byte, headSize)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("truncated RAR header: %v", err)
+		}
+
+		h := bytes.NewReader(header)
+		headerType, _ := binary.ReadUvarint(h)
+		flags, _ := binary.ReadUvarint(h)
+		if flags&rar5HasExtra != 0 {
+			binary.ReadUvarint(h)
+		}
+		var dataSize uint64
+		if flags&rar5HasData != 0 {
+			if dataSize, err = binary.ReadUvarint(h); err != nil {
+				return nil, fmt.Errorf("corrupt RAR header")
+			}
+		}
+
+		switch headerType {
+		case rar5HeaderEncryption:
+			return nil, errRarEncryptedHeaders
+		case rar5HeaderFile:
+			name, dir, err := readRar5FileHeader(h)
+			if err != nil {
+				return nil, err
+			}
+			entries++
+			if maxEntries > 0 && entries > maxEntries {
+				return nil, fmt.Errorf("RAR archive has too many entries, max allowed: %d", maxEntries)
+			}
+			if !dir {
+				names = append(names, name)
+			}
+		case rar5HeaderEnd:
+			return names, nil
+		}
+
+		if err := skipBytes(r, dataSize); err != nil {
+			return nil, fmt.Errorf("truncated RAR archive: %v", err)
+		}
+	}
+}
+
+// readRar5FileHeader reads the name of a RAR 5.0 file header and whether it is a directory
+func readRar5FileHeader(h *bytes.Reader) (string, bool, error) {
+	fileFlags, err := binary.ReadUvarint(h)
+	if err != nil {
+		return "", false, fmt.Errorf("corrupt RAR file header")
+	}
+	binary.ReadUvarint(h) // Unpacked size
+	binary.ReadUvarint(h) // Attributes
+	if fileFlags&rar5FileHasTime != 0 {
+		h.Seek(4, io.SeekCurrent)
+	}
+	if fileFlags&rar5FileHasCRC != 0 {
+		h.Seek(4, io.SeekCurrent)
+	}
+	binary.ReadUvarint(h) // Compression information
+	binary.ReadUvarint(h) // Host OS
+	nameLength, err := binary.ReadUvarint(h)
+	if err != nil || nameLength > uint64(h.Len()) {
+		return "", false, fmt.Errorf("corrupt RAR file header")
+	}
+	name := make([]byte, nameLength)
+	io.ReadFull(h, name)
+	return string(name), fileFlags&rar5FileDirectory != 0, nil
+}
+
+// skipBytes discards n bytes from r
+func skipBytes(r *bufio.Reader, n uint64) error {
+	if n > math.MaxInt64 {
+		return fmt.Errorf("entry too large")
+	}
+	_, err := io.CopyN(io.Discard, r, int64(n))
+	return err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// rar5Header builds a RAR 5.0 header block, including its CRC and size
+func rar5Header(fields ...[]byte) []byte {
+	var body []byte
+	for _, field := range fields {
+		body = append(body, field...)
+	}
+	size := binary.AppendUvarint(nil, uint64(len(body)))
+	block := append(size, body...)
+	crc := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(block))
+	return append(crc, block...)
+}
+
+func vint(v uint64) []byte {
+	return binary.AppendUvarint(nil, v)
+}
+
+// createTestRar5 writes a stored (uncompressed) RAR 5.0 archive. Names ending
+// in "/" become directories.
+func createTestRar5(path string, files map[string]string) error {
+	var buf bytes.Buffer
+	buf.Write(rar5Signature)
+	buf.Write(rar5Header(vint(1), vint(0), vint(0))) // Main archive header
+	for name, content := range files {
+		fileFlags := uint64(rar5FileHasCRC)
+		if name[len(name)-1] == '/' {
+			fileFlags |= rar5FileDirectory
+			name = name[:len(name)-1]
+		}
+		buf.Write(rar5Header(
+			vint(rar5HeaderFile), vint(rar5HasData), vint(uint64(len(content))),
+			vint(fileFlags), vint(uint64(len(content))), vint(0x20),
+			binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte(content))),
+			vint(0), vint(0), vint(uint64(len(name))), []byte(name),
+		))
+		buf.WriteString(content)
+	}
+	buf.Write(rar5Header(vint(rar5HeaderEnd), vint(0), vint(0)))
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// rar4Block builds a RAR 4.x block header
+func rar4Block(blockType byte, flags uint16, body []byte) []byte {
+	head := []byte{0, 0, blockType}
+	head = binary.LittleEndian.AppendUint16(head, flags)
+	head = binary.LittleEndian.AppendUint16(head, uint16(7+len(body)))
+	head = append(head, body...)
+	binary.LittleEndian.PutUint16(head[0:2], uint16(crc32.ChecksumIEEE(head[2:])))
+	return head
+}
+
+// createTestRar4 writes a stored RAR 4.x archive with Windows-style names
+func createTestRar4(path string, files map[string]string, archiveFlags uint16) error {
+	var buf bytes.Buffer
+	buf.Write(rar4Signature)
+	buf.Write(rar4Block(rar4BlockArchive, archiveFlags, make([]byte, 6)))
+	for name, content := range files {
+		flags := uint16(rar4LongBlock)
+		if name[len(name)-1] == '\\' {
+			flags |= rar4FileDirectory
+			name = name[:len(name)-1]
+		}
+		var body []byte
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(content))) // Packed size
+		body = binary.LittleEndian.AppendUint32(body, uint32(len(content))) // Unpacked size
+		body = append(body, 2)                                              // Host OS: Windows
+		body = binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE([]byte(content)))
+		body = binary.LittleEndian.AppendUint32(body, 0) // DOS time
+		body = append(body, 29, 0x30)                    // Version, method: store
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(name)))
+		body = binary.LittleEndian.AppendUint32(body, 0x20)
+		body = append(body, name...)
+		buf.Write(rar4Block(rar4BlockFile, flags, body))
+		buf.WriteString(content)
+	}
+	buf.Write(rar4Block(rar4BlockEnd, 0x4000, nil))
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func TestListRarEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	rar5Path := filepath.Join(tmpDir, "photos5.rar")
+	if err := createTestRar5(rar5Path, map[string]string{
+		"holiday/":          "",
+		"holiday/beach.jpg": "fake image data",
+	}); err != nil {
+		t.Fatalf("Failed to create RAR5 fixture: %v", err)
+	}
+	names, err := listRarEntries(rar5Path, 0)
+	if err != nil {
+		t.Fatalf("listRarEntries(RAR5) error = %v", err)
+	}
+	if want := []string{"holiday/beach.jpg"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("RAR5 entries = %v, want %v", names, want)
+	}
+
+	rar4Path := filepath.Join(tmpDir, "photos4.rar")
+	if err := createTestRar4(rar4Path, map[string]string{
+		"holiday\\":          "",
+		"holiday\\beach.jpg": "fake image data",
+	}, 0); err != nil {
+		t.Fatalf("Failed to create RAR4 fixture: %v", err)
+	}
+	names, err = listRarEntries(rar4Path, 0)
+	if err != nil {
+		t.Fatalf("listRarEntries(RAR4) error = %v", err)
+	}
+	if want := []string{"holiday/beach.jpg"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("RAR4 entries = %v, want %v", names, want)
+	}
+
+	if _, err := listRarEntries(rar5Path, 1); err == nil {
+		t.Error("Expected an error for a RAR archive with more entries than allowed")
+	}
+
+	encryptedPath := filepath.Join(tmpDir, "secret.rar")
+	if err := createTestRar4(encryptedPath, nil, rar4ArchivePassword); err != nil {
+		t.Fatalf("Failed to create encrypted fixture: %v", err)
+	}
+	if _, err := listRarEntries(encryptedPath, 0); err != errRarEncryptedHeaders {
+		t.Errorf("listRarEntries(encrypted) error = %v, want %v", err, errRarEncryptedHeaders)
+	}
+
+	notRar := filepath.Join(tmpDir, "fake.rar")
+	os.WriteFile(notRar, []byte("just some text"), 0644)
+	if _, err := listRarEntries(notRar, 0); err == nil {
+		t.Error("Expected an error for a file that isn't a RAR archive")
+	}
+}
+
+func TestProcessZipFilesCategorizesRar(t *testing.T) {
+	tmpDir := t.TempDir()
+	rarPath := filepath.Join(tmpDir, "photos.rar")
+	if err := createTestRar5(rarPath, map[string]string{
+		"image1.jpg": "fake image data",
+		"image2.png": "fake image data",
+		"image3.gif": "fake image data",
+		"notes.txt":  "fake text data",
+	}); err != nil {
+		t.Fatalf("Failed to create RAR fixture: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.ProcessZipFiles(); err != nil {
+		t.Fatalf("ProcessZipFiles() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "Images", "photos.rar")); err != nil {
+		t.Errorf("RAR archive of images was not moved to Images: %v", err)
+	}
+}
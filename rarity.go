@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rareFolder is where files of a rarely seen type go
+const rareFolder = "Misc"
+
+// extensionCounts counts how many files there are of each extension, ignoring case
+func extensionCounts(files []FileInfo) map[string]int {
+	counts := make(map[string]int)
+	for _, file := range files {
+		counts[strings.ToLower(file.Extension)]++
+	}
+	return counts
+}
+
+// OrganizeByRarity moves files whose extension turns up threshold times or
+// fewer into Misc, and everything else into its category folder as usual, so
+// one-off file types don't each get a folder of their own. Extensions are
+// counted over every file taking part in the run, including ones organized
+// before, so a type stays common once it is.
+func (fo *FileOrganizer) OrganizeByRarity(threshold int) error {
+	fmt.Println("🧮 Starting rarity-based organization...")
+	fmt.Println()
+
+	var files []FileInfo
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	counts := extensionCounts(files)
+	rarityGroups := make(map[string][]FileInfo)
+	for _, file := range files {
+		folder := rareFolder
+		if count := counts[strings.ToLower(file.Extension)]; count > threshold {
+			folder = fo.categoryFolder(file.Category)
+		} else if fo.Scanner.Verbose {
+			fmt.Printf("   🔎 %s: only %d %s files, moving it to %s\n", file.Name, count, extensionLabel(file.Extension), rareFolder)
+		}
+		rarityGroups[folder] = append(rarityGroups[folder], file)
+	}
+
+	return fo.moveGroups(rarityGroups, "🧮", "rarity-based")
+}
+
+// extensionLabel names an extension for messages
+func extensionLabel(ext string) string {
+	if ext == "" {
+		return "extensionless"
+	}
+	return strings.ToLower(ext)
+}
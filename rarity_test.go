@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrganizeByRarity(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"odd.xyz", "manual.pdf"}
+	for i := 1; i <= 5; i++ {
+		names = append(names, fmt.Sprintf("photo%d.jpg", i))
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByRarity(2); err != nil {
+		t.Fatalf("OrganizeByRarity() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"odd.xyz":    rareFolder,
+		"manual.pdf": rareFolder,
+	}
+	for i := 1; i <= 5; i++ {
+		expected[fmt.Sprintf("photo%d.jpg", i)] = "Images"
+	}
+	for name, folder := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder, name)); err != nil {
+			t.Errorf("Expected %s in %s: %v", name, folder, err)
+		}
+	}
+
+	// Running again counts the organized files too, so nothing moves
+	scanner = NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer = NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByRarity(2); err != nil {
+		t.Fatalf("OrganizeByRarity() error = %v", err)
+	}
+	if organizer.TotalMoved != 0 {
+		t.Errorf("Second run moved %d files, want 0", organizer.TotalMoved)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	remoteManifestTimeout = 30 * time.Second  // Longest a remote manifest fetch may take
+	maxRemoteManifestSize = 256 * 1024 * 1024 // Refuse manifests larger than this
+)
+
+// FetchRemoteManifest downloads a manifest in the format --index writes and
+// returns the hashes it lists. It only ever reads from the URL.
+func FetchRemoteManifest(url string, timeout time.Duration) (map[string]bool, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("remote manifest must be an http:// or https:// URL: %s", url)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch remote manifest: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot fetch remote manifest: %s returned %s", url, resp.Status)
+	}
+
+	var manifest FileIndex
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteManifestSize)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("cannot parse remote manifest: %v", err)
+	}
+
+	hashes := make(map[string]bool, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		if entry.Hash != "" {
+			hashes[strings.ToLower(entry.Hash)] = true
+		}
+	}
+	return hashes, nil
+}
+
+// MarkArchived flags the scanned files whose hash is in a remote manifest as
+// duplicates of a copy archived elsewhere, so they are left out of organizing.
+// It returns how many files were flagged.
+func (s *Scanner) MarkArchived(hashes map[string]bool) int {
+	mark := func(file *FileInfo) bool {
+		if file.Hash == "" || !hashes[strings.ToLower(file.Hash)] {
+			return false
+		}
+		file.Archived = true
+		file.IsDuplicate = true
+		return true
+	}
+
+	marked := 0
+	for i := range s.Files {
+		if mark(&s.Files[i]) {
+			marked++
+		}
+	}
+	for _, files := range s.Categories {
+		for i := range files {
+			mark(&files[i])
+		}
+	}
+	return marked
+}
+
+// PrintArchived lists the files already archived elsewhere
+func (s *Scanner) PrintArchived() {
+	var archived []FileInfo
+	for _, file := range s.Files {
+		if file.Archived {
+			archived = append(archived, file)
+		}
+	}
+	if len(archived) == 0 {
+		fmt.Println("\n☁️  None of the scanned files are in the remote manifest")
+		return
+	}
+
+	fmt.Printf("\n☁️  %d files are already archived elsewhere and will be left alone:\n", len(archived))
+	for _, file := range archived {
+		fmt.Printf("  - %s (%.2f MB)\n", file.Path, float64(file.Size)/1024/1024)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoteManifestFlagsArchivedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"backed-up.jpg", "new.jpg"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	var archivedHash string
+	for _, file := range scanner.Files {
+		if file.Name == "backed-up.jpg" {
+			archivedHash = file.Hash
+		}
+	}
+
+	manifest := FileIndex{Entries: []IndexEntry{
+		{Name: "holiday.jpg", Path: "/archive/Images/holiday.jpg", Hash: archivedHash},
+		{Name: "other.pdf", Path: "/archive/Documents/other.pdf", Hash: "0123456789abcdef0123456789abcdef"},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected a read-only GET, got %s", r.Method)
+		}
+		json.NewEncoder(w).Encode(manifest)
+	}))
+	defer server.Close()
+
+	hashes, err := FetchRemoteManifest(server.URL+"/index.json", 5*time.Second)
+	if err != nil {
+		t.Fatalf("FetchRemoteManifest() error = %v", err)
+	}
+	if marked := scanner.MarkArchived(hashes); marked != 1 {
+		t.Errorf("Expected 1 file to be flagged, got %d", marked)
+	}
+	for _, file := range scanner.Files {
+		if want := file.Name == "backed-up.jpg"; file.Archived != want || file.IsDuplicate != want {
+			t.Errorf("%s: archived = %v, duplicate = %v, want %v", file.Name, file.Archived, file.IsDuplicate, want)
+		}
+	}
+
+	// Archived files are left where they are
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "backed-up.jpg")); err != nil {
+		t.Errorf("Expected the archived file to stay in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Images", "new.jpg")); err != nil {
+		t.Errorf("Expected new.jpg to be organized: %v", err)
+	}
+}
+
+func TestFetchRemoteManifestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := FetchRemoteManifest(server.URL, 5*time.Second); err == nil {
+		t.Error("Expected an error for a missing manifest")
+	}
+	if _, err := FetchRemoteManifest("file:///etc/passwd", 5*time.Second); err == nil {
+		t.Error("Expected an error for a non-HTTP URL")
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	if _, err := FetchRemoteManifest(slow.URL, 50*time.Millisecond); err == nil {
+		t.Error("Expected an error when the server doesn't answer in time")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import "unicode/utf8"
+
+// DuplicateResolver decides which copy in a group of identical files to keep.
+// DuplicateHandler asks it once per group and removes or moves the rest, so
+// library users can plug in their own rule by implementing it.
+type DuplicateResolver interface {
+	Keep(files []FileInfo) FileInfo
+}
+
+// NewestResolver keeps the most recently modified copy. It is the default.
+type NewestResolver struct{}
+
+// Keep returns the most recently modified file
+func (NewestResolver) Keep(files []FileInfo) FileInfo {
+	return findNewest(files)
+}
+
+// OldestResolver keeps the copy that was modified longest ago
+type OldestResolver struct{}
+
+// Keep returns the least recently modified file
+func (OldestResolver) Keep(files []FileInfo) FileInfo {
+	oldest := files[0]
+	for _, file := range files {
+		if file.LastModified.Before(oldest.LastModified) {
+			oldest = file
+		}
+	}
+	return oldest
+}
+
+// LargestResolver keeps the largest copy, falling back to the newest when sizes
+// are equal, as they are for exact duplicates
+type LargestResolver struct{}
+
+// Keep returns the largest file
+func (LargestResolver) Keep(files []FileInfo) FileInfo {
+	largest := files[0]
+	for _, file := range files {
+		if file.Size > largest.Size || (file.Size == largest.Size && file.LastModified.After(largest.LastModified)) {
+			largest = file
+		}
+	}
+	return largest
+}
+
+// OriginalityWeights sets how much each sign of being the original adds to a
+// file's score in PatternResolver. Length and age are scaled within the group,
+// so the shortest or oldest copy gets the full weight and the longest or newest none.
+type OriginalityWeights struct {
+	NoCopyMarker float64 `json:"no_copy_marker"` // Name has no copy marker like "(1)" or "copy"
+	ShortName    float64 `json:"short_name"`     // Name is short compared to the other copies
+	OldModTime   float64 `json:"old_mod_time"`   // Modified early compared to the other copies
+}
+
+// defaultOriginalityWeights let a copy marker outweigh length and age together
+var defaultOriginalityWeights = OriginalityWeights{NoCopyMarker: 3, ShortName: 1, OldModTime: 1}
+
+// PatternResolver keeps the copy that scores highest for looking like the
+// original, such as "report.pdf" over "report (1).pdf". Ties go to the newest.
+type PatternResolver struct {
+	Weights *OriginalityWeights // nil for the default weights
+}
+
+// Keep returns the file that looks most like the original
+func (r PatternResolver) Keep(files []FileInfo) FileInfo {
+	weights := defaultOriginalityWeights
+	if r.Weights != nil {
+		weights = *r.Weights
+	}
+
+	shortest, longest := nameLength(files[0].Name), nameLength(files[0].Name)
+	oldest, newest := files[0].LastModified, files[0].LastModified
+	for _, file := range files {
+		if n := nameLength(file.Name); n < shortest {
+			shortest = n
+		} else if n > longest {
+			longest = n
+		}
+		if file.LastModified.Before(oldest) {
+			oldest = file.LastModified
+		} else if file.LastModified.After(newest) {
+			newest = file.LastModified
+		}
+	}
+
+	score := func(file FileInfo) float64 {
+		total := 0.0
+		if isOriginalName(file.Name) {
+			total += weights.NoCopyMarker
+		}
+		if longest > shortest {
+			total += weights.ShortName * float64(longest-nameLength(file.Name)) / float64(longest-shortest)
+		}
+		if span := newest.Sub(oldest); span > 0 {
+			total += weights.OldModTime * float64(newest.Sub(file.LastModified)) / float64(span)
+		}
+		return total
+	}
+
+	best := findNewest(files)
+	bestScore := score(best)
+	for _, file := range files {
+		if s := score(file); s > bestScore {
+			best, bestScore = file, s
+		}
+	}
+	return best
+}
+
+// nameLength returns the length of a file name in characters
+func nameLength(name string) int {
+	return utf8.RuneCountInString(name)
+}
+
+// keeper returns the file to keep in a group, using the handler's resolver
+func (dh *DuplicateHandler) keeper(files []FileInfo) FileInfo {
+	if dh.Resolver == nil {
+		return NewestResolver{}.Keep(keepable(files))
+	}
+	return dh.Resolver.Keep(keepable(files))
+}
+
+// keepable returns the copies in a group that may be kept. A symbolic link
+// is only kept when every copy is one: keeping a link and removing the file
+// it points to would leave a link to nothing.
+func keepable(files []FileInfo) []FileInfo {
+	var regular []FileInfo
+	for _, file := range files {
+		if !file.IsSymlink {
+			regular = append(regular, file)
+		}
+	}
+	if len(regular) == 0 {
+		return files
+	}
+	return regular
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// alphabeticalResolver keeps the copy whose name sorts first
+type alphabeticalResolver struct{}
+
+func (alphabeticalResolver) Keep(files []FileInfo) FileInfo {
+	first := files[0]
+	for _, file := range files {
+		if file.Name < first.Name {
+			first = file
+		}
+	}
+	return first
+}
+
+func TestCustomDuplicateResolver(t *testing.T) {
+	tmpDir := t.TempDir()
+	now := time.Now()
+	names := []string{"zebra.txt", "apple.txt", "mango.txt"}
+	for i, name := range names {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		// Make zebra.txt the newest, so the default would keep it
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set times on %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	handler.Resolver = alphabeticalResolver{}
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "apple.txt")); err != nil {
+		t.Errorf("Expected apple.txt to be kept: %v", err)
+	}
+	for _, name := range []string{"zebra.txt", "mango.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", name)
+		}
+	}
+}
+
+func TestBuiltinResolvers(t *testing.T) {
+	now := time.Now()
+	files := []FileInfo{
+		{Path: "/d/report (1).pdf", Name: "report (1).pdf", Size: 10, LastModified: now},
+		{Path: "/d/report.pdf", Name: "report.pdf", Size: 10, LastModified: now.Add(-2 * time.Hour)},
+		{Path: "/d/report copy.pdf", Name: "report copy.pdf", Size: 10, LastModified: now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		resolver DuplicateResolver
+		expected string
+	}{
+		{NewestResolver{}, "report (1).pdf"},
+		{OldestResolver{}, "report.pdf"},
+		{LargestResolver{}, "report (1).pdf"},
+		{PatternResolver{}, "report.pdf"},
+	}
+	for _, tt := range tests {
+		if kept := tt.resolver.Keep(files); kept.Name != tt.expected {
+			t.Errorf("%T kept %s, want %s", tt.resolver, kept.Name, tt.expected)
+		}
+	}
+
+	// Without a name that looks original, and with length and age cancelling out,
+	// the pattern resolver keeps the newest
+	copies := []FileInfo{files[0], files[2]}
+	if kept := (PatternResolver{}).Keep(copies); kept.Name != "report (1).pdf" {
+		t.Errorf("PatternResolver kept %s, want report (1).pdf", kept.Name)
+	}
+}
+
+func TestPatternResolverWeights(t *testing.T) {
+	now := time.Now()
+	files := []FileInfo{
+		{Path: "/d/scan.pdf", Name: "scan.pdf", Size: 10, LastModified: now},
+		{Path: "/d/scan 2019 tax return.pdf", Name: "scan 2019 tax return.pdf", Size: 10, LastModified: now.Add(-48 * time.Hour)},
+		{Path: "/d/scan (1).pdf", Name: "scan (1).pdf", Size: 10, LastModified: now.Add(-72 * time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		weights  *OriginalityWeights
+		expected string
+	}{
+		{"short names win", &OriginalityWeights{NoCopyMarker: 3, ShortName: 2, OldModTime: 1}, "scan.pdf"},
+		{"old dates win", &OriginalityWeights{NoCopyMarker: 3, ShortName: 1, OldModTime: 2}, "scan 2019 tax return.pdf"},
+		{"copy marker ignored", &OriginalityWeights{ShortName: 1, OldModTime: 2}, "scan (1).pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kept := (PatternResolver{Weights: tt.weights}).Keep(files); kept.Name != tt.expected {
+				t.Errorf("Kept %s, want %s", kept.Name, tt.expected)
+			}
+		})
+	}
+}
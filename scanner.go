@@ -2,12 +2,20 @@ package main
 
 import (
 	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
+	"sort"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -19,9 +27,123 @@ type FileInfo struct {
 	Extension    string
 	Category     string
 	Hash         string
+	Hashes       map[string]string // Extra checksums by algorithm, with the MD5, when HashAlgorithms is set or Hash is a zip fingerprint
 	LastModified time.Time
+	LastAccessed time.Time // Zero if the platform doesn't record it
+	FileID       string    // Device and inode, shared by hard links; empty if unknown
 	IsDuplicate  bool
+	Archived     bool // Its hash is in a remote manifest, so a copy is archived elsewhere
 	IsZip        bool
+	IsSymlink    bool         // A symbolic link; Size is that of the link, not its target
+	Snapshot     FileSnapshot // What the file looked like when scanned
+}
+
+// FileSnapshot records a file's size and modification time at scan time, so
+// changes made before it is moved or removed can be detected
+type FileSnapshot struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// changedSinceScan re-checks a file right before it is touched and reports
+// whether it was modified after it was scanned, printing a warning if so
+func changedSinceScan(file FileInfo) bool {
+	if file.Snapshot.ModTime.IsZero() {
+		return false // Not from a scan, so there's nothing to compare against
+	}
+	info, err := os.Lstat(file.Path) // The scan saw symlinks themselves, not what they point to
+	if err != nil {
+		return false // Let the move itself report the problem
+	}
+	if info.Size() != file.Snapshot.Size || !info.ModTime().Equal(file.Snapshot.ModTime) {
+		fmt.Printf("   ⚠️  %s changed since scan, skipping\n", file.Name)
+		return true
+	}
+	return false
+}
+
+const (
+	defaultHashBlockSize = 32 * 1024          // 32KB read buffer for hashing
+	noCacheThreshold     = 256 * 1024 * 1024 // Files this large skip the page cache when NoCacheHashing is set
+	defaultMaxDepth      = 64                // Folders nested deeper than this below the scan root are skipped
+	defaultMaxPathLength = 4096              // Paths longer than this are skipped (PATH_MAX on Linux)
+)
+
+// defaultScreenshotPatterns match the names macOS and Windows give screenshots
+var defaultScreenshotPatterns = []string{
+	"screenshot*",
+	"screen shot*",
+	"screen_shot*",
+	"screencapture*",
+}
+
+// defaultCategoryExtensions lists the extensions that belong to each category
+var defaultCategoryExtensions = map[string][]string{
+	"Images":       {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".svg", ".webp", ".avif", ".heic", ".heif", ".jxl"},
+	"Documents":    {".pdf", ".doc", ".docx", ".txt", ".rtf", ".odt", ".xls", ".xlsx", ".ppt", ".pptx"},
+	"Videos":       {".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm", ".m4v", ".3gp", ".3g2", ".hevc", ".av1"},
+	"Music":        {".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma", ".opus", ".m4a", ".aiff"},
+	"Applications": {".pkg", ".exe", ".msi", ".deb", ".rpm", ".app"},
+	"Archives":     {".zip", ".rar", ".7z", ".tar", ".gz", ".bz2"},
+	"Disk Images":  {".iso", ".dmg"},
+}
+
+// extensionIndex inverts a category to extensions map so extensions can be looked up
+func extensionIndex(categoryExtensions map[string][]string) map[string]string {
+	index := make(map[string]string)
+	for category, exts := range categoryExtensions {
+		for _, ext := range exts {
+			index[strings.ToLower(ext)] = category
+		}
+	}
+	return index
+}
+
+var defaultExtensionCategories = extensionIndex(defaultCategoryExtensions)
+
+// splitInstallerCategories holds extensions that only get a category with
+// SplitInstallers set, when no extension rule claims them. Without it they
+// go by name like any other unknown extension.
+var splitInstallerCategories = map[string]string{
+	".img": "Disk Images", // Raw disk images, often firmware or OS installers
+}
+
+// inProgressExtensions mark files a browser, torrent client or download manager
+// is still writing. Moving or deduping them can break the download.
+var inProgressExtensions = []string{
+	".part",       // Firefox, wget
+	".partial",    // Edge (legacy), IE
+	".crdownload", // Chrome, Edge, Brave
+	".download",   // Safari (a folder), other macOS apps
+	".opdownload", // Opera
+	".filepart",   // WinSCP
+	".!ut",        // uTorrent
+	".!bt",        // BitTorrent, BitComet
+	".!qb",        // qBittorrent
+	".aria2",      // aria2 control file
+}
+
+// isInProgressDownload reports whether a file or folder name looks like a download that hasn't finished
+func isInProgressDownload(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, inProgress := range inProgressExtensions {
+		if ext == inProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedDir reports whether a folder is never looked into: hidden folders,
+// like .git or .Trash, and macOS .app bundles
+func skippedDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".app")
+}
+
+// skippedFile reports whether a file is never touched: hidden files, like
+// .DS_Store, and category sidecars, which travel with their own file
+func skippedFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(strings.ToLower(name), categorySidecarExt)
 }
 
 // Scanner handles scanning the downloads folder
@@ -29,15 +151,95 @@ type Scanner struct {
 	Files      []FileInfo
 	Duplicates map[string][]FileInfo // Map of hash to files with that hash
 	Categories map[string][]FileInfo // Map of category to files in that category
+
+	ScreenshotPatterns []string // Filename patterns for the Screenshots category, empty to keep them in Images
+	SplitInstallers    bool     // Put .dmg/.pkg installers in Installers instead of Disk Images/Applications
+	HashBlockSize      int      // Read buffer size used when hashing files
+	NoCacheHashing     bool     // Avoid filling the page cache when hashing huge files
+	PartialThreshold   float64  // Report files sharing at least this fraction of content, 0 to skip
+	Verbose            bool     // Explain each categorization decision while scanning
+	HashAlgorithms     []string // Extra checksums to compute alongside MD5 in the same read, e.g. "sha256"
+	SkipHashing        bool     // Don't hash files or look for duplicates, for when only organizing
+	CRCPrefilter       bool     // Only hash files sharing a size and CRC32 with another file, leaving the rest without a hash
+	IgnorePatterns     []string // Filename patterns to leave out of the scan, on top of hidden files
+	IncludePatterns    []string // If set, only filenames matching one of these are scanned
+	ExcludePatterns    []string // Filenames matching one of these aren't scanned
+	IncludeWins        bool     // Scan files matching both an include and an exclude pattern, and any file matching neither
+	ExcludeDirs        []string // Absolute paths of folders not to scan, like a destination inside the scan root
+	IncludeInProgress  bool     // Scan unfinished downloads like .crdownload and .part too
+	MaxDepth           int      // Skip folders nested deeper than this below the scan root, 0 for no limit
+	MaxPathLength      int      // Skip files and folders, and leave files in place, when a path is longer than this, 0 for no limit
+	TrialLimit         int      // Stop scanning once this many files have been collected, 0 for no limit
+	MaxFiles           int      // Refuse a folder holding more files than this, in case the path is wrong, 0 for no limit
+
+	ExtensionCategories map[string]string // Maps extensions to categories, nil for the built-in rules
+	ExtensionOverrides  map[string]string // Maps extensions to the folder they go in, whatever their category or content
+	Plugins             *PluginRegistry   // Asked to categorize each file, nil to use only the built-in rules
+	Throttle            *RateLimiter      // Limits how fast files are read for hashing, nil for no limit
+	Deadline            *Deadline         // Stops the scan once --max-runtime is up, nil for no limit
+
+	CompareArchiveContents bool // Treat zips holding the same files as duplicates, even if the zips differ
+
+	ProtectedHashes map[string]bool // Files with one of these hashes are left out of every operation
+	Protected       []FileInfo      // Files left out because their hash is protected
+
+	Since time.Time // Only scan files modified at or after this, zero to scan everything
+
+	CategoryFromParent bool            // Use the name of the top-level folder a file is in as its category, for files below the scan root
+	FolderCategories   map[string]bool // Categories that came from folder names rather than the built-in rules
+
+	PartialDuplicates []PartialDuplicate // Files that share much of their content, for manual review
+
+	InProgress      []string // Unfinished downloads left out of the scan
+	Unchanged       int      // Files left out because they weren't modified since Since
+	TooDeep         []string // Folders skipped because they are nested deeper than MaxDepth
+	TooLong         []string // Paths skipped because they are longer than MaxPathLength
+	TrialEnded      bool     // The scan stopped early because it reached TrialLimit
+	DeadlineReached bool     // The scan stopped early because Deadline ran out
 }
 
+// errTooManyFiles stops the scan once it has come across more than MaxFiles files
+var errTooManyFiles = errors.New("too many files")
+
 // NewScanner creates a new Scanner instance
 func NewScanner() *Scanner {
 	return &Scanner{
 		Files:      make([]FileInfo, 0),
 		Duplicates: make(map[string][]FileInfo),
 		Categories: make(map[string][]FileInfo),
+		HashBlockSize: defaultHashBlockSize,
+		MaxDepth:      defaultMaxDepth,
+		MaxPathLength: defaultMaxPathLength,
+	}
+}
+
+// parentCategory uses the name of the top-level folder under root that a file
+// is in as its category, so files in its subfolders share it. A folder named
+// like a built-in category, such as "images", gets that category.
+func (s *Scanner) parentCategory(root, path string) (string, string) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = path
+	}
+	parent := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	if known, ok := s.folderCategory(parent); ok {
+		return known, fmt.Sprintf("parent folder %s -> %s", parent, known)
 	}
+	return parent, fmt.Sprintf("parent folder -> %s", parent)
+}
+
+// folderCategory returns the category of files that belong in the named
+// folder, and whether it is a known one. Any other name becomes a category
+// of its own, organized into a folder of that name.
+func (s *Scanner) folderCategory(folder string) (string, bool) {
+	if known, ok := s.knownCategory(folder); ok {
+		return known, true
+	}
+	if s.FolderCategories == nil {
+		s.FolderCategories = make(map[string]bool)
+	}
+	s.FolderCategories[folder] = true
+	return folder, false
 }
 
 // checkFilePermissions checks if we have read permissions for a file
@@ -54,28 +256,93 @@ func (s *Scanner) checkFilePermissions(filePath string) error {
 func (s *Scanner) ScanDirectory(dirPath string) error {
 	fmt.Printf("🔍 Scanning directory: %s\n", dirPath)
 
+	seen := 0
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			// A path the system can't handle shouldn't stop the rest of the scan
+			if errors.Is(err, syscall.ENAMETOOLONG) {
+				s.TooLong = append(s.TooLong, path)
+				if info != nil && info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 			return err
 		}
 
+		// Stop looking once the time budget is used up
+		if s.Deadline.Expired() {
+			s.DeadlineReached = true
+			return filepath.SkipAll
+		}
+
+		// Leave out paths that are too long to move safely
+		if s.pathTooLong(path) {
+			s.TooLong = append(s.TooLong, path)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		// Skip directories
 		if info.IsDir() {
-			// Skip hidden directories (like .DS_Store on macOS)
-			if strings.HasPrefix(info.Name(), ".") {
+			// Don't follow runaway nesting
+			if s.MaxDepth > 0 && pathDepth(dirPath, path) > s.MaxDepth {
+				s.TooDeep = append(s.TooDeep, path)
 				return filepath.SkipDir
 			}
-			
-			// Skip macOS .app bundle contents
-			if strings.HasSuffix(info.Name(), ".app") {
+
+			// Skip hidden directories and macOS .app bundle contents
+			if skippedDir(info.Name()) {
+				return filepath.SkipDir
+			}
+
+			// Skip folders elf-cli is moving files into
+			if s.excludedDir(path) {
+				return filepath.SkipDir
+			}
+
+			// Safari keeps unfinished downloads in a .download folder
+			if path != dirPath && !s.IncludeInProgress && isInProgressDownload(info.Name()) {
+				s.InProgress = append(s.InProgress, path)
 				return filepath.SkipDir
 			}
 			
 			return nil
 		}
 
-		// Skip hidden files
-		if strings.HasPrefix(info.Name(), ".") {
+		// Stop before anything is changed if the folder is far bigger than expected
+		if seen++; s.MaxFiles > 0 && seen > s.MaxFiles {
+			return errTooManyFiles
+		}
+
+		// Skip hidden files and category sidecar files
+		if skippedFile(info.Name()) {
+			return nil
+		}
+
+		// Skip anything the user asked to ignore
+		if s.ignored(info.Name()) {
+			return nil
+		}
+		// Leave unfinished downloads alone so the download can complete
+		if !s.IncludeInProgress && isInProgressDownload(info.Name()) {
+			if s.Verbose {
+				fmt.Printf("   🔎 %s: still downloading, left alone\n", info.Name())
+			}
+			s.InProgress = append(s.InProgress, path)
+			return nil
+		}
+		if !s.selected(info.Name()) {
+			if s.Verbose {
+				fmt.Printf("   🔎 %s: left out by --include/--exclude\n", info.Name())
+			}
+			return nil
+		}
+		// In an incremental run, only look at files modified since the last one
+		if !s.Since.IsZero() && info.ModTime().Before(s.Since) {
+			s.Unchanged++
 			return nil
 		}
 		
@@ -97,14 +364,40 @@ func (s *Scanner) ScanDirectory(dirPath string) error {
 		}
 
 		// Determine category
-		category := s.determineCategory(ext, info.Name())
+		category, reason := s.determineCategory(ext, info.Name())
+		if s.CategoryFromParent && filepath.Dir(path) != filepath.Clean(dirPath) {
+			category, reason = s.parentCategory(filepath.Clean(dirPath), path)
+		}
+		if pluginCategory, pluginReason, ok, skip := s.pluginCategory(path, info, ext, category); skip {
+			if s.Verbose {
+				fmt.Printf("   🔎 %s: %s\n", info.Name(), pluginReason)
+			}
+			return nil
+		} else if ok {
+			category, reason = pluginCategory, pluginReason
+		}
+		if folder, ok := s.ExtensionOverrides[ext]; ok {
+			category, _ = s.folderCategory(folder)
+			reason = fmt.Sprintf("extension override %s -> %s", ext, folder)
+		}
+		if pinned, pinnedReason, ok := s.categoryOverride(path); ok {
+			category, reason = pinned, pinnedReason
+		}
+		if s.Verbose {
+			fmt.Printf("   🔎 %s: %s\n", info.Name(), reason)
+		}
 
-		// Calculate file hash for duplicate detection
-		hash, err := s.calculateFileHash(path)
-		if err != nil {
-			fmt.Printf("⚠️  Could not calculate hash for %s: %v\n", path, err)
-			// Continue without hash rather than failing completely
-			hash = ""
+		// Calculate file hash for duplicate detection, unless the CRC
+		// pre-filter hashes only likely duplicates once the walk is done
+		var hash string
+		var hashes map[string]string
+		if !s.SkipHashing && !s.CRCPrefilter {
+			hash, hashes = s.hashFile(path, info.Name(), ext)
+		}
+		// Hashing may have been cut short, so leave this file out with the rest
+		if s.Deadline.Expired() {
+			s.DeadlineReached = true
+			return filepath.SkipAll
 		}
 
 		// Create file info
@@ -115,8 +408,18 @@ func (s *Scanner) ScanDirectory(dirPath string) error {
 			Extension:    ext,
 			Category:     category,
 			Hash:         hash,
+			Hashes:       hashes,
 			LastModified: info.ModTime(),
 			IsZip:        ext == ".zip",
+			IsSymlink:    info.Mode()&os.ModeSymlink != 0,
+			Snapshot:     FileSnapshot{Size: info.Size(), ModTime: info.ModTime()},
+		}
+		// Taken from the stat made before hashing, which may itself count as an access
+		if accessed, ok := accessTime(info); ok {
+			fileInfo.LastAccessed = accessed
+		}
+		if id, ok := fileID(info); ok {
+			fileInfo.FileID = id
 		}
 
 		s.Files = append(s.Files, fileInfo)
@@ -124,70 +427,291 @@ func (s *Scanner) ScanDirectory(dirPath string) error {
 		// Add to categories map
 		s.Categories[category] = append(s.Categories[category], fileInfo)
 
+		// A trial run only looks at the first files it comes across
+		if s.TrialLimit > 0 && len(s.Files) >= s.TrialLimit {
+			s.TrialEnded = true
+			return filepath.SkipAll
+		}
+
 		return nil
 	})
 
+	if err == errTooManyFiles {
+		return fmt.Errorf("%w: %s holds more than %d files; check --path is right, then raise --max-scan-files or add --i-know-what-im-doing", err, dirPath, s.MaxFiles)
+	}
 	if err != nil {
 		return fmt.Errorf("error scanning directory: %v", err)
 	}
 
 	// Find duplicates after scanning all files
-	s.findDuplicates()
+	if s.SkipHashing {
+		fmt.Println("⏭️  Skipped duplicate detection")
+	} else {
+		if s.CRCPrefilter {
+			s.hashCandidates()
+		}
+		if len(s.ProtectedHashes) > 0 {
+			s.protectFiles()
+		}
+		s.findDuplicates()
+		if s.PartialThreshold > 0 {
+			s.findPartialDuplicates(s.PartialThreshold)
+		}
+	}
 
 	fmt.Printf("✅ Found %d files\n", len(s.Files))
 	return nil
 }
 
-// determineCategory determines the category of a file based on its extension and name
-func (s *Scanner) determineCategory(ext, name string) string {
-	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tiff", ".svg", ".webp":
-		return "Images"
-	case ".pdf", ".doc", ".docx", ".txt", ".rtf", ".odt", ".xls", ".xlsx", ".ppt", ".pptx":
-		return "Documents"
-	case ".mp4", ".avi", ".mkv", ".mov", ".wmv", ".flv", ".webm":
-		return "Videos"
-	case ".mp3", ".wav", ".flac", ".aac", ".ogg", ".wma":
-		return "Music"
-	case ".pkg", ".exe", ".msi", ".deb", ".rpm", ".app":
-		return "Applications"
-	case ".zip", ".rar", ".7z", ".tar", ".gz", ".bz2":
-		return "Archives"
-	case ".iso", ".dmg":
-		return "Disk Images"
-	default:
+// hashFile calculates a file's hash for duplicate detection, plus any extra
+// checksums, reading the file only once. A zip is hashed by its content when
+// CompareArchiveContents is set. The hash is empty if the file couldn't be read.
+func (s *Scanner) hashFile(path, name, ext string) (string, map[string]string) {
+	hashes, err := s.calculateFileHashes(path, append([]string{"md5"}, s.HashAlgorithms...))
+	if err != nil {
+		// Running out of time isn't a problem with the file
+		if !s.Deadline.Expired() {
+			fmt.Printf("⚠️  Could not calculate hash for %s: %v\n", path, err)
+		}
+		// Continue without hash rather than failing completely
+		return "", nil
+	}
+	hash := hashes["md5"]
+
+	// Match re-zipped copies of the same files too
+	if s.CompareArchiveContents && ext == ".zip" {
+		if fingerprint, err := archiveFingerprint(path); err == nil {
+			hash = fingerprint
+		} else if s.Verbose {
+			fmt.Printf("   🔎 %s: comparing the zip itself, not its content: %v\n", name, err)
+		}
+	}
+
+	// Keep the zip's own MD5 next to its fingerprint, so --ignore-hashes can still match it
+	if len(s.HashAlgorithms) == 0 && !strings.HasPrefix(hash, archiveHashPrefix) {
+		hashes = nil
+	}
+	return hash, hashes
+}
+
+// pathTooLong reports whether path is longer than MaxPathLength
+func (s *Scanner) pathTooLong(path string) bool {
+	return s.MaxPathLength > 0 && len(path) > s.MaxPathLength
+}
+
+// pathDepth returns how many folders deep path is below root, where root itself is 0
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// determineCategory determines the category of a file based on its extension and name,
+// along with the reason for the decision
+func (s *Scanner) determineCategory(ext, name string) (string, string) {
+	matched := func(category string) (string, string) {
+		return category, fmt.Sprintf("matched extension %s -> %s", ext, category)
+	}
+
+	extensionCategories := s.ExtensionCategories
+	if extensionCategories == nil {
+		extensionCategories = defaultExtensionCategories
+	}
+
+	category := extensionCategories[ext]
+	if category == "" && s.SplitInstallers {
+		category = splitInstallerCategories[ext]
+	}
+
+	switch category {
+	case "Images":
+		if pattern := s.screenshotPattern(name); pattern != "" {
+			return "Screenshots", fmt.Sprintf("name matches screenshot pattern %q -> Screenshots", pattern)
+		}
+		return matched("Images")
+	case "Applications", "Disk Images":
+		if (ext == ".pkg" || ext == ".dmg") && s.SplitInstallers {
+			return "Installers", fmt.Sprintf("matched extension %s with split installers -> Installers", ext)
+		}
+		return matched(category)
+	case "":
 		// Try to determine from name patterns
 		lowerName := strings.ToLower(name)
-		if strings.Contains(lowerName, "install") || strings.Contains(lowerName, "setup") {
-			return "Applications"
+		for _, word := range []string{"install", "setup"} {
+			if strings.Contains(lowerName, word) {
+				return "Applications", fmt.Sprintf("name contains '%s' -> Applications", word)
+			}
+		}
+		for _, word := range []string{"manual", "guide"} {
+			if strings.Contains(lowerName, word) {
+				return "Documents", fmt.Sprintf("name contains '%s' -> Documents", word)
+			}
+		}
+		return "Other", fmt.Sprintf("no rule for extension %s -> Other", ext)
+	default:
+		return matched(category)
+	}
+}
+
+// ExcludeOutputDirs keeps the folders elf-cli moves files into out of the
+// scan when they are inside root, so files it already moved aren't picked up
+// again. Folders outside root, and root itself, are left alone. It returns the
+// absolute paths of the folders it excluded.
+func (s *Scanner) ExcludeOutputDirs(root string, dirs ...string) []string {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil
+	}
+
+	var excluded []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(absRoot, absDir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !s.excludedDir(absDir) {
+			s.ExcludeDirs = append(s.ExcludeDirs, absDir)
+			excluded = append(excluded, absDir)
+		}
+	}
+	return excluded
+}
+
+// excludedDir reports whether a folder is one of the excluded folders
+func (s *Scanner) excludedDir(path string) bool {
+	if len(s.ExcludeDirs) == 0 {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, dir := range s.ExcludeDirs {
+		if absPath == filepath.Clean(dir) {
+			return true
 		}
-		if strings.Contains(lowerName, "manual") || strings.Contains(lowerName, "guide") {
-			return "Documents"
+	}
+	return false
+}
+
+// ignored reports whether a filename matches one of the ignore patterns
+func (s *Scanner) ignored(name string) bool {
+	return matchesAny(s.IgnorePatterns, name)
+}
+
+// selected applies the include and exclude patterns to a filename. A file
+// matching both is left out, unless IncludeWins is set. With IncludeWins the
+// include patterns only carve exceptions out of the excludes, so a file
+// matching neither is still scanned.
+func (s *Scanner) selected(name string) bool {
+	included := matchesAny(s.IncludePatterns, name)
+	if matchesAny(s.ExcludePatterns, name) {
+		return s.IncludeWins && included
+	}
+	return s.IncludeWins || len(s.IncludePatterns) == 0 || included
+}
+
+// matchesAny reports whether a filename matches one of the patterns, ignoring case
+func matchesAny(patterns []string, name string) bool {
+	lowerName := strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), lowerName); ok {
+			return true
 		}
-		return "Other"
 	}
+	return false
+}
+
+// screenshotPattern returns the screenshot pattern a filename matches, or "" if none do
+func (s *Scanner) screenshotPattern(name string) string {
+	lowerName := strings.ToLower(name)
+	for _, pattern := range s.ScreenshotPatterns {
+		if matched, _ := filepath.Match(strings.ToLower(pattern), lowerName); matched {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// hashAlgorithms are the checksums calculateFileHashes knows how to compute
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
 }
 
 // calculateFileHash calculates the MD5 hash of a file
 func (s *Scanner) calculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+	hashes, err := s.calculateFileHashes(filePath, []string{"md5"})
 	if err != nil {
 		return "", err
 	}
+	return hashes["md5"], nil
+}
+
+// calculateFileHashes computes several checksums of a file in a single
+// read, returning each one as hex keyed by algorithm name
+func (s *Scanner) calculateFileHashes(filePath string, algos []string) (map[string]string, error) {
+	hashers := make(map[string]hash.Hash, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		if _, ok := hashers[algo]; ok {
+			continue
+		}
+		newHash, ok := hashAlgorithms[algo]
+		if !ok {
+			return nil, fmt.Errorf("unknown hash algorithm %q", algo)
+		}
+		hashers[algo] = newHash()
+		writers = append(writers, hashers[algo])
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
 			fmt.Printf("⚠️  Warning: failed to close file %s: %v\n", filePath, closeErr)
 		}
 	}()
 
-	hash := md5.New()
+	// Huge files are usually read just once, so keep them out of the page cache
+	if s.NoCacheHashing {
+		if info, err := file.Stat(); err == nil && info.Size() >= noCacheThreshold {
+			adviseNoCache(file)
+			defer dropFromCache(file)
+		}
+	}
+
 	// Use a buffer to limit memory usage for large files
-	buf := make([]byte, 32*1024) // 32KB buffer
-	if _, err := io.CopyBuffer(hash, file, buf); err != nil {
-		return "", err
+	blockSize := s.HashBlockSize
+	if blockSize <= 0 {
+		blockSize = defaultHashBlockSize
+	}
+	buf := make([]byte, blockSize)
+	// Hide the file's WriterTo so the buffer size is actually used
+	if _, err := io.CopyBuffer(io.MultiWriter(writers...), struct{ io.Reader }{s.Deadline.Reader(s.Throttle.Reader(file))}, buf); err != nil {
+		return nil, err
 	}
 
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	hashes := make(map[string]string, len(hashers))
+	for algo, h := range hashers {
+		hashes[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
 }
 
 // findDuplicates finds duplicate files based on their hash
@@ -205,6 +729,7 @@ func (s *Scanner) findDuplicates() {
 
 	// Find duplicates (files with same hash)
 	for hash, files := range hashMap {
+		files = collapseHardLinks(files)
 		if len(files) > 1 {
 			s.Duplicates[hash] = files
 			// Mark files as duplicates
@@ -232,6 +757,144 @@ func (s *Scanner) findDuplicates() {
 	}
 }
 
+// collapseHardLinks keeps one path for each set of hard links in a group of
+// identical files. Hard links share their data, so removing one saves nothing.
+func collapseHardLinks(files []FileInfo) []FileInfo {
+	seen := make(map[string]bool)
+	var distinct []FileInfo
+	for _, file := range files {
+		if file.FileID != "" {
+			if seen[file.FileID] {
+				continue
+			}
+			seen[file.FileID] = true
+		}
+		distinct = append(distinct, file)
+	}
+	return distinct
+}
+
+// updatePath records that a file was renamed or moved from oldPath to newPath
+func (s *Scanner) updatePath(oldPath, newPath string) {
+	name := filepath.Base(newPath)
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		ext = "no_extension"
+	}
+	category, _ := s.determineCategory(ext, name)
+	for _, path := range []string{newPath, oldPath} {
+		if pinned, _, ok := s.categoryOverride(path); ok {
+			category = pinned
+			break
+		}
+	}
+
+	update := func(file *FileInfo) {
+		file.Path = newPath
+		file.Name = name
+		file.Extension = ext
+		file.Category = category
+		file.IsZip = ext == ".zip"
+	}
+
+	for i := range s.Files {
+		if s.Files[i].Path == oldPath {
+			update(&s.Files[i])
+			break
+		}
+	}
+
+	// Move the file to its new category if the name change affects it
+	for oldCategory, files := range s.Categories {
+		for i := range files {
+			if files[i].Path != oldPath {
+				continue
+			}
+			file := files[i]
+			update(&file)
+			if oldCategory == category {
+				files[i] = file
+			} else {
+				s.Categories[oldCategory] = append(files[:i:i], files[i+1:]...)
+				if len(s.Categories[oldCategory]) == 0 {
+					delete(s.Categories, oldCategory)
+				}
+				s.Categories[category] = append(s.Categories[category], file)
+			}
+			break
+		}
+	}
+
+	for _, files := range s.Duplicates {
+		for i := range files {
+			if files[i].Path == oldPath {
+				update(&files[i])
+			}
+		}
+	}
+}
+
+// forgetPath drops a file from the scanned files and categories, so later
+// steps leave alone a file that is about to be deleted
+func (s *Scanner) forgetPath(path string) {
+	for i := range s.Files {
+		if s.Files[i].Path == path {
+			s.Files = append(s.Files[:i:i], s.Files[i+1:]...)
+			break
+		}
+	}
+
+	for category, files := range s.Categories {
+		for i := range files {
+			if files[i].Path != path {
+				continue
+			}
+			s.Categories[category] = append(files[:i:i], files[i+1:]...)
+			if len(s.Categories[category]) == 0 {
+				delete(s.Categories, category)
+			}
+			break
+		}
+	}
+}
+
+// CategoryCount is the number and total size of files in a category
+type CategoryCount struct {
+	Category string
+	Files    int
+	Size     int64
+}
+
+// CategoryCounts tallies the scanned files per category, largest first
+func (s *Scanner) CategoryCounts() []CategoryCount {
+	counts := make([]CategoryCount, 0, len(s.Categories))
+	for category, files := range s.Categories {
+		count := CategoryCount{Category: category, Files: len(files)}
+		for _, file := range files {
+			count.Size += file.Size
+		}
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Size != counts[j].Size {
+			return counts[i].Size > counts[j].Size
+		}
+		return counts[i].Category < counts[j].Category
+	})
+	return counts
+}
+
+// PrintCategoryCounts prints the per-category tally and the overall total
+func (s *Scanner) PrintCategoryCounts() {
+	fmt.Println("\n📊 Files by category:")
+	var total int64
+	for _, count := range s.CategoryCounts() {
+		fmt.Printf("  %-14s %6d files %10.2f MB\n", count.Category, count.Files, float64(count.Size)/1024/1024)
+		total += count.Size
+	}
+	fmt.Printf("  %-14s %6d files %10.2f MB\n", "Total", len(s.Files), float64(total)/1024/1024)
+}
+
 // PrintSummary prints a summary of the scan results
 func (s *Scanner) PrintSummary() {
 	fmt.Println("\n📊 Scan Summary:")
@@ -251,4 +914,49 @@ func (s *Scanner) PrintSummary() {
 			}
 		}
 	}
+
+	if len(s.InProgress) > 0 {
+		fmt.Printf("\n⏳ Left %d unfinished downloads alone (use --include-in-progress to include them):\n", len(s.InProgress))
+		for _, path := range s.InProgress {
+			fmt.Printf("  - %s\n", filepath.Base(path))
+		}
+	}
+
+	if len(s.Protected) > 0 {
+		fmt.Printf("\n🛡️  Left %d protected files untouched (their hash is in --ignore-hashes):\n", len(s.Protected))
+		for _, file := range s.Protected {
+			fmt.Printf("  - %s\n", file.Path)
+		}
+	}
+
+	if s.Unchanged > 0 {
+		fmt.Printf("\n⏭️  Left out %d files not modified since %s\n", s.Unchanged, s.Since.Format("2006-01-02 15:04:05"))
+	}
+
+	if s.TrialEnded {
+		fmt.Printf("\n🧪 Trial mode: stopped after the first %d files, the rest of the folder wasn't looked at\n", s.TrialLimit)
+	}
+
+	if s.DeadlineReached {
+		warningColor.Printf("\n⏰ Reached --max-runtime of %s while scanning, the rest of the folder wasn't looked at\n", s.Deadline.Budget)
+	}
+
+	if len(s.TooDeep) > 0 || len(s.TooLong) > 0 {
+		fmt.Println("\n📏 Too deep / too long (skipped):")
+		for _, path := range s.TooDeep {
+			fmt.Printf("  - %s (nested more than %d folders deep)\n", path, s.MaxDepth)
+		}
+		for _, path := range s.TooLong {
+			fmt.Printf("  - %s (path longer than %d characters)\n", path, s.MaxPathLength)
+		}
+	}
+
+	if len(s.PartialDuplicates) > 0 {
+		fmt.Println("\n🧩 Partial duplicates (review these yourself, they are never removed):")
+		for _, partial := range s.PartialDuplicates {
+			fmt.Printf("  %.0f%% shared (%.2f MB):\n", partial.Fraction*100, float64(partial.SharedBytes)/1024/1024)
+			fmt.Printf("    - %s\n", partial.A.Path)
+			fmt.Printf("    - %s\n", partial.B.Path)
+		}
+	}
 }
\ No newline at end of file
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -96,7 +101,7 @@ func TestDetermineCategory(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := scanner.determineCategory(tt.ext, tt.name)
+			result, _ := scanner.determineCategory(tt.ext, tt.name)
 			if result != tt.expected {
 				t.Errorf("determineCategory(%s, %s) = %s, want %s", tt.ext, tt.name, result, tt.expected)
 			}
@@ -104,6 +109,153 @@ func TestDetermineCategory(t *testing.T) {
 	}
 }
 
+func TestDetermineCategoryModernFormats(t *testing.T) {
+	scanner := NewScanner()
+
+	tests := []struct {
+		ext      string
+		expected string
+	}{
+		{".avif", "Images"},
+		{".heic", "Images"},
+		{".heif", "Images"},
+		{".jxl", "Images"},
+		{".m4v", "Videos"},
+		{".3gp", "Videos"},
+		{".3g2", "Videos"},
+		{".hevc", "Videos"},
+		{".av1", "Videos"},
+		{".opus", "Music"},
+		{".m4a", "Music"},
+		{".aiff", "Music"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			name := "media" + tt.ext
+			if result, _ := scanner.determineCategory(tt.ext, name); result != tt.expected {
+				t.Errorf("determineCategory(%s, %s) = %s, want %s", tt.ext, name, result, tt.expected)
+			}
+			if result := dominantArchiveCategory([]string{name}); result != tt.expected {
+				t.Errorf("dominantArchiveCategory(%s) = %s, want %s", name, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetermineCategoryScreenshots(t *testing.T) {
+	scanner := NewScanner()
+	scanner.ScreenshotPatterns = defaultScreenshotPatterns
+
+	tests := []struct {
+		name     string
+		ext      string
+		expected string
+	}{
+		{"Screenshot 2024-05-01 at 10.15.32.png", ".png", "Screenshots"},
+		{"Screen Shot 2019-03-14 at 9.41.07 AM.png", ".png", "Screenshots"},
+		{"Screenshot (12).png", ".png", "Screenshots"},
+		{"Screenshot 2024-05-01 101532.jpg", ".jpg", "Screenshots"},
+		{"IMG_2041.jpg", ".jpg", "Images"},
+		{"holiday photo.png", ".png", "Images"},
+		{"Screenshot notes.pdf", ".pdf", "Documents"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := scanner.determineCategory(tt.ext, tt.name)
+			if result != tt.expected {
+				t.Errorf("determineCategory(%s, %s) = %s, want %s", tt.ext, tt.name, result, tt.expected)
+			}
+		})
+	}
+
+	// Screenshots stay in Images unless enabled
+	if result, _ := NewScanner().determineCategory(".png", "Screenshot (12).png"); result != "Images" {
+		t.Errorf("Expected Images without screenshot patterns, got %s", result)
+	}
+
+	// Custom patterns replace the defaults
+	scanner.ScreenshotPatterns = []string{"Bildschirmfoto*"}
+	if result, _ := scanner.determineCategory(".png", "Bildschirmfoto 2024-05-01.png"); result != "Screenshots" {
+		t.Errorf("Expected Screenshots for custom pattern, got %s", result)
+	}
+	if result, _ := scanner.determineCategory(".png", "Screenshot (12).png"); result != "Images" {
+		t.Errorf("Expected Images when default patterns are replaced, got %s", result)
+	}
+}
+
+func TestDetermineCategorySplitInstallers(t *testing.T) {
+	scanner := NewScanner()
+	scanner.SplitInstallers = true
+
+	tests := []struct {
+		name     string
+		ext      string
+		expected string
+	}{
+		{"Firefox.dmg", ".dmg", "Installers"},
+		{"Zoom.pkg", ".pkg", "Installers"},
+		{"ubuntu-24.04.iso", ".iso", "Disk Images"},
+		{"raspios.img", ".img", "Disk Images"},
+		{"app.exe", ".exe", "Applications"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := scanner.determineCategory(tt.ext, tt.name)
+			if result != tt.expected {
+				t.Errorf("determineCategory(%s, %s) = %s, want %s", tt.ext, tt.name, result, tt.expected)
+			}
+		})
+	}
+
+	// Without the split, installers keep their usual categories
+	scanner.SplitInstallers = false
+	if result, _ := scanner.determineCategory(".dmg", "Firefox.dmg"); result != "Disk Images" {
+		t.Errorf("Expected Disk Images for .dmg without split, got %s", result)
+	}
+	if result, _ := scanner.determineCategory(".pkg", "Zoom.pkg"); result != "Applications" {
+		t.Errorf("Expected Applications for .pkg without split, got %s", result)
+	}
+	if result, _ := scanner.determineCategory(".img", "raspios.img"); result != "Other" {
+		t.Errorf("Expected Other for .img without split, got %s", result)
+	}
+
+	// A config rule for .img wins over the split
+	scanner.SplitInstallers = true
+	scanner.ExtensionCategories = map[string]string{".img": "Images"}
+	if result, _ := scanner.determineCategory(".img", "photo.img"); result != "Images" {
+		t.Errorf("Expected the config rule to win for .img, got %s", result)
+	}
+}
+
+func TestDetermineCategoryReason(t *testing.T) {
+	scanner := NewScanner()
+	scanner.ScreenshotPatterns = defaultScreenshotPatterns
+
+	tests := []struct {
+		name     string
+		ext      string
+		expected string
+	}{
+		{"report.pdf", ".pdf", "matched extension .pdf -> Documents"},
+		{"setup_v2.bin", ".bin", "name contains 'setup' -> Applications"},
+		{"user-guide.xyz", ".xyz", "name contains 'guide' -> Documents"},
+		{"Screenshot 1.png", ".png", `name matches screenshot pattern "screenshot*" -> Screenshots`},
+		{"mystery.xyz", ".xyz", "no rule for extension .xyz -> Other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, reason := scanner.determineCategory(tt.ext, tt.name)
+			if reason != tt.expected {
+				t.Errorf("determineCategory(%s, %s) reason = %q, want %q", tt.ext, tt.name, reason, tt.expected)
+			}
+		})
+	}
+}
+
 func TestCalculateFileHash(t *testing.T) {
 	scanner := NewScanner()
 
@@ -138,6 +290,125 @@ func TestCalculateFileHash(t *testing.T) {
 	}
 }
 
+func TestCalculateFileHashBlockSizes(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "media.bin")
+
+	// Use a size that isn't a multiple of any block size
+	data := make([]byte, 3*1024*1024+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	expected, err := scanner.calculateFileHash(testFile)
+	if err != nil {
+		t.Fatalf("calculateFileHash() error = %v", err)
+	}
+
+	for _, blockSize := range []int{1024, 4 * 1024, 256 * 1024, 1024 * 1024, 8 * 1024 * 1024} {
+		scanner.HashBlockSize = blockSize
+		scanner.NoCacheHashing = true
+		hash, err := scanner.calculateFileHash(testFile)
+		if err != nil {
+			t.Fatalf("calculateFileHash() error with block size %d: %v", blockSize, err)
+		}
+		if hash != expected {
+			t.Errorf("Block size %d: hash %s, want %s", blockSize, hash, expected)
+		}
+	}
+}
+
+func TestCalculateFileHashesSinglePass(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "media.bin")
+	data := make([]byte, 1024*1024+77)
+	for i := range data {
+		data[i] = byte(i % 253)
+	}
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	algos := []string{"md5", "sha1", "sha256", "sha512", "crc32"}
+	combined, err := scanner.calculateFileHashes(testFile, algos)
+	if err != nil {
+		t.Fatalf("calculateFileHashes() error = %v", err)
+	}
+
+	for _, algo := range algos {
+		separate, err := scanner.calculateFileHashes(testFile, []string{algo})
+		if err != nil {
+			t.Fatalf("calculateFileHashes(%s) error = %v", algo, err)
+		}
+		if combined[algo] == "" || combined[algo] != separate[algo] {
+			t.Errorf("%s: single-pass hash %q, want %q", algo, combined[algo], separate[algo])
+		}
+	}
+
+	if md5Hash, _ := scanner.calculateFileHash(testFile); md5Hash != combined["md5"] {
+		t.Errorf("calculateFileHash() = %s, want %s", md5Hash, combined["md5"])
+	}
+
+	if _, err := scanner.calculateFileHashes(testFile, []string{"md4"}); err == nil {
+		t.Error("Expected an error for an unknown algorithm")
+	}
+}
+
+func TestScanDirectoryHashAlgorithms(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	scanner := NewScanner()
+	scanner.HashAlgorithms = []string{"sha256", "crc32", "sha256"}
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	file := scanner.Files[0]
+	if file.Hash == "" || file.Hashes["md5"] != file.Hash {
+		t.Errorf("Expected the MD5 hash in both Hash and Hashes, got %q and %q", file.Hash, file.Hashes["md5"])
+	}
+	if file.Hashes["sha256"] != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Unexpected sha256: %s", file.Hashes["sha256"])
+	}
+	if file.Hashes["crc32"] == "" {
+		t.Error("Expected a crc32 checksum")
+	}
+
+	if _, err := scanner.calculateFileHashes(file.Path, []string{"whirlpool"}); err == nil {
+		t.Error("Expected an error for an unknown algorithm")
+	}
+}
+
+func BenchmarkCalculateFileHash(b *testing.B) {
+	tmpDir := b.TempDir()
+	testFile := filepath.Join(tmpDir, "media.bin")
+	data := make([]byte, 64*1024*1024)
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		b.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, blockSize := range []int{32 * 1024, 256 * 1024, 1024 * 1024, 4 * 1024 * 1024} {
+		b.Run(fmt.Sprintf("%dKB", blockSize/1024), func(b *testing.B) {
+			scanner := NewScanner()
+			scanner.HashBlockSize = blockSize
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := scanner.calculateFileHash(testFile); err != nil {
+					b.Fatalf("calculateFileHash() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestFindDuplicates(t *testing.T) {
 	scanner := NewScanner()
 
@@ -179,6 +450,156 @@ func TestFindDuplicates(t *testing.T) {
 	}
 }
 
+func TestScanDirectorySkipHashing(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"report.pdf", "report (1).pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("same content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	for _, file := range scanner.Files {
+		if file.Hash != "" || file.IsDuplicate {
+			t.Errorf("Expected %s to be left unhashed, got hash %q duplicate %v", file.Name, file.Hash, file.IsDuplicate)
+		}
+	}
+	if len(scanner.Duplicates) != 0 {
+		t.Errorf("Expected no duplicates without hashing, got %d groups", len(scanner.Duplicates))
+	}
+
+	// Without duplicate detection, both copies are organized
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	for _, name := range []string{"report.pdf", "report (1).pdf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "Documents", name)); err != nil {
+			t.Errorf("Expected %s in Documents: %v", name, err)
+		}
+	}
+}
+
+func BenchmarkScanDirectory(b *testing.B) {
+	tmpDir := b.TempDir()
+	data := make([]byte, 1024*1024)
+	for i := 0; i < 32; i++ {
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%d.bin", i)), data, 0644); err != nil {
+			b.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	for _, skip := range []bool{false, true} {
+		b.Run(fmt.Sprintf("SkipHashing=%v", skip), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanner := NewScanner()
+				scanner.SkipHashing = skip
+				if err := scanner.ScanDirectory(tmpDir); err != nil {
+					b.Fatalf("ScanDirectory() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestIncludeExcludePrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"invoice-2024.pdf", "manual.pdf", "photo.jpg", "invoice.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		include     []string
+		exclude     []string
+		includeWins bool
+		expected    []string
+	}{
+		{"no patterns", nil, nil, false, []string{"invoice-2024.pdf", "invoice.txt", "manual.pdf", "photo.jpg"}},
+		{"include only", []string{"*.pdf"}, nil, false, []string{"invoice-2024.pdf", "manual.pdf"}},
+		{"exclude only", nil, []string{"*.PDF"}, false, []string{"invoice.txt", "photo.jpg"}},
+		{"exclude wins by default", []string{"invoice*"}, []string{"*.pdf"}, false, []string{"invoice.txt"}},
+		{"include wins when asked", []string{"invoice*"}, []string{"*.pdf"}, true, []string{"invoice-2024.pdf", "invoice.txt", "photo.jpg"}},
+		{"carve-out from a broad exclude", []string{"invoice*.pdf"}, []string{"*.pdf"}, true, []string{"invoice-2024.pdf", "invoice.txt", "photo.jpg"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner()
+			scanner.IncludePatterns = tt.include
+			scanner.ExcludePatterns = tt.exclude
+			scanner.IncludeWins = tt.includeWins
+			if err := scanner.ScanDirectory(tmpDir); err != nil {
+				t.Fatalf("ScanDirectory() error = %v", err)
+			}
+
+			var names []string
+			for _, file := range scanner.Files {
+				names = append(names, file.Name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Scanned %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCategoryCountsWithoutHashing(t *testing.T) {
+	// Count how many hashers get created
+	created := 0
+	original := hashAlgorithms["md5"]
+	hashAlgorithms["md5"] = func() hash.Hash {
+		created++
+		return original()
+	}
+	defer func() { hashAlgorithms["md5"] = original }()
+
+	tmpDir := t.TempDir()
+	files := map[string]int{"a.jpg": 100, "b.png": 200, "report.pdf": 1000, "song.mp3": 50}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), make([]byte, size), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	scanner.PrintCategoryCounts()
+
+	if created != 0 {
+		t.Errorf("Expected no hashing, but %d hashers were created", created)
+	}
+
+	expected := []CategoryCount{
+		{Category: "Documents", Files: 1, Size: 1000},
+		{Category: "Images", Files: 2, Size: 300},
+		{Category: "Music", Files: 1, Size: 50},
+	}
+	if counts := scanner.CategoryCounts(); !reflect.DeepEqual(counts, expected) {
+		t.Errorf("CategoryCounts() = %+v, want %+v", counts, expected)
+	}
+
+	// The stub is really used when hashing is on
+	scanner = NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if created != len(files) {
+		t.Errorf("Expected %d hashers with hashing on, got %d", len(files), created)
+	}
+}
+
 func TestCheckFilePermissions(t *testing.T) {
 	scanner := NewScanner()
 
@@ -202,4 +623,235 @@ func TestCheckFilePermissions(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for non-existent file")
 	}
-} 
\ No newline at end of file
+}
+
+func TestScanDirectorySkipsDeepFolders(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// a/b/c/d/e, with a file at every level
+	dir := tmpDir
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		dir = filepath.Join(dir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".txt"), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.MaxDepth = 3
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	if len(scanner.Files) != 3 {
+		t.Errorf("Expected the files in the top 3 folders, got %d files", len(scanner.Files))
+	}
+	want := filepath.Join(tmpDir, "a", "b", "c", "d")
+	if len(scanner.TooDeep) != 1 || scanner.TooDeep[0] != want {
+		t.Errorf("Expected only %s to be reported as too deep, got %v", want, scanner.TooDeep)
+	}
+}
+
+func TestScanDirectoryTrialLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.TrialLimit = 3
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 3 || len(scanner.Categories["Documents"]) != 3 {
+		t.Errorf("Expected only 3 files to be scanned, got %d", len(scanner.Files))
+	}
+	if !scanner.TrialEnded {
+		t.Error("Expected the scan to report that it stopped early")
+	}
+
+	// Organizing then only touches those files
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if organizer.TotalMoved != 3 {
+		t.Errorf("Expected 3 files to be moved, got %d", organizer.TotalMoved)
+	}
+	entries, _ := os.ReadDir(filepath.Join(tmpDir, "Documents"))
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 files in Documents, got %d", len(entries))
+	}
+
+	// A limit above the number of files scans them all
+	scanner = NewScanner()
+	scanner.TrialLimit = 100
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 10 || scanner.TrialEnded {
+		t.Errorf("Expected all 10 files without the trial ending, got %d (ended %v)", len(scanner.Files), scanner.TrialEnded)
+	}
+}
+
+func TestScanDirectorySkipsLongPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	short := filepath.Join(tmpDir, "short.txt")
+	long := filepath.Join(tmpDir, strings.Repeat("x", 60)+".txt")
+	for _, path := range []string{short, long} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Just long enough for short.txt
+	scanner := NewScanner()
+	scanner.MaxPathLength = len(short)
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	if len(scanner.Files) != 1 || scanner.Files[0].Path != short {
+		t.Errorf("Expected only short.txt to be scanned, got %v", scanner.Files)
+	}
+	if len(scanner.TooLong) != 1 || scanner.TooLong[0] != long {
+		t.Errorf("Expected %s to be reported as too long, got %v", long, scanner.TooLong)
+	}
+}
+
+func TestInProgressDownloadsLeftAlone(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"movie.mp4.crdownload", "album.zip.part", "linux.iso.!ut", "done.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 1 || scanner.Files[0].Name != "done.pdf" {
+		t.Errorf("Expected only the finished download to be scanned, got %v", scanner.Files)
+	}
+	if len(scanner.InProgress) != 3 {
+		t.Errorf("Expected 3 unfinished downloads to be reported, got %v", scanner.InProgress)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "movie.mp4.crdownload")); err != nil {
+		t.Errorf("Expected the .crdownload file to stay where it is: %v", err)
+	}
+
+	// The override brings them back into the scan
+	scanner = NewScanner()
+	scanner.IncludeInProgress = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	if len(scanner.Files) != 4 {
+		t.Errorf("Expected the unfinished downloads and Documents/done.pdf to be scanned with IncludeInProgress, got %d files", len(scanner.Files))
+	}
+}
+
+func TestCategoryFromParent(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := []string{
+		filepath.Join("TaxDocs", "return.pdf"),
+		filepath.Join("TaxDocs", "receipt.jpg"),
+		filepath.Join("TaxDocs", "2023", "w2.pdf"),
+		filepath.Join("images", "cat.mp4"),
+		"loose.pdf",
+	}
+	for _, name := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.CategoryFromParent = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"return.pdf":  "TaxDocs",
+		"receipt.jpg": "TaxDocs",
+		"w2.pdf":      "TaxDocs", // Subfolders share the top-level folder's category
+		"cat.mp4":     "Images", // A folder named like a built-in category gets that category
+		"loose.pdf":   "Documents",
+	}
+	for _, file := range scanner.Files {
+		if file.Category != expected[file.Name] {
+			t.Errorf("%s: category = %q, want %q", file.Name, file.Category, expected[file.Name])
+		}
+	}
+	if len(scanner.Categories["TaxDocs"]) != 3 {
+		t.Errorf("Expected 3 files in the TaxDocs category, got %d", len(scanner.Categories["TaxDocs"]))
+	}
+
+	// Organizing leaves the grouped files in their folder
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	for _, name := range []string{"return.pdf", "receipt.jpg", "w2.pdf"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, "TaxDocs", name)); err != nil {
+			t.Errorf("Expected %s to be in TaxDocs: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "images", "cat.mp4")); err != nil {
+		t.Errorf("Expected cat.mp4 to stay in images, the Images folder: %v", err)
+	}
+}
+
+func TestExcludeOutputDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	destDir := filepath.Join(tmpDir, "Sorted")
+	for _, name := range []string{"photo.jpg", "report.pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Elsewhere and the root itself are never excluded
+	scanner := NewScanner()
+	excluded := scanner.ExcludeOutputDirs(tmpDir, destDir, tmpDir, filepath.Join(filepath.Dir(tmpDir), "elsewhere"), "")
+	if len(excluded) != 1 || excluded[0] != destDir {
+		t.Fatalf("Expected only %s to be excluded, got %v", destDir, excluded)
+	}
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, destDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "Images", "photo.jpg")); err != nil {
+		t.Fatalf("Expected photo.jpg to be organized into the destination: %v", err)
+	}
+
+	// A second run doesn't pick up what the first one moved
+	scanner = NewScanner()
+	scanner.ExcludeOutputDirs(tmpDir, destDir)
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	for _, file := range scanner.Files {
+		if strings.HasPrefix(file.Path, destDir+string(filepath.Separator)) {
+			t.Errorf("Expected %s in the destination to be left out of the scan", file.Path)
+		}
+	}
+}
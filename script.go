@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// scriptForWindows reports whether a deletion script written to path should
+// be a batch file rather than a shell script: .bat and .cmd files are, .sh
+// files aren't, and anything else follows the system elf-cli runs on
+func scriptForWindows(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bat", ".cmd":
+		return true
+	case ".sh":
+		return false
+	}
+	return runtime.GOOS == "windows"
+}
+
+// EmitDeletionScript writes a script to path that deletes the duplicates the
+// handler would remove, keeping the copy resolver picks in each group (or the
+// handler's usual pick if it is nil), so they can be reviewed and deleted by hand. Nothing is deleted. It returns
+// how many delete commands were written.
+func (dh *DuplicateHandler) EmitDeletionScript(path string, resolver DuplicateResolver) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create script: %v", err)
+	}
+	count, err := dh.WriteDeletionScript(file, resolver, scriptForWindows(path))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot write script: %v", err)
+	}
+	return count, nil
+}
+
+// WriteDeletionScript writes the commands EmitDeletionScript saves, as a
+// shell script or, if windows is set, a batch file. Each group lists the copy
+// it keeps in a comment above the commands deleting the others.
+func (dh *DuplicateHandler) WriteDeletionScript(w io.Writer, resolver DuplicateResolver, windows bool) (int, error) {
+	type scriptGroup struct {
+		keep  FileInfo
+		files []FileInfo
+	}
+	var groups []scriptGroup
+	for _, files := range dh.duplicateGroups() {
+		if len(files) < 2 {
+			continue
+		}
+		keep := dh.keeper(files)
+		if resolver != nil {
+			keep = resolver.Keep(keepable(files))
+		}
+		var remove []FileInfo
+		for _, file := range files {
+			if file.Path != keep.Path {
+				remove = append(remove, file)
+			}
+		}
+		sort.Slice(remove, func(i, j int) bool { return remove[i].Path < remove[j].Path })
+		groups = append(groups, scriptGroup{keep: keep, files: remove})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].keep.Path < groups[j].keep.Path })
+
+	format := shellScript
+	if windows {
+		format = batchScript
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprint(out, format.header)
+	format.comment(out, "Duplicates found by elf-cli. Review before running: nothing has been deleted yet.")
+
+	count := 0
+	for _, group := range groups {
+		fmt.Fprint(out, format.newline)
+		format.comment(out, "Keep: "+group.keep.Path)
+		for _, file := range group.files {
+			fmt.Fprint(out, format.remove+format.quote(file.Path)+format.newline)
+			count++
+		}
+	}
+	return count, out.Flush()
+}
+
+// scriptFormat is how a deletion script is written for one kind of shell
+type scriptFormat struct {
+	header  string
+	newline string
+	remark  string              // Starts a comment line
+	remove  string              // Deletes the file that follows
+	quote   func(string) string // Quotes a path
+}
+
+var (
+	shellScript = scriptFormat{header: "#!/bin/sh\n", newline: "\n", remark: "# ", remove: "rm -f -- ", quote: shellQuote}
+	batchScript = scriptFormat{header: "@echo off\r\n", newline: "\r\n", remark: "REM ", remove: "del /f /q ", quote: batchQuote}
+)
+
+// comment writes a comment line. Line breaks in it, which file names can
+// contain, are replaced so the rest of the text can't become a command.
+func (f scriptFormat) comment(w io.Writer, text string) {
+	text = strings.NewReplacer("\r", "?", "\n", "?").Replace(text)
+	fmt.Fprint(w, f.remark+text+f.newline)
+}
+
+// shellQuote quotes a path for a POSIX shell
+func shellQuote(path string) string {
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
+
+// batchQuote quotes a path for a batch file, where % starts a variable
+func batchQuote(path string) string {
+	return `"` + strings.ReplaceAll(path, "%", "%%") + `"`
+}
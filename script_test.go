@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitDeletionScript(t *testing.T) {
+	tmpDir := t.TempDir()
+	older := time.Now().Add(-time.Hour)
+	files := map[string]string{
+		"report.pdf":         "report content",
+		"report (1).pdf":     "report content",
+		"it's a photo.jpg":   "photo content",
+		"it's a photo 2.jpg": "photo content",
+		"unique.txt":         "unique content",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if name == "report (1).pdf" || name == "it's a photo 2.jpg" {
+			os.Chtimes(path, older, older)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+
+	scriptPath := filepath.Join(t.TempDir(), "dupes.sh")
+	count, err := handler.EmitDeletionScript(scriptPath, nil)
+	if err != nil {
+		t.Fatalf("EmitDeletionScript() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 delete commands, got %d", count)
+	}
+	data, err := os.ReadFile(scriptPath)
+	if err != nil {
+		t.Fatalf("Failed to read script: %v", err)
+	}
+	script := string(data)
+
+	for _, line := range []string{
+		"rm -f -- '" + filepath.Join(tmpDir, "report (1).pdf") + "'\n",
+		"rm -f -- '" + filepath.Join(tmpDir, `it'\''s a photo 2.jpg`) + "'\n",
+		"# Keep: " + filepath.Join(tmpDir, "report.pdf") + "\n",
+	} {
+		if !strings.Contains(script, line) {
+			t.Errorf("Expected script to contain %q, got:\n%s", line, script)
+		}
+	}
+	for _, kept := range []string{"report.pdf'", "it'\\''s a photo.jpg'", "unique.txt"} {
+		if strings.Contains(script, "rm -f -- '"+filepath.Join(tmpDir, kept)) {
+			t.Errorf("Expected no delete command for %s, got:\n%s", kept, script)
+		}
+	}
+
+	// Nothing was deleted
+	for name := range files {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("Expected %s to be left in place: %v", name, err)
+		}
+	}
+
+	// Batch files use del, and double % so it isn't taken for a variable
+	var batch bytes.Buffer
+	scanner.Duplicates = map[string][]FileInfo{"abc": {
+		{Path: `C:\Downloads\100%.txt`, Name: "100%.txt", LastModified: older},
+		{Path: `C:\Downloads\keep.txt`, Name: "keep.txt", LastModified: time.Now()},
+	}}
+	if _, err := handler.WriteDeletionScript(&batch, nil, true); err != nil {
+		t.Fatalf("WriteDeletionScript() error = %v", err)
+	}
+	if !strings.Contains(batch.String(), "del /f /q \"C:\\Downloads\\100%%.txt\"\r\n") || strings.Contains(batch.String(), `del /f /q "C:\Downloads\keep.txt"`) {
+		t.Errorf("Unexpected batch file:\n%s", batch.String())
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// selfTestFile is a file in the self-test fixture, with the folder it should
+// end up in, or "" if it should be removed as a duplicate
+type selfTestFile struct {
+	Name    string
+	Content string
+	Folder  string
+}
+
+// selfTestFiles is the fixture RunSelfTest cleans up. The copy of the report
+// is older, so the original is the one kept.
+var selfTestFiles = []selfTestFile{
+	{"photo.jpg", "not really a photo", "Images"},
+	{"report.pdf", "quarterly numbers", "Documents"},
+	{"report (1).pdf", "quarterly numbers", ""},
+	{"notes.txt", "remember the milk", "Documents"},
+	{"song.mp3", "la la la", "Music"},
+	{"movie.mp4", "roll credits", "Videos"},
+}
+
+// RunSelfTest cleans up a known set of files in a temporary folder, first as
+// a dry run and then for real, and checks the outcome matches what it should
+// be. Nothing outside the temporary folder is touched.
+func RunSelfTest() []CheckResult {
+	var results []CheckResult
+
+	dir, err := os.MkdirTemp("", "elf-selftest-*")
+	if err != nil {
+		return append(results, CheckResult{"Create test folder", CheckFail, err.Error()})
+	}
+	defer os.RemoveAll(dir)
+	if err := writeSelfTestFixture(dir); err != nil {
+		return append(results, CheckResult{"Create test folder", CheckFail, err.Error()})
+	}
+	results = append(results, CheckResult{"Create test folder", CheckPass, fmt.Sprintf("%d files, one of them a duplicate", len(selfTestFiles))})
+
+	// A dry run must leave every file where it is
+	if err := selfTestClean(dir, true); err != nil {
+		return append(results, CheckResult{"Dry run", CheckFail, err.Error()})
+	}
+	var moved []string
+	for _, file := range selfTestFiles {
+		if _, err := os.Stat(filepath.Join(dir, file.Name)); err != nil {
+			moved = append(moved, file.Name)
+		}
+	}
+	if len(moved) > 0 {
+		results = append(results, CheckResult{"Dry run", CheckFail, "changed files: " + strings.Join(moved, ", ")})
+	} else {
+		results = append(results, CheckResult{"Dry run", CheckPass, "no files were changed"})
+	}
+
+	// A real run removes the duplicate and sorts the rest into their folders
+	if err := selfTestClean(dir, false); err != nil {
+		return append(results, CheckResult{"Remove duplicates and organize", CheckFail, err.Error()})
+	}
+	var problems []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Name() == "report (1).pdf" {
+			problems = append(problems, "report (1).pdf was not removed")
+		}
+		return nil
+	})
+	results = append(results, selfTestResult("Remove duplicates", problems, "the duplicate was removed and the original kept"))
+
+	problems = nil
+	for _, file := range selfTestFiles {
+		if file.Folder == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, file.Folder, file.Name))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is not in %s", file.Name, file.Folder))
+		} else if string(content) != file.Content {
+			problems = append(problems, fmt.Sprintf("%s was changed", file.Name))
+		}
+	}
+	results = append(results, selfTestResult("Organize", problems, "every file is in its folder with its content intact"))
+
+	return results
+}
+
+// writeSelfTestFixture writes the self-test files into dir
+func writeSelfTestFixture(dir string) error {
+	older := time.Now().Add(-time.Hour)
+	for _, file := range selfTestFiles {
+		path := filepath.Join(dir, file.Name)
+		if err := os.WriteFile(path, []byte(file.Content), 0644); err != nil {
+			return err
+		}
+		if file.Folder == "" {
+			if err := os.Chtimes(path, older, older); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selfTestClean scans dir, removes duplicates and organizes it, the way the
+// clean command does with --remove-duplicates --organize
+func selfTestClean(dir string, dryRun bool) error {
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(dir); err != nil {
+		return err
+	}
+	if len(scanner.Files) != len(selfTestFiles) || len(scanner.Duplicates) != 1 {
+		return fmt.Errorf("scan found %d files and %d duplicate groups, expected %d files and 1 group", len(scanner.Files), len(scanner.Duplicates), len(selfTestFiles))
+	}
+	if err := NewDuplicateHandler(scanner, dryRun).RemoveDuplicates(); err != nil {
+		return err
+	}
+	return NewFileOrganizer(scanner, dryRun, dir).OrganizeFiles()
+}
+
+// selfTestResult turns the problems a check found into its result
+func selfTestResult(name string, problems []string, success string) CheckResult {
+	if len(problems) > 0 {
+		return CheckResult{name, CheckFail, strings.Join(problems, "; ")}
+	}
+	return CheckResult{name, CheckPass, success}
+}
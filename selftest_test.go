@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestRunSelfTest(t *testing.T) {
+	results := RunSelfTest()
+	if len(results) != 4 {
+		t.Errorf("Expected 4 checks, got %+v", results)
+	}
+	for _, result := range results {
+		if result.Status != CheckPass {
+			t.Errorf("Check %s failed on a healthy build: %s", result.Name, result.Message)
+		}
+	}
+	if err := printCheckResults(results); err != nil {
+		t.Errorf("printCheckResults() error = %v", err)
+	}
+}
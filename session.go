@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	defaultSessionGap  = 10 * time.Minute // Longest pause between two downloads of the same session
+	miscSessionFolder  = "Misc"           // Where files downloaded on their own go, if they are moved at all
+	sessionFolderStamp = "2006-01-02 15.04"
+)
+
+// downloadSessions splits files into runs whose modification times are no more
+// than gap apart, in time order
+func downloadSessions(files []FileInfo, gap time.Duration) [][]FileInfo {
+	sorted := append([]FileInfo(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastModified.Before(sorted[j].LastModified)
+	})
+
+	var sessions [][]FileInfo
+	for i, file := range sorted {
+		if i == 0 || file.LastModified.Sub(sorted[i-1].LastModified) > gap {
+			sessions = append(sessions, nil)
+		}
+		sessions[len(sessions)-1] = append(sessions[len(sessions)-1], file)
+	}
+	return sessions
+}
+
+// sessionFolder names the folder for the nth session, starting at 1. The start
+// time is part of the name so later runs don't mix new sessions into old folders.
+func sessionFolder(n int, start time.Time) string {
+	return fmt.Sprintf("Session %d (%s)", n, start.Format(sessionFolderStamp))
+}
+
+// OrganizeBySession groups files downloaded close together in time into
+// numbered session folders. A file with no other download within gap of it is
+// left where it is, or moved to Misc if groupSingletons is set.
+func (fo *FileOrganizer) OrganizeBySession(gap time.Duration, groupSingletons bool) error {
+	if gap <= 0 {
+		gap = defaultSessionGap
+	}
+
+	fmt.Println("🕒 Starting session-based organization...")
+	fmt.Println()
+
+	var files []FileInfo
+	for _, file := range fo.Scanner.Files {
+		if file.IsDuplicate || !fo.categoryEnabled(file.Category) {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	sessionGroups := make(map[string][]FileInfo)
+	n := 0
+	for _, session := range downloadSessions(files, gap) {
+		if len(session) == 1 {
+			if groupSingletons {
+				sessionGroups[miscSessionFolder] = append(sessionGroups[miscSessionFolder], session[0])
+			} else if fo.Scanner.Verbose {
+				fmt.Printf("   🔎 %s: downloaded on its own, leaving it in place\n", session[0].Name)
+			}
+			continue
+		}
+		n++
+		sessionGroups[sessionFolder(n, session[0].LastModified)] = session
+	}
+
+	return fo.moveGroups(sessionGroups, "🕒", "session")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOrganizeBySession(t *testing.T) {
+	tmpDir := t.TempDir()
+	morning := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	evening := time.Date(2024, 3, 1, 18, 30, 0, 0, time.Local)
+	files := map[string]time.Time{
+		"slides.pdf":  morning,
+		"notes.txt":   morning.Add(4 * time.Minute),
+		"diagram.png": morning.Add(12 * time.Minute), // Within the gap of notes.txt
+		"song.mp3":    evening,
+		"cover.jpg":   evening.Add(2 * time.Minute),
+		"lonely.zip":  morning.Add(3 * time.Hour),
+	}
+	for name, modTime := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set time on %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeBySession(10*time.Minute, false); err != nil {
+		t.Fatalf("OrganizeBySession() error = %v", err)
+	}
+
+	first := sessionFolder(1, morning)
+	second := sessionFolder(2, evening)
+	expected := map[string]string{
+		"slides.pdf":  first,
+		"notes.txt":   first,
+		"diagram.png": first,
+		"song.mp3":    second,
+		"cover.jpg":   second,
+		"lonely.zip":  "",
+	}
+	for name, folder := range expected {
+		if _, err := os.Stat(filepath.Join(tmpDir, folder, name)); err != nil {
+			t.Errorf("Expected %s in %q: %v", name, folder, err)
+		}
+	}
+}
+
+func TestOrganizeBySessionMisc(t *testing.T) {
+	tmpDir := t.TempDir()
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	for i, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+		modTime := start.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set time on %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeBySession(10*time.Minute, true); err != nil {
+		t.Fatalf("OrganizeBySession() error = %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, miscSessionFolder, name)); err != nil {
+			t.Errorf("Expected %s in %s: %v", name, miscSessionFolder, err)
+		}
+	}
+}
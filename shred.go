@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// defaultShredPasses is how many times --shred overwrites a file by default
+const defaultShredPasses = 3
+
+// shredFile overwrites a file's contents and then removes it. It refuses to
+// run where overwriting in place wouldn't actually destroy the old data.
+// A symlink is only removed, as overwriting it would destroy its target.
+func shredFile(path string, passes int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(path)
+	}
+	// Other hard links share the data, so overwriting would destroy them too
+	if linkCount(info) > 1 {
+		return fmt.Errorf("refusing to shred: the file has %d hard links sharing its data", linkCount(info))
+	}
+	if reason := shredIneffective(path); reason != "" {
+		return fmt.Errorf("refusing to shred: %s", reason)
+	}
+	if err := overwriteFile(path, passes); err != nil {
+		return fmt.Errorf("failed to overwrite: %v", err)
+	}
+	return os.Remove(path)
+}
+
+// overwriteFile replaces every byte of a file with random data, once per pass,
+// syncing after each pass so the writes reach the disk
+func overwriteFile(path string, passes int) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|openNoFollow, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	for pass := 0; pass < passes; pass++ {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(file, rand.Reader, info.Size()); err != nil {
+			return err
+		}
+		if err := file.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// shredIneffective explains why overwriting a file in place wouldn't destroy
+// its old contents, or returns "" if shredding should work
+func shredIneffective(path string) string {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(path, &fs); err != nil {
+		return ""
+	}
+	if unix.ByteSliceToString(fs.Fstypename[:]) == "apfs" {
+		return "APFS is a copy-on-write filesystem, so old data is not overwritten"
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Filesystems that write changes to new blocks, leaving the old data behind
+var copyOnWriteFilesystems = map[int64]string{
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0xCA451A4E: "bcachefs",
+}
+
+// shredIneffective explains why overwriting a file in place wouldn't destroy
+// its old contents, or returns "" if shredding should work
+func shredIneffective(path string) string {
+	var fs unix.Statfs_t
+	if err := unix.Statfs(path, &fs); err == nil {
+		if name, ok := copyOnWriteFilesystems[int64(fs.Type)]; ok {
+			return fmt.Sprintf("%s is a copy-on-write filesystem, so old data is not overwritten", name)
+		}
+	}
+
+	// SSDs remap writes to fresh cells, so check whether the disk is rotational
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return ""
+	}
+	device := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev)))
+	for _, queue := range []string{device + "/queue/rotational", device + "/../queue/rotational"} {
+		data, err := os.ReadFile(queue)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "0" {
+			return "the file is on an SSD, which remaps writes so old data is not overwritten"
+		}
+		return ""
+	}
+	return ""
+}
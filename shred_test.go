@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverwriteFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	secret := filepath.Join(tmpDir, "passwords.txt")
+	original := bytes.Repeat([]byte("hunter2 "), 512)
+	if err := os.WriteFile(secret, original, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := overwriteFile(secret, 2); err != nil {
+		t.Fatalf("overwriteFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(secret)
+	if err != nil {
+		t.Fatalf("Failed to read overwritten file: %v", err)
+	}
+	if len(data) != len(original) {
+		t.Errorf("Expected size %d after overwrite, got %d", len(original), len(data))
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Error("Expected original content to be overwritten")
+	}
+}
+
+func TestRemoveDuplicatesShred(t *testing.T) {
+	tmpDir := t.TempDir()
+	if reason := shredIneffective(tmpDir); reason != "" {
+		t.Skipf("Shredding not supported here: %s", reason)
+	}
+
+	content := []byte("sensitive duplicate content")
+	for _, name := range []string{"statement.pdf", "statement (1).pdf"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	handler := NewDuplicateHandler(scanner, false)
+	handler.ShredPasses = 1
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 file left after shredding duplicates, got %d", len(entries))
+	}
+	if handler.TotalRemoved != 1 {
+		t.Errorf("Expected 1 duplicate removed, got %d", handler.TotalRemoved)
+	}
+}
+
+func TestShredFileLinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := []byte("the copy being kept")
+	real := filepath.Join(tmpDir, "real.bin")
+	if err := os.WriteFile(real, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	link := filepath.Join(tmpDir, "link.bin")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	// Shredding a symlink removes the link and leaves its target alone
+	if err := shredFile(link, 1); err != nil {
+		t.Fatalf("shredFile() error = %v", err)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("Expected the symlink to be removed")
+	}
+	if data, err := os.ReadFile(real); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("Expected the link target to be untouched, got %q (%v)", data, err)
+	}
+
+	// A file with another hard link isn't overwritten
+	hardLink := filepath.Join(tmpDir, "hard.bin")
+	if err := os.Link(real, hardLink); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+	if linkCount(mustLstat(t, real)) < 2 {
+		t.Skip("Hard link counts not available on this platform")
+	}
+	if err := shredFile(hardLink, 1); err == nil {
+		t.Error("Expected shredding a hard-linked file to be refused")
+	}
+	if data, err := os.ReadFile(real); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("Expected the hard-linked data to be untouched, got %q (%v)", data, err)
+	}
+}
+
+func TestRemoveDuplicatesShredSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := []byte("the only real copy")
+	real := filepath.Join(tmpDir, "real.bin")
+	if err := os.WriteFile(real, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	link := filepath.Join(tmpDir, "link.bin")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	handler.ShredPasses = 1
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Error("Expected the symlinked duplicate to be removed")
+	}
+	if data, err := os.ReadFile(real); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("Expected the kept file to be untouched, got %q (%v)", data, err)
+	}
+}
+
+// mustLstat returns the Lstat info of a path, failing the test if it can't
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("Lstat(%s) error = %v", path, err)
+	}
+	return info
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// LifetimeStats holds usage totals accumulated across runs.
+// They are only ever written to a local file and never leave the machine.
+type LifetimeStats struct {
+	Runs              int       `json:"runs"`
+	FilesOrganized    int       `json:"files_organized"`
+	DuplicatesRemoved int       `json:"duplicates_removed"`
+	BytesReclaimed    int64     `json:"bytes_reclaimed"`
+	FirstRun          time.Time `json:"first_run"`
+	LastRun           time.Time `json:"last_run"`
+
+	// The latest snapshot of each folder, by absolute path, for --compare-last
+	Snapshots map[string]CategorySnapshot `json:"snapshots,omitempty"`
+}
+
+// RunStats holds the totals for a single run
+type RunStats struct {
+	FilesOrganized    int
+	DuplicatesRemoved int
+	BytesReclaimed    int64
+}
+
+// duplicatesCategory is the snapshot entry counting redundant copies of files
+const duplicatesCategory = "Duplicates"
+
+// CategoryTotals is the number and size of files in a category
+type CategoryTotals struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// CategorySnapshot records how many files of each category a folder held
+// when it was scanned
+type CategorySnapshot struct {
+	TakenAt    time.Time                 `json:"taken_at"`
+	Categories map[string]CategoryTotals `json:"categories"`
+}
+
+// CategoryChange is how much a category grew or shrank between two snapshots
+type CategoryChange struct {
+	Category string
+	Files    int
+	Bytes    int64
+}
+
+// getConfigDir returns the directory where elf-cli keeps its local files
+func getConfigDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "elf-cli"), nil
+}
+
+// getStatsPath returns the path of the local stats file
+func getStatsPath() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "stats.json"), nil
+}
+
+// LoadStats reads lifetime stats from a file, returning empty stats if it doesn't exist yet
+func LoadStats(path string) (*LifetimeStats, error) {
+	stats := &LifetimeStats{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read stats file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("cannot parse stats file: %v", err)
+	}
+	return stats, nil
+}
+
+// Save writes lifetime stats to a file, creating its directory if needed
+func (ls *LifetimeStats) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create stats directory: %v", err)
+	}
+
+	if err := writeJSONAtomic(path, ls); err != nil {
+		return fmt.Errorf("cannot write stats file: %v", err)
+	}
+	return nil
+}
+
+// writeJSONAtomic writes v to path as indented JSON. It goes through a
+// temporary file that is synced before it replaces path, so a crash leaves
+// either the old file or the new one, never a half-written one.
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// Add adds the totals of a single run to the lifetime stats
+func (ls *LifetimeStats) Add(run RunStats, when time.Time) {
+	if ls.Runs == 0 {
+		ls.FirstRun = when
+	}
+	ls.Runs++
+	ls.FilesOrganized += run.FilesOrganized
+	ls.DuplicatesRemoved += run.DuplicatesRemoved
+	ls.BytesReclaimed += run.BytesReclaimed
+	ls.LastRun = when
+}
+
+// RecordRun adds the totals of a single run to the stats file at path
+func RecordRun(path string, run RunStats) error {
+	stats, err := LoadStats(path)
+	if err != nil {
+		return err
+	}
+	stats.Add(run, time.Now())
+	return stats.Save(path)
+}
+
+// Print prints the lifetime stats
+func (ls *LifetimeStats) Print() {
+	fmt.Println("📊 Lifetime Stats:")
+	if ls.Runs == 0 {
+		fmt.Println("No runs recorded yet. Use --record-stats with the clean command to start counting.")
+		return
+	}
+	fmt.Printf("Runs: %d\n", ls.Runs)
+	fmt.Printf("Files organized: %d\n", ls.FilesOrganized)
+	fmt.Printf("Duplicates removed: %d\n", ls.DuplicatesRemoved)
+	fmt.Printf("Space reclaimed: %.2f MB\n", float64(ls.BytesReclaimed)/1024/1024)
+	fmt.Printf("First run: %s\n", ls.FirstRun.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Last run: %s\n", ls.LastRun.Format("2006-01-02 15:04:05"))
+}
+
+// TakeSnapshot counts the files of each category the scanner found. Unless
+// hashing was skipped, it also counts the copies of files beyond the first as
+// Duplicates.
+func TakeSnapshot(scanner *Scanner, when time.Time) CategorySnapshot {
+	snapshot := CategorySnapshot{TakenAt: when, Categories: make(map[string]CategoryTotals)}
+	for category, files := range scanner.Categories {
+		if len(files) == 0 {
+			continue
+		}
+		totals := CategoryTotals{Files: len(files)}
+		for _, file := range files {
+			totals.Bytes += file.Size
+		}
+		snapshot.Categories[category] = totals
+	}
+
+	if !scanner.SkipHashing {
+		var duplicates CategoryTotals
+		for _, files := range scanner.Duplicates {
+			for _, file := range files[1:] {
+				duplicates.Files++
+				duplicates.Bytes += file.Size
+			}
+		}
+		snapshot.Categories[duplicatesCategory] = duplicates
+	}
+	return snapshot
+}
+
+// CompareSnapshots lists the categories whose file count or size changed
+// from previous to current, sorted by name. Duplicates are only compared
+// when both scans hashed files.
+func CompareSnapshots(previous, current CategorySnapshot) []CategoryChange {
+	names := make(map[string]bool)
+	for category := range previous.Categories {
+		names[category] = true
+	}
+	for category := range current.Categories {
+		names[category] = true
+	}
+
+	var changes []CategoryChange
+	for category := range names {
+		before, hadBefore := previous.Categories[category]
+		after, hasAfter := current.Categories[category]
+		if category == duplicatesCategory && (!hadBefore || !hasAfter) {
+			continue
+		}
+		change := CategoryChange{Category: category, Files: after.Files - before.Files, Bytes: after.Bytes - before.Bytes}
+		if change.Files != 0 || change.Bytes != 0 {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Category < changes[j].Category })
+	return changes
+}
+
+// LastSnapshot returns the snapshot of folder saved by the last run, if any
+func (ls *LifetimeStats) LastSnapshot(folder string) (CategorySnapshot, bool) {
+	snapshot, ok := ls.Snapshots[highWaterKey(folder)]
+	return snapshot, ok
+}
+
+// RecordSnapshot saves snapshot as the latest one of folder in the stats file at path
+func RecordSnapshot(path, folder string, snapshot CategorySnapshot) error {
+	stats, err := LoadStats(path)
+	if err != nil {
+		return err
+	}
+	if stats.Snapshots == nil {
+		stats.Snapshots = make(map[string]CategorySnapshot)
+	}
+	stats.Snapshots[highWaterKey(folder)] = snapshot
+	return stats.Save(path)
+}
+
+// PrintCategoryChanges prints how a folder changed since the snapshot taken at since
+func PrintCategoryChanges(changes []CategoryChange, since time.Time) {
+	if len(changes) == 0 {
+		fmt.Printf("\n📈 Nothing changed since the last run (%s)\n", since.Format("2006-01-02 15:04"))
+		return
+	}
+	fmt.Printf("\n📈 Changes since the last run (%s):\n", since.Format("2006-01-02 15:04"))
+	for _, change := range changes {
+		fmt.Printf("  %s %+d files (%+.2f MB)\n", change.Category, change.Files, float64(change.Bytes)/1024/1024)
+	}
+}
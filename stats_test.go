@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	statsPath := filepath.Join(tmpDir, "elf-cli", "stats.json")
+
+	// Loading before any run should give empty stats
+	stats, err := LoadStats(statsPath)
+	if err != nil {
+		t.Fatalf("LoadStats() error = %v", err)
+	}
+	if stats.Runs != 0 {
+		t.Errorf("Expected 0 runs, got %d", stats.Runs)
+	}
+
+	// Simulate a run that removes duplicates and organizes files
+	srcDir := filepath.Join(tmpDir, "downloads")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "photo.jpg"} {
+		content := "duplicate content"
+		if name == "photo.jpg" {
+			content = "photo"
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(srcDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	handler := NewDuplicateHandler(scanner, false)
+	if err := handler.RemoveDuplicates(); err != nil {
+		t.Fatalf("RemoveDuplicates() error = %v", err)
+	}
+	organizer := NewFileOrganizer(scanner, false, srcDir)
+	if err := organizer.OrganizeFiles(); err != nil {
+		t.Fatalf("OrganizeFiles() error = %v", err)
+	}
+
+	run := RunStats{
+		FilesOrganized:    organizer.TotalMoved,
+		DuplicatesRemoved: handler.TotalRemoved,
+		BytesReclaimed:    handler.TotalSpaceSaved,
+	}
+	if run.DuplicatesRemoved != 1 || run.FilesOrganized == 0 {
+		t.Fatalf("Unexpected run totals: %+v", run)
+	}
+
+	// Record the same run twice and check the counters add up
+	for i := 0; i < 2; i++ {
+		if err := RecordRun(statsPath, run); err != nil {
+			t.Fatalf("RecordRun() error = %v", err)
+		}
+	}
+
+	stats, err = LoadStats(statsPath)
+	if err != nil {
+		t.Fatalf("LoadStats() error = %v", err)
+	}
+	if stats.Runs != 2 {
+		t.Errorf("Expected 2 runs, got %d", stats.Runs)
+	}
+	if stats.FilesOrganized != 2*run.FilesOrganized {
+		t.Errorf("Expected %d files organized, got %d", 2*run.FilesOrganized, stats.FilesOrganized)
+	}
+	if stats.DuplicatesRemoved != 2 {
+		t.Errorf("Expected 2 duplicates removed, got %d", stats.DuplicatesRemoved)
+	}
+	if stats.BytesReclaimed != 2*int64(len("duplicate content")) {
+		t.Errorf("Expected %d bytes reclaimed, got %d", 2*len("duplicate content"), stats.BytesReclaimed)
+	}
+	if stats.LastRun.Before(stats.FirstRun) {
+		t.Error("LastRun is before FirstRun")
+	}
+}
+
+func TestCompareLastRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	statsPath := filepath.Join(tmpDir, "elf-cli", "stats.json")
+	srcDir := filepath.Join(tmpDir, "downloads")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	writeFiles := func(files map[string]string) {
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create test file %s: %v", name, err)
+			}
+		}
+	}
+	snapshot := func() CategorySnapshot {
+		scanner := NewScanner()
+		if err := scanner.ScanDirectory(srcDir); err != nil {
+			t.Fatalf("ScanDirectory() error = %v", err)
+		}
+		return TakeSnapshot(scanner, time.Now())
+	}
+
+	// First run: two copies of a report and a photo
+	writeFiles(map[string]string{"report.pdf": "numbers", "report (1).pdf": "numbers", "photo.jpg": "photo"})
+	if err := RecordSnapshot(statsPath, srcDir, snapshot()); err != nil {
+		t.Fatalf("RecordSnapshot() error = %v", err)
+	}
+
+	// Second run: the extra report is gone and two more photos arrived
+	if err := os.Remove(filepath.Join(srcDir, "report (1).pdf")); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	writeFiles(map[string]string{"beach.jpg": "sand", "hills.jpg": "grass"})
+	current := snapshot()
+
+	stats, err := LoadStats(statsPath)
+	if err != nil {
+		t.Fatalf("LoadStats() error = %v", err)
+	}
+	previous, ok := stats.LastSnapshot(srcDir)
+	if !ok {
+		t.Fatal("Expected a snapshot from the first run")
+	}
+
+	expected := []CategoryChange{
+		{Category: "Documents", Files: -1, Bytes: -int64(len("numbers"))},
+		{Category: "Duplicates", Files: -1, Bytes: -int64(len("numbers"))},
+		{Category: "Images", Files: 2, Bytes: int64(len("sand") + len("grass"))},
+	}
+	if changes := CompareSnapshots(previous, current); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+
+	// Nothing changes between identical scans
+	if changes := CompareSnapshots(current, current); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	finderTagsAttr  = "com.apple.metadata:_kMDItemUserTags"      // Extended attribute holding Finder tags
+	whereFromsAttr  = "com.apple.metadata:kMDItemWhereFroms"     // Extended attribute holding download source URLs
+	downloadedAttr  = "com.apple.metadata:kMDItemDownloadedDate" // Extended attribute holding when a file was downloaded
+	untaggedFolder  = "Untagged"                                 // Folder for files without Finder tags
+	unknownSource   = "UnknownSource"                            // Folder for files without a download source
+	bplistHeader    = "bplist00"
+	bplistTrailerSz = 32
+	plistEpoch      = 978307200 // Unix time of 2001-01-01, which plist dates count from
+	plistMaxSeconds = 1e12      // Dates further than this from 2001 aren't real download dates
+)
+
+// readFinderTags returns the Finder tags set on a file
+func readFinderTags(path string) ([]string, error) {
+	data, err := readXattr(path, finderTagsAttr)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return parseFinderTags(data)
+}
+
+// readWhereFroms returns the URLs macOS recorded a file as being downloaded from
+func readWhereFroms(path string) ([]string, error) {
+	data, err := readXattr(path, whereFromsAttr)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return parseBinaryPlistStrings(data)
+}
+
+// sourceDomainFolder returns the folder name for a file's download source URLs
+func sourceDomainFolder(urls []string) string {
+	for _, rawURL := range urls {
+		parsed, err := url.Parse(rawURL)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(parsed.Hostname())
+		return strings.TrimPrefix(host, "www.")
+	}
+	return unknownSource
+}
+
+// primaryTagFolder returns the folder name for a file's Finder tags
+func primaryTagFolder(tags []string) string {
+	if len(tags) == 0 {
+		return untaggedFolder
+	}
+
+	// Tag names can contain characters that aren't allowed in folder names
+	folder := strings.TrimSpace(tags[0])
+	folder = strings.ReplaceAll(folder, "/", "-")
+	folder = strings.ReplaceAll(folder, ":", "-")
+	if folder == "" || folder == "." || folder == ".." {
+		return untaggedFolder
+	}
+	return folder
+}
+
+// parseFinderTags decodes the value of the Finder tags extended attribute.
+// The value is a binary plist holding an array of strings like "Work\n6",
+// where the part after the newline is the tag's color.
+func parseFinderTags(data []byte) ([]string, error) {
+	values, err := parseBinaryPlistStrings(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		if i := strings.IndexByte(value, '\n'); i >= 0 {
+			value = value[:i]
+		}
+		if value != "" {
+			tags = append(tags, value)
+		}
+	}
+	return tags, nil
+}
+
+// parseBinaryPlistStrings decodes a binary plist whose top object is an array of strings.
+// It only supports what Finder writes for tags, not the full plist format.
+func parseBinaryPlistStrings(data []byte) ([]string, error) {
+	positions, err := binaryPlistArray(data)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		value, err := readPlistString(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// parseBinaryPlistDates decodes a binary plist whose top object is an array
+// of dates, as macOS writes for the date a file was downloaded
+func parseBinaryPlistDates(data []byte) ([]time.Time, error) {
+	positions, err := binaryPlistArray(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]time.Time, 0, len(positions))
+	for _, pos := range positions {
+		date, err := readPlistDate(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// binaryPlistArray returns the position of each object in a binary plist whose top object is an array
+func binaryPlistArray(data []byte) ([]int, error) {
+	if len(data) < len(bplistHeader)+bplistTrailerSz || !bytes.HasPrefix(data, []byte(bplistHeader)) {
+		return nil, fmt.Errorf("not a binary plist")
+	}
+
+	trailer := data[len(data)-bplistTrailerSz:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	offsetTable := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 {
+		return nil, fmt.Errorf("invalid binary plist trailer")
+	}
+	if numObjects == 0 || topObject >= numObjects || offsetTable >= uint64(len(data)) ||
+		numObjects > (uint64(len(data))-offsetTable)/uint64(offsetSize) {
+		return nil, fmt.Errorf("invalid binary plist trailer")
+	}
+
+	objectOffset := func(ref uint64) (int, error) {
+		if ref >= numObjects {
+			return 0, fmt.Errorf("object reference out of range")
+		}
+		start := offsetTable + ref*uint64(offsetSize)
+		offset := readBigEndian(data[start : start+uint64(offsetSize)])
+		if offset >= offsetTable {
+			return 0, fmt.Errorf("object offset out of range")
+		}
+		return int(offset), nil
+	}
+
+	pos, err := objectOffset(topObject)
+	if err != nil {
+		return nil, err
+	}
+	if data[pos]>>4 != 0xA {
+		return nil, fmt.Errorf("top object is not an array")
+	}
+	count, pos, err := readPlistLength(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	if pos+count*refSize > len(data) {
+		return nil, fmt.Errorf("array out of range")
+	}
+
+	positions := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		ref := readBigEndian(data[pos+i*refSize : pos+(i+1)*refSize])
+		objPos, err := objectOffset(ref)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, objPos)
+	}
+	return positions, nil
+}
+
+// readPlistString decodes an ASCII or UTF-16 string object at pos
+func readPlistString(data []byte, pos int) (string, error) {
+	marker := data[pos] >> 4
+	length, pos, err := readPlistLength(data, pos)
+	if err != nil {
+		return "", err
+	}
+
+	switch marker {
+	case 0x5: // ASCII
+		if pos+length > len(data) {
+			return "", fmt.Errorf("string out of range")
+		}
+		return string(data[pos : pos+length]), nil
+	case 0x6: // UTF-16 big-endian, length is in code units
+		if pos+length*2 > len(data) {
+			return "", fmt.Errorf("string out of range")
+		}
+		units := make([]uint16, length)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[pos+i*2:])
+		}
+		return string(utf16.Decode(units)), nil
+	default:
+		return "", fmt.Errorf("unsupported plist object type 0x%x", marker)
+	}
+}
+
+// readPlistDate decodes a date object at pos, stored as a big-endian float of
+// seconds since the start of 2001 UTC
+func readPlistDate(data []byte, pos int) (time.Time, error) {
+	if data[pos] != 0x33 {
+		return time.Time{}, fmt.Errorf("unsupported plist object type 0x%x", data[pos]>>4)
+	}
+	if pos+9 > len(data) {
+		return time.Time{}, fmt.Errorf("date out of range")
+	}
+	seconds := math.Float64frombits(binary.BigEndian.Uint64(data[pos+1:]))
+	if math.IsNaN(seconds) || math.Abs(seconds) > plistMaxSeconds {
+		return time.Time{}, fmt.Errorf("date out of range")
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(plistEpoch+int64(whole), int64(frac*1e9)), nil
+}
+
+// readPlistLength reads the length of the object at pos and returns the position of its contents
+func readPlistLength(data []byte, pos int) (int, int, error) {
+	length := int(data[pos] & 0x0F)
+	pos++
+	if length != 0x0F {
+		return length, pos, nil
+	}
+
+	// Longer lengths are stored as a following integer object
+	if pos >= len(data) || data[pos]>>4 != 0x1 {
+		return 0, 0, fmt.Errorf("invalid length")
+	}
+	size := 1 << (data[pos] & 0x0F)
+	pos++
+	if size > 4 || pos+size > len(data) {
+		return 0, 0, fmt.Errorf("invalid length")
+	}
+	return int(readBigEndian(data[pos : pos+size])), pos + size, nil
+}
+
+// readBigEndian reads an unsigned big-endian integer of up to 8 bytes
+func readBigEndian(b []byte) uint64 {
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+//go:build darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestOrganizeByTag(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tagged := filepath.Join(tmpDir, "tagged.txt")
+	untagged := filepath.Join(tmpDir, "untagged.txt")
+	if err := os.WriteFile(tagged, []byte("tagged content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(untagged, []byte("untagged content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tags := encodeTestBinaryPlist([]string{"Work\n6", "Home\n2"})
+	if err := unix.Setxattr(tagged, finderTagsAttr, tags, 0); err != nil {
+		t.Skipf("Cannot set extended attributes here: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeByTag(); err != nil {
+		t.Fatalf("OrganizeByTag() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "Work", "tagged.txt")); err != nil {
+		t.Errorf("Tagged file not moved to its tag folder: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "Untagged", "untagged.txt")); err != nil {
+		t.Errorf("Untagged file not moved to Untagged folder: %v", err)
+	}
+}
+
+func TestOrganizeBySource(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	downloaded := filepath.Join(tmpDir, "release.zip")
+	unknown := filepath.Join(tmpDir, "local.txt")
+	if err := os.WriteFile(downloaded, []byte("release content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(unknown, []byte("local content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	whereFroms := encodeTestBinaryPlist([]string{
+		"https://github.com/owner/repo/releases/download/v1/release.zip",
+		"https://github.com/owner/repo/releases",
+	})
+	if err := unix.Setxattr(downloaded, whereFromsAttr, whereFroms, 0); err != nil {
+		t.Skipf("Cannot set extended attributes here: %v", err)
+	}
+
+	scanner := NewScanner()
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	organizer := NewFileOrganizer(scanner, false, tmpDir)
+	if err := organizer.OrganizeBySource(); err != nil {
+		t.Fatalf("OrganizeBySource() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "github.com", "release.zip")); err != nil {
+		t.Errorf("Downloaded file not moved to its source folder: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "UnknownSource", "local.txt")); err != nil {
+		t.Errorf("File without a source not moved to UnknownSource: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseFinderTags(t *testing.T) {
+	data := encodeTestBinaryPlist([]string{"Work\n6", "Important\n2", "Café"})
+
+	tags, err := parseFinderTags(data)
+	if err != nil {
+		t.Fatalf("parseFinderTags() error = %v", err)
+	}
+
+	expected := []string{"Work", "Important", "Café"}
+	if len(tags) != len(expected) {
+		t.Fatalf("Expected %d tags, got %d: %v", len(expected), len(tags), tags)
+	}
+	for i := range expected {
+		if tags[i] != expected[i] {
+			t.Errorf("Tag %d: expected '%s', got '%s'", i, expected[i], tags[i])
+		}
+	}
+
+	// Garbage should be rejected rather than panicking
+	if _, err := parseFinderTags([]byte("not a plist")); err == nil {
+		t.Error("Expected error for invalid plist")
+	}
+	if _, err := parseFinderTags(data[:len(data)-4]); err == nil {
+		t.Error("Expected error for truncated plist")
+	}
+}
+
+func TestParseBinaryPlistDates(t *testing.T) {
+	downloaded := time.Date(2020, 2, 14, 18, 30, 15, 0, time.UTC)
+	data := encodeTestBinaryPlistDate(downloaded)
+
+	dates, err := parseBinaryPlistDates(data)
+	if err != nil {
+		t.Fatalf("parseBinaryPlistDates() error = %v", err)
+	}
+	if len(dates) != 1 || !dates[0].Equal(downloaded) {
+		t.Errorf("Expected [%v], got %v", downloaded, dates)
+	}
+
+	// A plist of strings isn't a date
+	if _, err := parseBinaryPlistDates(encodeTestBinaryPlist([]string{"Work"})); err == nil {
+		t.Error("Expected error for a plist of strings")
+	}
+}
+
+func TestPrimaryTagFolder(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+	}{
+		{nil, "Untagged"},
+		{[]string{"Work", "Home"}, "Work"},
+		{[]string{"Client/Project"}, "Client-Project"},
+		{[]string{"  "}, "Untagged"},
+		{[]string{".."}, "Untagged"},
+	}
+
+	for _, tt := range tests {
+		result := primaryTagFolder(tt.tags)
+		if result != tt.expected {
+			t.Errorf("primaryTagFolder(%v) = %s, want %s", tt.tags, result, tt.expected)
+		}
+	}
+}
+
+func TestSourceDomainFolder(t *testing.T) {
+	tests := []struct {
+		urls     []string
+		expected string
+	}{
+		{nil, "UnknownSource"},
+		{[]string{"https://github.com/owner/repo/releases/download/v1/app.zip", "https://github.com/owner/repo"}, "github.com"},
+		{[]string{"https://www.dropbox.com/s/abc/file.pdf?dl=1"}, "dropbox.com"},
+		{[]string{"", "https://Example.COM/file"}, "example.com"},
+		{[]string{"not a url"}, "UnknownSource"},
+	}
+
+	for _, tt := range tests {
+		result := sourceDomainFolder(tt.urls)
+		if result != tt.expected {
+			t.Errorf("sourceDomainFolder(%v) = %s, want %s", tt.urls, result, tt.expected)
+		}
+	}
+}
+
+// encodeTestBinaryPlist encodes an array of strings as a binary plist, the way Finder stores tags
+func encodeTestBinaryPlist(values []string) []byte {
+	data := []byte("bplist00")
+	offsets := []int{}
+
+	// Object 0 is the array, objects 1..n are the strings
+	offsets = append(offsets, len(data))
+	data = append(data, 0xA0|byte(len(values)))
+	for i := range values {
+		data = append(data, byte(i+1))
+	}
+
+	for _, value := range values {
+		offsets = append(offsets, len(data))
+		ascii := true
+		for _, r := range value {
+			if r > 0x7F {
+				ascii = false
+			}
+		}
+		if ascii {
+			data = append(data, 0x50|byte(len(value)))
+			data = append(data, value...)
+		} else {
+			units := []rune(value)
+			data = append(data, 0x60|byte(len(units)))
+			for _, r := range units {
+				data = append(data, byte(r>>8), byte(r))
+			}
+		}
+	}
+
+	offsetTable := len(data)
+	for _, offset := range offsets {
+		data = append(data, byte(offset))
+	}
+
+	trailer := make([]byte, 32)
+	trailer[6] = 1 // offset size
+	trailer[7] = 1 // object ref size
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(offsets)))
+	binary.BigEndian.PutUint64(trailer[16:], 0)
+	binary.BigEndian.PutUint64(trailer[24:], uint64(offsetTable))
+	return append(data, trailer...)
+}
+
+// encodeTestBinaryPlistDate builds a binary plist holding an array with one
+// date, the way macOS stores when a file was downloaded
+func encodeTestBinaryPlistDate(date time.Time) []byte {
+	data := []byte("bplist00")
+	offsets := []int{len(data)}
+	data = append(data, 0xA1, 1)
+
+	offsets = append(offsets, len(data))
+	seconds := float64(date.UnixNano()-plistEpoch*int64(time.Second)) / float64(time.Second)
+	data = append(data, 0x33)
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(seconds))
+
+	offsetTable := len(data)
+	for _, offset := range offsets {
+		data = append(data, byte(offset))
+	}
+
+	trailer := make([]byte, 32)
+	trailer[6] = 1 // offset size
+	trailer[7] = 1 // object ref size
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(offsets)))
+	binary.BigEndian.PutUint64(trailer[16:], 0)
+	binary.BigEndian.PutUint64(trailer[24:], uint64(offsetTable))
+	return append(data, trailer...)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minThrottleBurst is the least a rate limiter lets through at once after
+// being idle, so a single read buffer never has to wait for itself
+const minThrottleBurst = 64 * 1024
+
+// RateLimiter is a token bucket limiting how fast file data is read and
+// copied. One limiter is shared by the scanner, organizer and duplicate
+// handler, so --throttle caps their combined throughput. A nil limiter
+// doesn't limit anything.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Bytes per second
+	burst  float64 // Most bytes saved up while idle
+	tokens float64 // Bytes that can go through right now; negative while paying back a large read
+	last   time.Time
+
+	now   func() time.Time    // Overrides time.Now in tests
+	sleep func(time.Duration) // Overrides time.Sleep in tests
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSecond on average
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	burst := float64(bytesPerSecond) / 10
+	if burst < minThrottleBurst {
+		burst = minThrottleBurst
+	}
+	return &RateLimiter{
+		rate:  float64(bytesPerSecond),
+		burst: burst,
+		last:  time.Now(),
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// ParseThrottle parses a --throttle rate like "20MB" or "20MB/s" into bytes per second
+func ParseThrottle(rate string) (int64, error) {
+	bytesPerSecond, err := parseByteSize(strings.TrimSuffix(strings.TrimSpace(rate), "/s"))
+	if err != nil || bytesPerSecond < 1 {
+		return 0, fmt.Errorf("invalid throttle rate %q (use something like 20MB or 500KB/s)", rate)
+	}
+	return bytesPerSecond, nil
+}
+
+// Wait blocks until n more bytes can go through without going over the rate
+func (l *RateLimiter) Wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.sleep(wait)
+	}
+}
+
+// Reader wraps r so reading from it is held to the limiter's rate
+func (l *RateLimiter) Reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &throttledReader{r: r, limiter: l}
+}
+
+// throttledReader waits on a rate limiter after each read
+type throttledReader struct {
+	r       io.Reader
+	limiter *RateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.limiter.Wait(n)
+	return n, err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterStaysUnderRate(t *testing.T) {
+	// A fake clock that only moves when the limiter sleeps
+	now := time.Unix(0, 0)
+	limiter := NewRateLimiter(1024 * 1024)
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { now = now.Add(d) }
+	limiter.last = now
+
+	data := bytes.Repeat([]byte("x"), 5*1024*1024)
+	start := now
+	copied, err := io.CopyBuffer(io.Discard, limiter.Reader(bytes.NewReader(data)), make([]byte, 32*1024))
+	if err != nil {
+		t.Fatalf("Copy error = %v", err)
+	}
+	elapsed := now.Sub(start).Seconds()
+	if rate := float64(copied) / elapsed; rate > 1024*1024*1.001 {
+		t.Errorf("Copied at %.0f bytes/s, over the 1048576 bytes/s limit", rate)
+	}
+}
+
+func TestRateLimiterSlowsRealReads(t *testing.T) {
+	// 200KB at 1MB/s should take close to 200ms
+	limiter := NewRateLimiter(1024 * 1024)
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, limiter.Reader(bytes.NewReader(make([]byte, 200*1024)))); err != nil {
+		t.Fatalf("Copy error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected reading 200KB at 1MB/s to take about 200ms, took %v", elapsed)
+	}
+}
+
+func TestParseThrottle(t *testing.T) {
+	tests := map[string]int64{"20MB": 20 * 1024 * 1024, "500KB/s": 500 * 1024, "1G/s": 1024 * 1024 * 1024}
+	for rate, want := range tests {
+		if got, err := ParseThrottle(rate); err != nil || got != want {
+			t.Errorf("ParseThrottle(%q) = %d, %v, want %d", rate, got, err, want)
+		}
+	}
+	for _, rate := range []string{"", "0", "fast"} {
+		if _, err := ParseThrottle(rate); err == nil {
+			t.Errorf("ParseThrottle(%q) expected an error", rate)
+		}
+	}
+}
+
+func TestNilRateLimiter(t *testing.T) {
+	var limiter *RateLimiter
+	r := bytes.NewReader([]byte("data"))
+	if limiter.Reader(r) != io.Reader(r) {
+		t.Error("Expected a nil limiter to leave the reader alone")
+	}
+	limiter.Wait(100)
+}
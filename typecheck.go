@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// TypeMismatch is a file whose content doesn't look like what its extension
+// says, such as a .pdf that is really a zip or an HTML error page
+type TypeMismatch struct {
+	Path         string `json:"path"`
+	Extension    string `json:"extension"`
+	ExpectedType string `json:"expected_type"` // MIME type the extension stands for
+	DetectedType string `json:"detected_type"` // MIME type sniffed from the content
+}
+
+// TypeReport is the outcome of CheckFileTypes
+type TypeReport struct {
+	Checked    int            `json:"checked"`
+	Mismatches []TypeMismatch `json:"mismatches"`
+}
+
+// zipBasedExtensions are formats stored as zips, whose content sniffs as one
+var zipBasedExtensions = map[string]bool{
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".ods": true, ".odp": true,
+	".epub": true, ".jar": true, ".apk": true, ".xpi": true, ".ipa": true, ".cbz": true,
+	".kmz": true, ".whl": true, ".nupkg": true, ".vsix": true, ".3mf": true,
+}
+
+// binaryTypes are non-media types with a signature content sniffing knows,
+// so a file of one of them that sniffs as text is misnamed
+var binaryTypes = map[string]bool{
+	"pdf": true, "zip": true, "gzip": true, "rar": true, "7z-compressed": true, "wasm": true, "ogg": true,
+}
+
+// mimeAliases are subtypes that name the same format
+var mimeAliases = map[string]string{
+	"wave":           "wav",
+	"vnd.rar":        "rar",
+	"rar-compressed": "rar",
+	"matroska":       "webm", // WebM is a kind of Matroska and sniffs the same
+	"msvideo":        "avi",
+	"mpeg3":          "mpeg",
+	"mp3":            "mpeg",
+}
+
+// CheckFileTypes compares the content of each file with its extension and
+// reports the ones that disagree. Files with no known extension, or whose
+// content doesn't have a recognizable signature, are left out.
+func CheckFileTypes(files []FileInfo) TypeReport {
+	report := TypeReport{Mismatches: []TypeMismatch{}}
+	for _, file := range files {
+		expected := extensionMimeType(file.Extension)
+		if expected == "" {
+			continue
+		}
+		detected := sniffMimeType(file.Path)
+		if detected == "" {
+			continue
+		}
+		report.Checked++
+		if !typesAgree(strings.ToLower(file.Extension), expected, detected) {
+			report.Mismatches = append(report.Mismatches, TypeMismatch{
+				Path:         file.Path,
+				Extension:    file.Extension,
+				ExpectedType: baseMimeType(expected),
+				DetectedType: baseMimeType(detected),
+			})
+		}
+	}
+	sort.Slice(report.Mismatches, func(i, j int) bool { return report.Mismatches[i].Path < report.Mismatches[j].Path })
+	return report
+}
+
+// typesAgree reports whether content sniffed as detected fits a file with
+// extension ext, which stands for expected. Sniffing only tells plain text
+// apart from binary formats it has a signature for, so any text fits any
+// textual type, and formats stored as zips fit a zip.
+func typesAgree(ext, expected, detected string) bool {
+	expectedTop, expectedSub := splitMimeType(expected)
+	detectedTop, detectedSub := splitMimeType(detected)
+	if expectedSub == detectedSub {
+		return true // audio/mp4 and video/mp4 or audio/ogg and application/ogg are the same container
+	}
+	if detectedTop == "text" {
+		return !isBinaryType(expectedTop, expectedSub)
+	}
+	return detectedSub == "zip" && zipBasedExtensions[ext]
+}
+
+// isBinaryType reports whether a file of this type can't be plain text
+func isBinaryType(top, sub string) bool {
+	switch top {
+	case "image":
+		return !strings.HasSuffix(sub, "+xml")
+	case "audio", "video":
+		return true
+	}
+	return binaryTypes[sub]
+}
+
+// splitMimeType returns the top-level type and subtype of a MIME type, without
+// parameters, the "x-" prefix and other differences in naming the same format
+func splitMimeType(mimeType string) (string, string) {
+	top, sub, _ := strings.Cut(baseMimeType(mimeType), "/")
+	sub = strings.TrimPrefix(sub, "x-")
+	if alias, ok := mimeAliases[sub]; ok {
+		sub = alias
+	}
+	return top, sub
+}
+
+// baseMimeType drops parameters such as the charset from a MIME type
+func baseMimeType(mimeType string) string {
+	if base, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return base
+	}
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
+
+// Print prints the mismatches as a readable list
+func (report TypeReport) Print() {
+	if len(report.Mismatches) == 0 {
+		successColor.Printf("✅ Checked %d files, every one looks like its extension says\n", report.Checked)
+		return
+	}
+
+	warningColor.Printf("🕵️  %d of %d files don't look like their extension says:\n", len(report.Mismatches), report.Checked)
+	for _, mismatch := range report.Mismatches {
+		fmt.Printf("  - %s: named %s (%s), but looks like %s\n", mismatch.Path, mismatch.Extension, mismatch.ExpectedType, mismatch.DetectedType)
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (report TypeReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
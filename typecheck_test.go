@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckFileTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A zip renamed to .pdf, next to files whose content fits their names
+	zipPath := filepath.Join(tmpDir, "invoice.pdf")
+	if err := createTestZip(zipPath, map[string]string{"invoice.txt": "total: 42"}); err != nil {
+		t.Fatalf("Failed to create test zip: %v", err)
+	}
+	docxPath := filepath.Join(tmpDir, "letter.docx")
+	if err := createTestZip(docxPath, map[string]string{"word/document.xml": "<w:document/>"}); err != nil {
+		t.Fatalf("Failed to create test zip: %v", err)
+	}
+	files := map[string][]byte{
+		"notes.txt":  []byte("just some notes\n"),
+		"data.json":  []byte(`{"elf": true}`),
+		"photo.png":  []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"error.jpg":  []byte("<!DOCTYPE html><html><body>Not found</body></html>"),
+		"blob.elfxx": []byte{0x00, 0x01, 0x02},
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), content, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	scanner := NewScanner()
+	scanner.SkipHashing = true
+	if err := scanner.ScanDirectory(tmpDir); err != nil {
+		t.Fatalf("Failed to scan directory: %v", err)
+	}
+
+	report := CheckFileTypes(scanner.Files)
+	if report.Checked != 6 {
+		t.Errorf("Expected 6 files checked, got %d", report.Checked)
+	}
+	if len(report.Mismatches) != 2 {
+		t.Fatalf("Expected 2 mismatches, got %+v", report.Mismatches)
+	}
+
+	// Mismatches are sorted by path
+	errorPage, renamedZip := report.Mismatches[0], report.Mismatches[1]
+	if errorPage.Path != filepath.Join(tmpDir, "error.jpg") || errorPage.DetectedType != "text/html" {
+		t.Errorf("Expected error.jpg reported as text/html, got %+v", errorPage)
+	}
+	if renamedZip.Path != zipPath || renamedZip.ExpectedType != "application/pdf" || renamedZip.DetectedType != "application/zip" {
+		t.Errorf("Expected invoice.pdf reported as a zip, got %+v", renamedZip)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// xattrSupported reports whether macOS metadata attributes can be read on this platform.
+// Linux has extended attributes, but never the Finder ones.
+const xattrSupported = false
+
+// readXattr returns the value of an extended attribute, or nil if the file doesn't have it
+func readXattr(path, attr string) ([]byte, error) {
+	size, err := unix.Getxattr(path, attr, nil)
+	if err == unix.ENODATA || err == unix.ENOTSUP {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Getxattr(path, attr, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
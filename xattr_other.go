@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package main
+
+import "fmt"
+
+// xattrSupported reports whether macOS metadata attributes can be read on this platform
+const xattrSupported = false
+
+// readXattr returns the value of an extended attribute, or nil if the file doesn't have it
+func readXattr(path, attr string) ([]byte, error) {
+	return nil, fmt.Errorf("macOS metadata attributes are only supported on macOS")
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package main
+
+// xattrCopySupported reports whether copyXattrs can carry extended attributes over on this platform
+const xattrCopySupported = false
+
+// copyXattrs does nothing where extended attributes aren't supported
+func copyXattrs(src, dst string) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrCopySupported reports whether copyXattrs can carry extended attributes over on this platform
+const xattrCopySupported = true
+
+// copyXattrs copies every extended attribute of src onto dst. On Linux this
+// includes POSIX ACLs and SELinux labels, which are stored as attributes.
+// Attributes that can't be set, such as ones only root may write, are
+// skipped, and the first such error is returned once the rest are copied.
+func copyXattrs(src, dst string) error {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		if err == unix.ENOTSUP {
+			return nil
+		}
+		return err
+	}
+	list := make([]byte, size)
+	size, err = unix.Listxattr(src, list)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		value, err := readXattr(src, attr)
+		if err == nil {
+			err = unix.Setxattr(dst, attr, value, 0)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("cannot copy attribute %s: %v", attr, err)
+		}
+	}
+	return firstErr
+}
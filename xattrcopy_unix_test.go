@@ -0,0 +1,52 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCopyAndDeletePreservesXattrs(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "tagged.txt")
+	dst := filepath.Join(tmpDir, "moved.txt")
+	if err := os.WriteFile(src, []byte("tagged content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	const attr = "user.elf-cli.test"
+	if err := unix.Setxattr(src, attr, []byte("kept"), 0); err != nil {
+		t.Skipf("Extended attributes aren't supported here: %v", err)
+	}
+
+	// copyAndDelete is how moves to another drive are done
+	organizer := NewFileOrganizer(nil, false, tmpDir)
+	organizer.PreserveXattrs = true
+	if err := organizer.copyAndDelete(src, dst); err != nil {
+		t.Fatalf("copyAndDelete() error = %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected the source to be removed, got %v", err)
+	}
+	value, err := readXattr(dst, attr)
+	if err != nil || string(value) != "kept" {
+		t.Errorf("Expected %s to survive the move, got %q (err %v)", attr, value, err)
+	}
+
+	// Without the option only the content is copied
+	if err := os.WriteFile(src, []byte("tagged content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	unix.Setxattr(src, attr, []byte("kept"), 0)
+	plain := filepath.Join(tmpDir, "plain.txt")
+	organizer.PreserveXattrs = false
+	if err := organizer.copyAndDelete(src, plain); err != nil {
+		t.Fatalf("copyAndDelete() error = %v", err)
+	}
+	if value, _ := readXattr(plain, attr); value != nil {
+		t.Errorf("Expected no attributes to be copied without PreserveXattrs, got %q", value)
+	}
+}